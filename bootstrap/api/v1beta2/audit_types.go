@@ -0,0 +1,145 @@
+package v1beta2
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// AuditPolicyFilePath is the path of the audit policy on the control plane nodes.
+	AuditPolicyFilePath = "/etc/kubernetes/audit-policy.yaml"
+
+	// AuditWebhookConfigFilePath is the path of the kubeconfig of the audit webhook backend on the control plane nodes.
+	AuditWebhookConfigFilePath = "/etc/kubernetes/audit-webhook.conf"
+
+	// DefaultAuditLogPath is the path of the audit log when the log path is unset.
+	DefaultAuditLogPath = "/var/log/kubernetes/audit.log"
+
+	// DefaultAuditPolicyConfigMapKey is the default key of the audit policy in the referenced ConfigMap.
+	DefaultAuditPolicyConfigMapKey = "policy.yaml"
+
+	// DefaultAuditWebhookConfigSecretKey is the default key of the audit webhook kubeconfig in the referenced secret.
+	DefaultAuditWebhookConfigSecretKey = "value"
+
+	// AuditPolicyHashAnnotation records the hash of the audit policy a control plane node was bootstrapped with.
+	// Control plane machines whose hash does not match the referenced ConfigMap are rolled out.
+	AuditPolicyHashAnnotation = "v1beta2.k8sd.io/audit-policy-hash"
+)
+
+// AuditConfig configures the audit logging of the Kubernetes API server.
+type AuditConfig struct {
+	// PolicyConfigMapRef is a reference to a ConfigMap holding the audit policy.
+	// If the key is unset, "policy.yaml" is used.
+	// Changes of the policy are rolled out by replacing the control plane machines.
+	PolicyConfigMapRef ConfigMapRef `json:"policyConfigMapRef"`
+
+	// LogPath is the path of the audit log on the control plane nodes.
+	// If unset, "/var/log/kubernetes/audit.log" is used. Set to "-" to log to standard output.
+	// +optional
+	LogPath string `json:"logPath,omitempty"`
+
+	// MaxAge is the maximum number of days to retain old audit log files.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAge *int `json:"maxAge,omitempty"`
+
+	// MaxBackup is the maximum number of old audit log files to retain.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxBackup *int `json:"maxBackup,omitempty"`
+
+	// MaxSize is the maximum size in megabytes of the audit log file before it gets rotated.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxSize *int `json:"maxSize,omitempty"`
+
+	// Webhook configures an audit webhook backend, in addition to the log backend.
+	// +optional
+	Webhook *AuditWebhookConfig `json:"webhook,omitempty"`
+}
+
+// AuditWebhookConfig configures an audit webhook backend.
+type AuditWebhookConfig struct {
+	// ConfigSecretRef is a reference to a secret holding the kubeconfig of the webhook.
+	// If the key is unset, "value" is used.
+	ConfigSecretRef SecretRef `json:"configSecretRef"`
+
+	// Mode is the strategy for sending audit events. If unset, kube-apiserver uses "batch".
+	// +kubebuilder:validation:Enum=batch;blocking;blocking-strict
+	// +optional
+	Mode string `json:"mode,omitempty"`
+}
+
+// ConfigMapRef is a reference to a key of a ConfigMap.
+type ConfigMapRef struct {
+	// Name of the ConfigMap in the CK8sBootstrapConfig's namespace to use.
+	Name string `json:"name"`
+
+	// Key is the key in the ConfigMap's data map for this value.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// GetPolicyKey returns the key of the audit policy in the referenced ConfigMap.
+func (c *AuditConfig) GetPolicyKey() string {
+	if c.PolicyConfigMapRef.Key == "" {
+		return DefaultAuditPolicyConfigMapKey
+	}
+	return c.PolicyConfigMapRef.Key
+}
+
+// GetLogPath returns the path of the audit log.
+func (c *AuditConfig) GetLogPath() string {
+	if c.LogPath == "" {
+		return DefaultAuditLogPath
+	}
+	return c.LogPath
+}
+
+// WebhookConfigFile returns the file the kubeconfig of the audit webhook is written to on the control plane nodes,
+// or nil if no webhook is configured.
+func (c *AuditConfig) WebhookConfigFile() *File {
+	if c == nil || c.Webhook == nil {
+		return nil
+	}
+
+	key := c.Webhook.ConfigSecretRef.Key
+	if key == "" {
+		key = DefaultAuditWebhookConfigSecretKey
+	}
+	return &File{
+		Path:        AuditWebhookConfigFilePath,
+		Permissions: "0600",
+		ContentFrom: &FileSource{
+			Secret: SecretFileSource{
+				Name: c.Webhook.ConfigSecretRef.Name,
+				Key:  key,
+			},
+		},
+	}
+}
+
+// ValidateAudit validates the audit configuration of a control plane.
+func (c *CK8sControlPlaneConfig) ValidateAudit(fldPath *field.Path) field.ErrorList {
+	if c.Audit == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	auditPath := fldPath.Child("audit")
+	if c.Audit.PolicyConfigMapRef.Name == "" {
+		allErrs = append(allErrs, field.Required(auditPath.Child("policyConfigMapRef", "name"), "must reference a ConfigMap"))
+	}
+	if c.Audit.Webhook != nil && c.Audit.Webhook.ConfigSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(auditPath.Child("webhook", "configSecretRef", "name"), "must reference a secret"))
+	}
+
+	// NOTE: the typed configuration would silently override these, so they are rejected instead.
+	for arg := range c.ExtraKubeAPIServerArgs {
+		if strings.HasPrefix(strings.TrimLeft(arg, "-"), "audit-") {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("extraKubeAPIServerArgs").Key(arg), "audit arguments must be set through audit"))
+		}
+	}
+	return allErrs
+}
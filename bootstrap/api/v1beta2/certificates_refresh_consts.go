@@ -3,6 +3,15 @@ package v1beta2
 const (
 	CertificatesRefreshAnnotation       = "v1beta2.k8sd.io/refresh-certificates"
 	CertificatesRefreshStatusAnnotation = "v1beta2.k8sd.io/refresh-certificates-status"
+
+	// CertificatesRefreshFailedAtAnnotation records when the last certificates refresh of the machine failed.
+	CertificatesRefreshFailedAtAnnotation = "v1beta2.k8sd.io/refresh-certificates-failed-at"
+)
+
+const (
+	// CertificatesRefreshMaxConcurrencyAnnotation sets how many machines are refreshed at the same time when
+	// the certificates refresh is requested on a CK8sControlPlane, MachineDeployment or Cluster. Defaults to 1.
+	CertificatesRefreshMaxConcurrencyAnnotation = "v1beta2.k8sd.io/refresh-certificates-max-concurrency"
 )
 
 const (
@@ -0,0 +1,70 @@
+package v1beta2
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+)
+
+const (
+	// DefaultCertificatesRenewalExpiryThreshold is the default time before expiry to renew the certificates of a machine.
+	DefaultCertificatesRenewalExpiryThreshold = "30d"
+
+	// DefaultCertificatesRenewalTTL is the default validity of the renewed certificates.
+	DefaultCertificatesRenewalTTL = "1y"
+)
+
+// CertificatesRenewalPolicy configures the automatic renewal of the machine certificates.
+// Machines whose certificates expire within the threshold are refreshed one at a time.
+type CertificatesRenewalPolicy struct {
+	// ExpiryThreshold is how long before expiry the certificates of a machine are renewed.
+	// It is a number followed by a unit: y (years), mo (months), d (days) or
+	// any unit supported by time.ParseDuration. Defaults to "30d". It must be less than the TTL.
+	// +optional
+	ExpiryThreshold string `json:"expiryThreshold,omitempty"`
+
+	// TTL is how long the renewed certificates are valid for, in the same format as ExpiryThreshold.
+	// Defaults to "1y".
+	// +optional
+	TTL string `json:"ttl,omitempty"`
+}
+
+// GetExpiryThreshold returns the ExpiryThreshold field.
+// If the field is not set, it returns DefaultCertificatesRenewalExpiryThreshold.
+func (p *CertificatesRenewalPolicy) GetExpiryThreshold() string {
+	if p == nil || p.ExpiryThreshold == "" {
+		return DefaultCertificatesRenewalExpiryThreshold
+	}
+	return p.ExpiryThreshold
+}
+
+// GetTTL returns the TTL field.
+// If the field is not set, it returns DefaultCertificatesRenewalTTL.
+func (p *CertificatesRenewalPolicy) GetTTL() string {
+	if p == nil || p.TTL == "" {
+		return DefaultCertificatesRenewalTTL
+	}
+	return p.TTL
+}
+
+// Validate checks that the expiry threshold and the TTL are valid, and that the threshold is below the TTL,
+// since the renewed certificates would otherwise be renewed again right away.
+func (p *CertificatesRenewalPolicy) Validate(fldPath *field.Path) field.ErrorList {
+	if p == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	threshold, err := utiltime.TTLToSeconds(p.GetExpiryThreshold())
+	if err != nil || threshold <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("expiryThreshold"), p.ExpiryThreshold, "must be a positive duration"))
+	}
+	ttl, err := utiltime.TTLToSeconds(p.GetTTL())
+	if err != nil || ttl <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), p.TTL, "must be a positive duration"))
+	}
+	if len(allErrs) == 0 && threshold >= ttl {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("expiryThreshold"), p.GetExpiryThreshold(), "must be less than the ttl "+p.GetTTL()))
+	}
+	return allErrs
+}
@@ -190,6 +190,24 @@ type CK8sControlPlaneConfig struct {
 	// ExtraKubeSchedulerArgs - extra arguments to add to kube-scheduler.
 	// +optional
 	ExtraKubeSchedulerArgs map[string]*string `json:"extraKubeSchedulerArgs,omitempty"`
+
+	// Authentication configures how the Kubernetes API server authenticates users.
+	// +optional
+	Authentication *AuthenticationConfig `json:"authentication,omitempty"`
+
+	// Audit configures the audit logging of the Kubernetes API server.
+	// +optional
+	Audit *AuditConfig `json:"audit,omitempty"`
+
+	// EncryptionAtRest enables the encryption of secrets in the datastore with a key managed by the provider.
+	// The key is generated once per cluster and is not rotated.
+	// +optional
+	EncryptionAtRest *EncryptionAtRestConfig `json:"encryptionAtRest,omitempty"`
+
+	// K8sdProxy configures the k8sd-proxy daemonset. Changes are applied to the workload cluster
+	// by the control plane provider, without rolling out the control plane machines.
+	// +optional
+	K8sdProxy *K8sdProxyConfig `json:"k8sdProxy,omitempty"`
 }
 
 // GetMicroclusterPort returns the port to use for microcluster.
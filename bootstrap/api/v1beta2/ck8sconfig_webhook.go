@@ -18,8 +18,11 @@ package v1beta2
 
 import (
 	"context"
+	"fmt"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -40,13 +43,13 @@ var _ admission.CustomDefaulter = &CK8sConfig{}
 var _ admission.CustomValidator = &CK8sConfig{}
 
 // ValidateCreate will do any extra validation when creating a CK8sControlPlane.
-func (c *CK8sConfig) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
-	return []string{}, nil
+func (c *CK8sConfig) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sConfig(obj)
 }
 
 // ValidateUpdate will do any extra validation when updating a CK8sControlPlane.
-func (c *CK8sConfig) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
-	return []string{}, nil
+func (c *CK8sConfig) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sConfig(newObj)
 }
 
 // ValidateDelete allows you to add any extra validation when deleting.
@@ -58,3 +61,19 @@ func (c *CK8sConfig) ValidateDelete(_ context.Context, _ runtime.Object) (admiss
 func (c *CK8sConfig) Default(_ context.Context, _ runtime.Object) error {
 	return nil
 }
+
+func validateCK8sConfig(obj runtime.Object) error {
+	c, ok := obj.(*CK8sConfig)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a CK8sConfig but got a %T", obj))
+	}
+
+	fldPath := field.NewPath("spec", "controlPlane")
+	allErrs := c.Spec.ControlPlaneConfig.ValidateAuthentication(fldPath)
+	allErrs = append(allErrs, c.Spec.ControlPlaneConfig.ValidateAudit(fldPath)...)
+	allErrs = append(allErrs, c.Spec.ControlPlaneConfig.ValidateEncryptionAtRest(fldPath)...)
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("CK8sConfig").GroupKind(), c.Name, allErrs)
+	}
+	return nil
+}
@@ -25,6 +25,11 @@ type CK8sConfigTemplateSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Template CK8sConfigTemplateResource `json:"template"`
+
+	// CertificatesRenewal configures the automatic renewal of the certificates of the
+	// machines of the MachineDeployments using this template.
+	// +optional
+	CertificatesRenewal *CertificatesRenewalPolicy `json:"certificatesRenewal,omitempty"`
 }
 
 // CK8sConfigTemplateResource defines the Template structure.
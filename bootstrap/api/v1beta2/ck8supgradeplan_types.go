@@ -0,0 +1,269 @@
+package v1beta2
+
+import (
+	"fmt"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+// UpgradeTarget is the release the machines are upgraded to. Exactly one of the fields must be set.
+// +kubebuilder:validation:MinProperties=1
+// +kubebuilder:validation:MaxProperties=1
+type UpgradeTarget struct {
+	// Channel is the snap channel the k8s snap is refreshed to, e.g. "1.31-classic/stable".
+	// +optional
+	Channel string `json:"channel,omitempty"`
+
+	// Revision is the snap revision the k8s snap is refreshed to.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// LocalPath is the path of a snap file on the machines the k8s snap is refreshed to.
+	// +optional
+	LocalPath string `json:"localPath,omitempty"`
+
+	// Version is the Kubernetes version the machines are upgraded to, e.g. "v1.31.2".
+	// It is resolved to the snap revision it is mapped to by the VersionMap of the plan if set, or to the
+	// channel of its minor version with the Risk of the plan otherwise.
+	// +kubebuilder:validation:Pattern=`^v?\d+\.\d+\.\d+$`
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+var upgradeTargetVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+
+// GetRelease returns the release of the target, in the format of the in-place upgrade annotations.
+func (t *UpgradeTarget) GetRelease() (string, error) {
+	var releases []string
+	if t.Channel != "" {
+		releases = append(releases, "channel="+t.Channel)
+	}
+	if t.Revision != "" {
+		releases = append(releases, "revision="+t.Revision)
+	}
+	if t.LocalPath != "" {
+		releases = append(releases, "localPath="+t.LocalPath)
+	}
+	if t.Version != "" {
+		match := upgradeTargetVersionRegexp.FindStringSubmatch(t.Version)
+		if match == nil {
+			return "", fmt.Errorf("invalid version %q", t.Version)
+		}
+		releases = append(releases, fmt.Sprintf("version=v%s.%s.%s", match[1], match[2], match[3]))
+	}
+
+	if len(releases) != 1 {
+		return "", fmt.Errorf("exactly one of channel, revision, localPath or version must be set")
+	}
+	return releases[0], nil
+}
+
+// UpgradePlanHealthGates are the checks an upgraded machine must pass before the next machines are upgraded.
+type UpgradePlanHealthGates struct {
+	// Node checks that the node is Ready and reports the Kubernetes version of the target, if known.
+	// +optional
+	Node bool `json:"node,omitempty"`
+
+	// ControlPlane checks that the API server of the workload cluster is ready.
+	// +optional
+	ControlPlane bool `json:"controlPlane,omitempty"`
+
+	// Datastore checks that the node of a control plane machine is a member of the datastore cluster.
+	// +optional
+	Datastore bool `json:"datastore,omitempty"`
+
+	// Service is the "namespace/name:port/path" of a Service of the workload cluster that must respond with
+	// a 2xx status code. It is requested over HTTP through the API server of the workload cluster.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Job is the "namespace/name" of a CronJob of the workload cluster. A Job is created from its template
+	// for each upgraded machine, and must complete.
+	// +optional
+	Job string `json:"job,omitempty"`
+
+	// Timeout is how long a machine may take to pass the health gates, before they are reported as failed.
+	// Defaults to 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// CK8sUpgradePlanSpec defines the desired state of CK8sUpgradePlan.
+type CK8sUpgradePlanSpec struct {
+	// ClusterName is the name of the Cluster, in the same namespace, whose machines are upgraded.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// Target is the release the machines are upgraded to.
+	Target UpgradeTarget `json:"target"`
+
+	// MachineSelector selects the machines of the cluster that are upgraded, by their labels.
+	// All the machines of the cluster are selected if it is empty.
+	// +optional
+	MachineSelector metav1.LabelSelector `json:"machineSelector,omitempty"`
+
+	// MaxConcurrency is how many worker machines are upgraded at the same time. The control plane machines
+	// are upgraded first, one at a time. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConcurrency *int32 `json:"maxConcurrency,omitempty"`
+
+	// Drain drains the nodes before their upgrade.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+
+	// Rollback reverts the k8s snap to its previous revision if the upgrade of a machine fails.
+	// +optional
+	Rollback bool `json:"rollback,omitempty"`
+
+	// Risk is the risk level of the snap channel a target version is resolved to. Defaults to "stable".
+	// +kubebuilder:validation:Enum=stable;candidate;beta;edge
+	// +optional
+	Risk string `json:"risk,omitempty"`
+
+	// VersionMap is the name of a ConfigMap in the namespace of the plan that maps Kubernetes versions
+	// (e.g. "v1.31.2") to snap revisions. If set, a target version is resolved to the revision it is mapped to.
+	// +optional
+	VersionMap string `json:"versionMap,omitempty"`
+
+	// HealthGates are the checks an upgraded machine must pass before the next machines are upgraded.
+	// +optional
+	HealthGates *UpgradePlanHealthGates `json:"healthGates,omitempty"`
+}
+
+// GetMaxConcurrency returns the MaxConcurrency field.
+// If the field is not set, it returns 1.
+func (s *CK8sUpgradePlanSpec) GetMaxConcurrency() int {
+	if s.MaxConcurrency == nil || *s.MaxConcurrency < 1 {
+		return 1
+	}
+	return int(*s.MaxConcurrency)
+}
+
+// UpgradePlanPhase is the phase of a CK8sUpgradePlan.
+type UpgradePlanPhase string
+
+const (
+	// UpgradePlanPendingPhase is the phase of a plan that did not start upgrading machines yet.
+	UpgradePlanPendingPhase UpgradePlanPhase = "Pending"
+	// UpgradePlanInProgressPhase is the phase of a plan that is upgrading machines.
+	UpgradePlanInProgressPhase UpgradePlanPhase = "InProgress"
+	// UpgradePlanFailedPhase is the phase of a plan with a failed machine. No new upgrades are started, and
+	// the failed machine is retried.
+	UpgradePlanFailedPhase UpgradePlanPhase = "Failed"
+	// UpgradePlanCompletedPhase is the phase of a plan whose machines are all upgraded. Machines selected
+	// afterwards are upgraded as well.
+	UpgradePlanCompletedPhase UpgradePlanPhase = "Completed"
+)
+
+// MachineUpgradePhase is the phase of the upgrade of a machine of a CK8sUpgradePlan.
+type MachineUpgradePhase string
+
+const (
+	MachineUpgradePendingPhase        MachineUpgradePhase = "Pending"
+	MachineUpgradeUpgradingPhase      MachineUpgradePhase = "Upgrading"
+	MachineUpgradeHealthCheckingPhase MachineUpgradePhase = "HealthChecking"
+	MachineUpgradeSucceededPhase      MachineUpgradePhase = "Succeeded"
+	MachineUpgradeFailedPhase         MachineUpgradePhase = "Failed"
+	MachineUpgradeRolledBackPhase     MachineUpgradePhase = "RolledBack"
+)
+
+// MachineUpgradeStatus is the progress of the upgrade of a machine of a CK8sUpgradePlan.
+type MachineUpgradeStatus struct {
+	// Name is the name of the machine.
+	Name string `json:"name"`
+
+	// Phase is the phase of the upgrade of the machine.
+	Phase MachineUpgradePhase `json:"phase"`
+
+	// StartedAt is when the upgrade of the machine started.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the machine was upgraded.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// LastError is the last error of the upgrade of the machine.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// CK8sUpgradePlanStatus defines the observed state of CK8sUpgradePlan.
+type CK8sUpgradePlanStatus struct {
+	// Phase is the phase of the plan.
+	// +optional
+	Phase UpgradePlanPhase `json:"phase,omitempty"`
+
+	// Release is the release the machines are upgraded to, as resolved from the target.
+	// +optional
+	Release string `json:"release,omitempty"`
+
+	// Machines is the progress of the upgrade of the selected machines.
+	// +optional
+	Machines []MachineUpgradeStatus `json:"machines,omitempty"`
+
+	// UpgradedMachines is the number of selected machines that are upgraded.
+	// +optional
+	UpgradedMachines int32 `json:"upgradedMachines"`
+
+	// StartedAt is when the plan started upgrading machines.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is when all the selected machines were upgraded.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the CK8sUpgradePlan.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.clusterName",description="Cluster whose machines are upgraded"
+// +kubebuilder:printcolumn:name="Release",type=string,JSONPath=".status.release",description="Release the machines are upgraded to"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=".status.phase",description="Phase of the plan"
+// +kubebuilder:printcolumn:name="Upgraded",type=integer,JSONPath=".status.upgradedMachines",description="Number of upgraded machines"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// CK8sUpgradePlan is the Schema for the ck8supgradeplans API.
+// It upgrades the selected machines of a cluster in-place, and records the progress of each machine.
+// NOTE: the plan drives the in-place upgrade annotations of the machines, so the in-place upgrade annotations
+// of the CK8sControlPlane and MachineDeployments owning them are removed while the plan is in effect.
+type CK8sUpgradePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CK8sUpgradePlanSpec   `json:"spec,omitempty"`
+	Status CK8sUpgradePlanStatus `json:"status,omitempty"`
+}
+
+func (in *CK8sUpgradePlan) GetConditions() clusterv1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *CK8sUpgradePlan) SetConditions(conditions clusterv1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// CK8sUpgradePlanList contains a list of CK8sUpgradePlan.
+type CK8sUpgradePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CK8sUpgradePlan `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CK8sUpgradePlan{}, &CK8sUpgradePlanList{})
+}
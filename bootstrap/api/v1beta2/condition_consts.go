@@ -57,7 +57,7 @@ const (
 	// NOTE: Cluster certificates are generated only for the CK8sConfig object linked to the initial control plane
 	// machine, if the cluster is not using a control plane ref object, if the certificates are not provided
 	// by the users.
-	// IMPORTANT: This condition won't be re-created after clusterctl move.
+	// NOTE: After clusterctl move, the condition is restored from the existing cluster certificates.
 	CertificatesAvailableCondition clusterv1.ConditionType = "CertificatesAvailable"
 
 	// CertificatesGenerationFailedReason (Severity=Warning) documents a CK8sConfig controller detecting
@@ -75,3 +75,80 @@ const (
 
 	SnapInstallValidationFailedReason = "SnapInstallValidationFailed"
 )
+
+const (
+	// CertificatesRenewalCondition documents the status of the automatic certificates renewal
+	// of the machines of a MachineDeployment.
+	CertificatesRenewalCondition clusterv1.ConditionType = "CertificatesRenewal"
+
+	// CertificatesRenewalInProgressReason (Severity=Info) documents a machine having its certificates
+	// renewed because they are about to expire.
+	CertificatesRenewalInProgressReason = "CertificatesRenewalInProgress"
+
+	// CertificatesRenewalFailedReason (Severity=Warning) documents a failure to renew the certificates
+	// of a machine; the renewal of the other machines is halted until the renewal of the machine is retried.
+	CertificatesRenewalFailedReason = "CertificatesRenewalFailed"
+)
+
+const (
+	// InPlaceUpgradeHealthyCondition documents whether the machines of a CK8sControlPlane or a MachineDeployment
+	// passed the health gates after their in-place upgrade.
+	InPlaceUpgradeHealthyCondition clusterv1.ConditionType = "InPlaceUpgradeHealthy"
+
+	// InPlaceUpgradeHealthCheckPendingReason (Severity=Info) documents an upgraded machine that has not yet passed
+	// the health gates; the upgrade of the next machines waits for it.
+	InPlaceUpgradeHealthCheckPendingReason = "InPlaceUpgradeHealthCheckPending"
+
+	// InPlaceUpgradeHealthCheckFailedReason (Severity=Error) documents an upgraded machine that did not pass the
+	// health gates within the timeout; the in-place upgrade is paused until the machine is healthy.
+	InPlaceUpgradeHealthCheckFailedReason = "InPlaceUpgradeHealthCheckFailed"
+)
+
+const (
+	// InPlaceUpgradePreflightCondition documents whether a CK8sControlPlane or a MachineDeployment passed the
+	// preflight checks of its in-place upgrade, before any of its machines is upgraded.
+	InPlaceUpgradePreflightCondition clusterv1.ConditionType = "InPlaceUpgradePreflight"
+
+	// InPlaceUpgradePreflightFailedReason (Severity=Error) documents an in-place upgrade that did not pass the
+	// preflight checks; the upgrade does not start until they pass.
+	InPlaceUpgradePreflightFailedReason = "InPlaceUpgradePreflightFailed"
+
+	// InPlaceUpgradePreflightChecksSkippedReason (Severity=None) documents an in-place upgrade that passed the
+	// preflight checks, some of which could not be run; the condition is True, and its message lists them.
+	InPlaceUpgradePreflightChecksSkippedReason = "InPlaceUpgradePreflightChecksSkipped"
+)
+
+const (
+	// UpgradePlanReadyCondition documents whether all the machines selected by a CK8sUpgradePlan
+	// are upgraded to its target.
+	UpgradePlanReadyCondition clusterv1.ConditionType = "Ready"
+
+	// UpgradePlanInvalidTargetReason (Severity=Error) documents a CK8sUpgradePlan whose target
+	// cannot be resolved to a release.
+	UpgradePlanInvalidTargetReason = "InvalidTarget"
+
+	// UpgradePlanInProgressReason (Severity=Info) documents a CK8sUpgradePlan upgrading its machines.
+	UpgradePlanInProgressReason = "UpgradeInProgress"
+
+	// UpgradePlanFailedReason (Severity=Error) documents a CK8sUpgradePlan with a machine that failed
+	// to upgrade or to pass the health gates; no new upgrades are started until the machine recovers.
+	UpgradePlanFailedReason = "UpgradeFailed"
+
+	// UpgradePlanConflictReason (Severity=Warning) documents a CK8sUpgradePlan waiting for an in-place upgrade
+	// requested by annotations on the CK8sControlPlane or a MachineDeployment of its machines.
+	UpgradePlanConflictReason = "UpgradeConflict"
+)
+
+const (
+	// MaintenanceWindowCondition documents whether new disruptive operations can start on the machines of a
+	// CK8sControlPlane or a MachineDeployment, according to their maintenance windows.
+	MaintenanceWindowCondition clusterv1.ConditionType = "MaintenanceWindow"
+
+	// WaitingForMaintenanceWindowReason (Severity=Info) documents a disruptive operation that is deferred
+	// until the next maintenance window opens.
+	WaitingForMaintenanceWindowReason = "WaitingForMaintenanceWindow"
+
+	// InvalidMaintenanceWindowReason (Severity=Error) documents a MachineDeployment whose maintenance windows
+	// annotations cannot be parsed.
+	InvalidMaintenanceWindowReason = "InvalidMaintenanceWindow"
+)
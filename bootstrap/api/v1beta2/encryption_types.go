@@ -0,0 +1,52 @@
+package v1beta2
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// EncryptionConfigFilePath is the path of the encryption configuration on the control plane nodes.
+	EncryptionConfigFilePath = "/etc/kubernetes/pki/encryption-config.yaml"
+
+	// EncryptionProviderAESCBC encrypts resources with AES-CBC.
+	EncryptionProviderAESCBC = "aescbc"
+
+	// EncryptionProviderAESGCM encrypts resources with AES-GCM.
+	EncryptionProviderAESGCM = "aesgcm"
+)
+
+// EncryptionAtRestConfig configures the encryption of secrets in the datastore.
+// The encryption keys are generated by the provider and stored in the "<cluster>-encryption" secret.
+type EncryptionAtRestConfig struct {
+	// Provider is the encryption provider used to write secrets. If unset, "aescbc" is used.
+	// Secrets written with the other provider remain readable.
+	// +kubebuilder:validation:Enum=aescbc;aesgcm
+	// +optional
+	Provider string `json:"provider,omitempty"`
+}
+
+// GetProvider returns the encryption provider used to write secrets.
+func (c *EncryptionAtRestConfig) GetProvider() string {
+	if c.Provider == "" {
+		return EncryptionProviderAESCBC
+	}
+	return c.Provider
+}
+
+// ValidateEncryptionAtRest validates the encryption at rest configuration of a control plane.
+func (c *CK8sControlPlaneConfig) ValidateEncryptionAtRest(fldPath *field.Path) field.ErrorList {
+	if c.EncryptionAtRest == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	// NOTE: the typed configuration would silently override these, so they are rejected instead.
+	for arg := range c.ExtraKubeAPIServerArgs {
+		if strings.TrimLeft(arg, "-") == "encryption-provider-config" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("extraKubeAPIServerArgs").Key(arg), "the encryption configuration is managed through encryptionAtRest"))
+		}
+	}
+	return allErrs
+}
@@ -6,17 +6,91 @@ const (
 	InPlaceUpgradeReleaseAnnotation             = "v1beta2.k8sd.io/in-place-upgrade-release"
 	InPlaceUpgradeChangeIDAnnotation            = "v1beta2.k8sd.io/in-place-upgrade-change-id"
 	InPlaceUpgradeLastFailedAttemptAtAnnotation = "v1beta2.k8sd.io/in-place-upgrade-last-failed-attempt-at"
+
+	// InPlaceUpgradeDrainAnnotation enables draining the nodes before their in-place upgrade, if set to "true".
+	// It is set on a CK8sControlPlane or a MachineDeployment, and propagated to the machines that are upgraded.
+	InPlaceUpgradeDrainAnnotation = "v1beta2.k8sd.io/in-place-upgrade-drain"
+	// InPlaceUpgradeDrainStartedAtAnnotation records when the node of the machine was cordoned to be drained.
+	InPlaceUpgradeDrainStartedAtAnnotation = "v1beta2.k8sd.io/in-place-upgrade-drain-started-at"
+
+	// InPlaceUpgradeRollbackAnnotation enables refreshing the k8s snap back to its previous release if the in-place
+	// upgrade fails, if set to "true". It is propagated the same way as InPlaceUpgradeDrainAnnotation.
+	InPlaceUpgradeRollbackAnnotation = "v1beta2.k8sd.io/in-place-upgrade-rollback"
+	// InPlaceUpgradePreviousVersionAnnotation records the Kubernetes version of the node before the in-place upgrade.
+	// A rollback is verified against its minor version only, since the release the machine is refreshed back to
+	// may hold a newer patch version.
+	InPlaceUpgradePreviousVersionAnnotation = "v1beta2.k8sd.io/in-place-upgrade-previous-version"
+	// InPlaceUpgradePreviousReleaseAnnotation records the release the machine is refreshed to if the in-place upgrade
+	// is rolled back: the release of its previous in-place upgrade, or else the version of its node before the upgrade.
+	InPlaceUpgradePreviousReleaseAnnotation = "v1beta2.k8sd.io/in-place-upgrade-previous-release"
+	// InPlaceUpgradeRefreshedToAnnotation records the channel, revision or local path the k8s snap of the machine was
+	// refreshed to by its last successful in-place upgrade. Unlike InPlaceUpgradeReleaseAnnotation, "version="
+	// releases are recorded as the channel or revision they were resolved to, and it is kept while the machine is
+	// upgraded again.
+	InPlaceUpgradeRefreshedToAnnotation = "v1beta2.k8sd.io/in-place-upgrade-refreshed-to"
+	// InPlaceUpgradeRolledBackAtAnnotation records when the in-place upgrade of the machine was rolled back.
+	InPlaceUpgradeRolledBackAtAnnotation = "v1beta2.k8sd.io/in-place-upgrade-rolled-back-at"
+
+	// InPlaceUpgradeRiskAnnotation is the risk level ("stable", "candidate", "beta" or "edge") of the snap channel
+	// a "version=" release is resolved to. It defaults to "stable", and is propagated the same way as
+	// InPlaceUpgradeDrainAnnotation.
+	InPlaceUpgradeRiskAnnotation = "v1beta2.k8sd.io/in-place-upgrade-risk"
+	// InPlaceUpgradeVersionMapAnnotation is the name of a ConfigMap, in the namespace of the machines, that maps
+	// Kubernetes versions (e.g. "v1.31.2") to the snap revisions "version=" releases are resolved to, instead of
+	// a snap channel. It is propagated the same way as InPlaceUpgradeDrainAnnotation.
+	InPlaceUpgradeVersionMapAnnotation = "v1beta2.k8sd.io/in-place-upgrade-version-map"
+
+	// InPlaceUpgradeMaxUnavailableAnnotation is the maximum number, or percentage, of the machines of a
+	// MachineDeployment that are upgraded at the same time. It defaults to the maxUnavailable of the
+	// rolling update strategy of the MachineDeployment if set, or to 1 otherwise.
+	InPlaceUpgradeMaxUnavailableAnnotation = "v1beta2.k8sd.io/in-place-upgrade-max-unavailable"
+	// InPlaceUpgradeSpreadFailureDomainsAnnotation spreads the machines of a MachineDeployment that are upgraded
+	// at the same time across failure domains, if set to "true".
+	InPlaceUpgradeSpreadFailureDomainsAnnotation = "v1beta2.k8sd.io/in-place-upgrade-spread-failure-domains"
+
+	// InPlaceUpgradeHealthGatesAnnotation is the comma separated list of the built-in health gates ("node",
+	// "control-plane" and "datastore") a machine must pass after its in-place upgrade, before the next machines
+	// are upgraded. It is set on a CK8sControlPlane or a MachineDeployment.
+	InPlaceUpgradeHealthGatesAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-gates"
+	// InPlaceUpgradeHealthCheckServiceAnnotation is the "namespace/name:port/path" of a Service in the workload cluster
+	// that must respond with a 2xx status code for a machine to pass the health gates. It is requested over HTTP
+	// through the API server of the workload cluster.
+	InPlaceUpgradeHealthCheckServiceAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-check-service"
+	// InPlaceUpgradeHealthCheckJobAnnotation is the "namespace/name" of a CronJob in the workload cluster. A Job is
+	// created from its template for each upgraded machine, and must complete for the machine to pass the health gates.
+	InPlaceUpgradeHealthCheckJobAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-check-job"
+	// InPlaceUpgradeHealthCheckTimeoutAnnotation is how long a machine may take to pass the health gates, before
+	// they are reported as failed. It defaults to 10m.
+	InPlaceUpgradeHealthCheckTimeoutAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-check-timeout"
+	// InPlaceUpgradeHealthCheckedAnnotation records the release the machine passed the health gates for.
+	InPlaceUpgradeHealthCheckedAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-checked"
+	// InPlaceUpgradeHealthCheckStartedAtAnnotation records when the health gates of the machine were first checked.
+	InPlaceUpgradeHealthCheckStartedAtAnnotation = "v1beta2.k8sd.io/in-place-upgrade-health-check-started-at"
 )
 
 const (
 	InPlaceUpgradeInProgressStatus = "in-progress"
 	InPlaceUpgradeDoneStatus       = "done"
 	InPlaceUpgradeFailedStatus     = "failed"
+
+	// InPlaceUpgradeRollingBackStatus is set while the k8s snap is reverted after a failed in-place upgrade.
+	InPlaceUpgradeRollingBackStatus = "rolling-back"
+	// InPlaceUpgradeRolledBackStatus is set once the node is Ready on its previous version. Rolled back upgrades
+	// are not retried, until the status is set to "failed".
+	InPlaceUpgradeRolledBackStatus = "rolled-back"
 )
 
 const (
-	InPlaceUpgradeInProgressEvent = "InPlaceUpgradeInProgress"
-	InPlaceUpgradeDoneEvent       = "InPlaceUpgradeDone"
-	InPlaceUpgradeFailedEvent     = "InPlaceUpgradeFailed"
-	InPlaceUpgradeCancelledEvent  = "InPlaceUpgradeCancelled"
+	InPlaceUpgradeInProgressEvent   = "InPlaceUpgradeInProgress"
+	InPlaceUpgradeDoneEvent         = "InPlaceUpgradeDone"
+	InPlaceUpgradeFailedEvent       = "InPlaceUpgradeFailed"
+	InPlaceUpgradeCancelledEvent    = "InPlaceUpgradeCancelled"
+	InPlaceUpgradeDrainingEvent     = "InPlaceUpgradeDraining"
+	InPlaceUpgradeDrainTimeoutEvent = "InPlaceUpgradeDrainTimeout"
+	InPlaceUpgradeUncordonedEvent   = "InPlaceUpgradeUncordoned"
+	InPlaceUpgradeRollingBackEvent  = "InPlaceUpgradeRollingBack"
+	InPlaceUpgradeRolledBackEvent   = "InPlaceUpgradeRolledBack"
+
+	InPlaceUpgradeHealthCheckFailedEvent = "InPlaceUpgradeHealthCheckFailed"
+	InPlaceUpgradePreflightFailedEvent   = "InPlaceUpgradePreflightFailed"
 )
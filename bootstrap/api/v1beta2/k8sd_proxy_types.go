@@ -0,0 +1,63 @@
+package v1beta2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// K8sdProxyConfig configures the k8sd-proxy daemonset, which the providers use to reach k8sd on the nodes.
+type K8sdProxyConfig struct {
+	// Image is the k8sd-proxy image, e.g. mirrored to a registry that air-gapped clusters can pull from.
+	// If unset, the k8sd-proxy image released along with the providers is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ImagePullSecrets are references to secrets in the kube-system namespace of the workload cluster
+	// used to pull the image.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Tolerations of the k8sd-proxy pods. If unset, the pods tolerate the control plane taints.
+	// The pods must run on all the nodes the providers need to reach.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Resources of the k8sd-proxy container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PriorityClassName of the k8sd-proxy pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// SecurityContext of the k8sd-proxy container. If unset, the container runs as the non-root user 65532
+	// without capabilities, with the RuntimeDefault seccomp profile and a read-only root filesystem.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// DisableDaemonConfigMount stops mounting the k8sd daemon config of the nodes into the k8sd-proxy pods,
+	// e.g. where hostPath volumes are rejected by the Pod Security Standards. The k8sd-proxy then reaches
+	// k8sd on the node IP instead of the microcluster address.
+	// +optional
+	DisableDaemonConfigMount bool `json:"disableDaemonConfigMount,omitempty"`
+}
+
+// K8sdProxyHashAnnotation records the hash of the rendered manifest of the k8sd-proxy objects in the workload cluster,
+// so that they are only updated when the manifest changes.
+const K8sdProxyHashAnnotation = "v1beta2.k8sd.io/k8sd-proxy-hash"
+
+// K8sdTransportAnnotation on a Cluster selects the K8sdTransport the providers use to reach k8sd on its nodes.
+// If unset or unknown, K8sdTransportProxy is used.
+const K8sdTransportAnnotation = "v1beta2.k8sd.io/k8sd-transport"
+
+// K8sdTransport is how the providers reach k8sd on the nodes.
+type K8sdTransport string
+
+const (
+	// K8sdTransportProxy reaches k8sd through the k8sd-proxy pod of the node, port-forwarded by the API server
+	// of the workload cluster.
+	K8sdTransportProxy K8sdTransport = "proxy"
+
+	// K8sdTransportDirect reaches k8sd on the microcluster address of the node directly, for management clusters
+	// with network reach to the nodes. It falls back to K8sdTransportProxy if the node cannot be reached.
+	K8sdTransportDirect K8sdTransport = "direct"
+)
@@ -0,0 +1,43 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaintenanceWindowsAnnotation restricts the disruptive operations of the machines of a MachineDeployment
+	// to maintenance windows. It is a semicolon separated list of windows, each a cron schedule followed by
+	// a duration, e.g. "0 22 * * 1-5 2h; 0 0 * * SAT 8h".
+	MaintenanceWindowsAnnotation = "v1beta2.k8sd.io/maintenance-windows"
+	// MaintenanceWindowTimezoneAnnotation is the time zone the schedules of MaintenanceWindowsAnnotation are
+	// evaluated in, e.g. "Europe/Berlin". Defaults to "UTC".
+	MaintenanceWindowTimezoneAnnotation = "v1beta2.k8sd.io/maintenance-window-timezone"
+	// MaintenanceWindowSkipRemediationAnnotation marks the machines of a MachineDeployment that the
+	// "cluster.x-k8s.io/skip-remediation" annotation was set on while no maintenance window was open, so that
+	// it is removed again once a window opens.
+	MaintenanceWindowSkipRemediationAnnotation = "v1beta2.k8sd.io/maintenance-window-skip-remediation"
+)
+
+// MaintenanceWindowPolicy restricts the disruptive operations on the machines, such as rollouts, remediations,
+// in-place upgrades and certificates refreshes, to recurring maintenance windows. Operations that are already
+// in progress when a window closes are completed.
+type MaintenanceWindowPolicy struct {
+	// Windows are the recurring maintenance windows. New disruptive operations only start while one is open.
+	// +kubebuilder:validation:MinItems=1
+	Windows []MaintenanceWindow `json:"windows"`
+
+	// Timezone is the time zone the schedules are evaluated in, e.g. "Europe/Berlin". Defaults to "UTC".
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// MaintenanceWindow is a recurring maintenance window.
+type MaintenanceWindow struct {
+	// Schedule is a cron expression with five fields (minute, hour, day of month, month, day of week)
+	// for when the window opens, e.g. "0 22 * * 1-5".
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open, e.g. "2h".
+	Duration metav1.Duration `json:"duration"`
+}
@@ -14,9 +14,13 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	bsutil "sigs.k8s.io/cluster-api/bootstrap/util"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
@@ -45,7 +49,19 @@ type CertificatesScope struct {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *CertificatesReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	if err := ctrl.NewControllerManagedBy(mgr).For(&clusterv1.Machine{}).Complete(r); err != nil {
+	clusterToMachines, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineList{}, mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Machine{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachines),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		Complete(r); err != nil {
 		return err
 	}
 
@@ -88,6 +104,16 @@ func (r *CertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
+	cluster, err := util.GetClusterByName(ctx, r.Client, m.GetNamespace(), m.Spec.ClusterName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, m) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	mAnnotations := m.GetAnnotations()
 	if mAnnotations == nil {
 		mAnnotations = map[string]string{}
@@ -135,6 +161,7 @@ func (r *CertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		if err := r.refreshCertificates(ctx, scope); err != nil {
 			// On error, we requeue the request to retry.
 			mAnnotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshFailedStatus
+			mAnnotations[bootstrapv1.CertificatesRefreshFailedAtAnnotation] = time.Now().Format(time.RFC3339)
 			m.SetAnnotations(mAnnotations)
 			if err := r.Update(ctx, m); err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to clear status annotation after error: %w", err)
@@ -250,6 +277,7 @@ func (r *CertificatesReconciler) refreshCertificates(ctx context.Context, scope
 
 	expiryTime := time.Unix(int64(expirySecondsUnix), 0)
 	delete(mAnnotations, bootstrapv1.CertificatesRefreshAnnotation)
+	delete(mAnnotations, bootstrapv1.CertificatesRefreshFailedAtAnnotation)
 	mAnnotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshDoneStatus
 	mAnnotations[bootstrapv1.MachineCertificatesExpiryDateAnnotation] = expiryTime.Format(time.RFC3339)
 	scope.Machine.SetAnnotations(mAnnotations)
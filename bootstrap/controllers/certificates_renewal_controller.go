@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+)
+
+// CertificatesRenewalReconciler reconciles a MachineDeployment object and renews the certificates
+// of its machines before they expire, according to the certificates renewal policy of its CK8sConfigTemplate.
+type CertificatesRenewalReconciler struct {
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+	renewal  *certificates.Renewal
+
+	client.Client
+	Log logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *CertificatesRenewalReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-md-certificates-renewal-controller")
+	r.renewal = &certificates.Renewal{
+		Client:   r.Client,
+		Recorder: r.recorder,
+	}
+
+	clusterToMachineDeployments, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineDeploymentList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to MachineDeployments: %w", err)
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.MachineDeployment{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachineDeployments),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		// NOTE: the machines are owned by the MachineSets of the MachineDeployment, so they are mapped through
+		// their MachineDeployment name label instead.
+		Watches(
+			&clusterv1.Machine{},
+			handler.EnqueueRequestsFromMapFunc(machineToMachineDeployment),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigtemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile handles the reconciliation of a MachineDeployment object.
+func (r *CertificatesRenewalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("certificates_renewal", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	md := &clusterv1.MachineDeployment{}
+	if err := r.Get(ctx, req.NamespacedName, md); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("MachineDeployment resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get MachineDeployment: %w", err)
+	}
+
+	if isDeleted(md) {
+		log.V(1).Info("MachineDeployment is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	policy, err := r.getCertificatesRenewalPolicy(ctx, md)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get certificates renewal policy: %w", err)
+	}
+	if policy == nil {
+		log.V(1).Info("MachineDeployment has no certificates renewal policy, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName}, cluster); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, md) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	windows, err := maintenance.FromAnnotations(md)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to parse maintenance window: %w", err)
+	}
+
+	machines, err := getMachineDeploymentMachines(ctx, r.Client, md)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get machines: %w", err)
+	}
+
+	patchHelper, err := patch.NewHelper(md, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	requeueAfter, err := r.renewal.Reconcile(ctx, log, md, machines, policy, windows)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := patchHelper.Patch(ctx, md, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+		bootstrapv1.CertificatesRenewalCondition,
+		bootstrapv1.MaintenanceWindowCondition,
+	}}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to patch MachineDeployment: %w", err)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// getCertificatesRenewalPolicy returns the certificates renewal policy of the CK8sConfigTemplate
+// referenced by the MachineDeployment, if any.
+func (r *CertificatesRenewalReconciler) getCertificatesRenewalPolicy(ctx context.Context, md *clusterv1.MachineDeployment) (*bootstrapv1.CertificatesRenewalPolicy, error) {
+	configRef := md.Spec.Template.Spec.Bootstrap.ConfigRef
+	if configRef == nil || configRef.Kind != "CK8sConfigTemplate" {
+		return nil, nil
+	}
+
+	template := &bootstrapv1.CK8sConfigTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: md.Namespace, Name: configRef.Name}, template); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get CK8sConfigTemplate: %w", err)
+	}
+
+	return template.Spec.CertificatesRenewal, nil
+}
+
+// getMachineDeploymentMachines gets the machines of the MachineDeployment, across all of its MachineSets.
+func getMachineDeploymentMachines(ctx context.Context, c client.Client, md *clusterv1.MachineDeployment) ([]*clusterv1.Machine, error) {
+	var machineList clusterv1.MachineList
+	if err := c.List(ctx, &machineList, client.InNamespace(md.Namespace), client.MatchingLabels{
+		clusterv1.ClusterNameLabel:           md.Spec.ClusterName,
+		clusterv1.MachineDeploymentNameLabel: md.Name,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	machines := make([]*clusterv1.Machine, 0, len(machineList.Items))
+	for i := range machineList.Items {
+		machines = append(machines, &machineList.Items[i])
+	}
+	return machines, nil
+}
+
+// machineToMachineDeployment maps a Machine to the MachineDeployment it belongs to, if any.
+func machineToMachineDeployment(_ context.Context, o client.Object) []reconcile.Request {
+	m, ok := o.(*clusterv1.Machine)
+	if !ok {
+		return nil
+	}
+
+	name, ok := m.Labels[clusterv1.MachineDeploymentNameLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: m.Namespace, Name: name}}}
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMachineToMachineDeployment(t *testing.T) {
+	g := NewWithT(t)
+
+	worker := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+		Name:      "worker-0",
+		Namespace: "default",
+		Labels:    map[string]string{clusterv1.MachineDeploymentNameLabel: "md"},
+	}}
+	g.Expect(machineToMachineDeployment(context.Background(), worker)).To(ConsistOf(
+		reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "md"}},
+	))
+
+	controlPlane := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+		Name:      "cp-0",
+		Namespace: "default",
+		Labels:    map[string]string{clusterv1.MachineControlPlaneLabel: ""},
+	}}
+	g.Expect(machineToMachineDeployment(context.Background(), controlPlane)).To(BeEmpty())
+}
@@ -37,8 +37,11 @@ import (
 	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	kubeyaml "sigs.k8s.io/yaml"
 
@@ -46,6 +49,7 @@ import (
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
 	"github.com/canonical/cluster-api-k8s/pkg/cloudinit"
 	"github.com/canonical/cluster-api-k8s/pkg/locking"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
 	"github.com/canonical/cluster-api-k8s/pkg/secret"
 	"github.com/canonical/cluster-api-k8s/pkg/token"
 )
@@ -53,6 +57,7 @@ import (
 // InitLocker is a lock that is used around control plane init.
 type InitLocker interface {
 	Lock(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) bool
+	Renew(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error
 	Unlock(ctx context.Context, cluster *clusterv1.Cluster) bool
 }
 
@@ -84,6 +89,7 @@ var (
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status;machines;machines/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=exp.cluster.x-k8s.io,resources=machinepools;machinepools/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;events;configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 
 func (r *CK8sConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ reconcile.Result, rerr error) {
 	log := r.Log.WithValues("ck8sconfig", req.NamespacedName)
@@ -131,9 +137,11 @@ func (r *CK8sConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	if annotations.IsPaused(cluster, config) {
-		log.Info("Reconciliation is paused for this object")
-		return ctrl.Result{}, nil
+	if isPaused, err := paused.EnsurePausedCondition(ctx, r.Client, cluster, config); err != nil || isPaused {
+		if isPaused {
+			log.Info("Reconciliation is paused for this object")
+		}
+		return ctrl.Result{}, err
 	}
 
 	scope := &Scope{
@@ -185,9 +193,16 @@ func (r *CK8sConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		config.Status.Ready = true
 		config.Status.DataSecretName = configOwner.DataSecretName()
 		conditions.MarkTrue(config, bootstrapv1.DataSecretAvailableCondition)
+		if configOwner.IsControlPlaneMachine() && !conditions.Has(config, bootstrapv1.CertificatesAvailableCondition) {
+			return ctrl.Result{}, r.restoreCertificatesCondition(ctx, scope)
+		}
 		return ctrl.Result{}, nil
 	// Status is ready means a config has been generated.
 	case config.Status.Ready:
+		// The init lock is renewed on behalf of the initializing control plane machine until the control plane is initialized.
+		if configOwner.IsControlPlaneMachine() && !conditions.IsTrue(cluster, clusterv1.ControlPlaneInitializedCondition) {
+			return r.renewInitLock(ctx, scope)
+		}
 		// In any other case just return as the config is already generated and need not be generated again.
 		return ctrl.Result{}, nil
 	}
@@ -212,6 +227,39 @@ func (r *CK8sConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return reconcile.Result{}, r.joinWorker(ctx, scope)
 }
 
+// renewInitLock renews the init lock while the machine holding it initializes the control plane. The lock is not renewed
+// for failed or deleted machines, so that another control plane machine takes over once it expires.
+func (r *CK8sConfigReconciler) renewInitLock(ctx context.Context, scope *Scope) (ctrl.Result, error) {
+	machine := &clusterv1.Machine{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(scope.ConfigOwner.Object, machine); err != nil {
+		return ctrl.Result{}, fmt.Errorf("cannot convert %s to Machine: %w", scope.ConfigOwner.GetKind(), err)
+	}
+	if !machine.DeletionTimestamp.IsZero() || machine.Status.FailureReason != nil || machine.Status.FailureMessage != nil {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.CK8sInitLock.Renew(ctx, scope.Cluster, machine); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to renew init lock: %w", err)
+	}
+	return ctrl.Result{RequeueAfter: locking.DefaultControlPlaneInitLockDuration / 3}, nil
+}
+
+// restoreCertificatesCondition rebuilds the CertificatesAvailable condition of a control plane CK8sConfig whose
+// status was not preserved, e.g. after clusterctl move. It is only called if the condition is missing, and the
+// cluster certificates are looked up, never generated.
+func (r *CK8sConfigReconciler) restoreCertificatesCondition(ctx context.Context, scope *Scope) error {
+	certificates := secret.NewCertificatesForInitialControlPlane(&scope.Config.Spec)
+	if err := certificates.Lookup(ctx, r.Client, util.ObjectKey(scope.Cluster)); err != nil {
+		return fmt.Errorf("failed to lookup cluster certificates: %w", err)
+	}
+	if err := certificates.EnsureAllExist(); err != nil {
+		conditions.MarkFalse(scope.Config, bootstrapv1.CertificatesAvailableCondition, bootstrapv1.CertificatesCorruptedReason, clusterv1.ConditionSeverityError, "%s", err.Error())
+		return nil
+	}
+	conditions.MarkTrue(scope.Config, bootstrapv1.CertificatesAvailableCondition)
+	return nil
+}
+
 func (r *CK8sConfigReconciler) joinControlplane(ctx context.Context, scope *Scope) error {
 	machine := &clusterv1.Machine{}
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(scope.ConfigOwner.Object, machine); err != nil {
@@ -256,7 +304,7 @@ func (r *CK8sConfigReconciler) joinControlplane(ctx context.Context, scope *Scop
 		return err
 	}
 
-	files, err := r.resolveFiles(ctx, scope.Config)
+	files, err := r.resolveControlPlaneFiles(ctx, scope)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 		return err
@@ -474,6 +522,68 @@ func (r *CK8sConfigReconciler) resolveFiles(ctx context.Context, cfg *bootstrapv
 	return collected, nil
 }
 
+// resolveControlPlaneFiles resolves the files of a control plane node, including the CA of the OIDC provider,
+// the audit policy and webhook configuration and the encryption configuration, if any.
+// The hash of the audit policy is recorded on the config, so that outdated control plane nodes can be rolled out.
+func (r *CK8sConfigReconciler) resolveControlPlaneFiles(ctx context.Context, scope *Scope) ([]bootstrapv1.File, error) {
+	cfg := scope.Config
+	files, err := r.resolveFiles(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if f := cfg.Spec.ControlPlaneConfig.GetOIDC().CAFile(); f != nil {
+		data, err := r.resolveSecretFileContent(ctx, cfg.Namespace, *f.ContentFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve OIDC CA: %w", err)
+		}
+		f.ContentFrom = nil
+		f.Content = string(data)
+		files = append(files, *f)
+	}
+
+	if audit := cfg.Spec.ControlPlaneConfig.Audit; audit != nil {
+		policy, err := ck8s.GetAuditPolicy(ctx, r.Client, cfg.Namespace, audit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve audit policy: %w", err)
+		}
+		files = append(files, bootstrapv1.File{
+			Path:        bootstrapv1.AuditPolicyFilePath,
+			Content:     policy,
+			Permissions: "0600",
+		})
+		annotations.AddAnnotations(cfg, map[string]string{bootstrapv1.AuditPolicyHashAnnotation: ck8s.AuditPolicyHash(policy)})
+
+		if f := audit.WebhookConfigFile(); f != nil {
+			data, err := r.resolveSecretFileContent(ctx, cfg.Namespace, *f.ContentFrom)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve audit webhook configuration: %w", err)
+			}
+			f.ContentFrom = nil
+			f.Content = string(data)
+			files = append(files, *f)
+		}
+	}
+
+	if encryption := cfg.Spec.ControlPlaneConfig.EncryptionAtRest; encryption != nil {
+		key, err := secret.GetEncryptionKey(ctx, r.Client, util.ObjectKey(scope.Cluster))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get encryption key: %w", err)
+		}
+		config, err := ck8s.GenerateEncryptionConfig(encryption, key)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, bootstrapv1.File{
+			Path:        bootstrapv1.EncryptionConfigFilePath,
+			Content:     string(config),
+			Permissions: "0600",
+		})
+	}
+
+	return files, nil
+}
+
 func (r *CK8sConfigReconciler) resolveInPlaceUpgradeRelease(machine *clusterv1.Machine) *cloudinit.SnapInstallData {
 	mAnnotations := machine.GetAnnotations()
 
@@ -638,6 +748,17 @@ func (r *CK8sConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 		conditions.MarkFalse(scope.Config, bootstrapv1.CertificatesAvailableCondition, bootstrapv1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 		return ctrl.Result{}, err
 	}
+	if scope.Config.Spec.ControlPlaneConfig.EncryptionAtRest != nil {
+		if err := secret.LookupOrGenerateEncryptionKey(
+			ctx,
+			r.Client,
+			util.ObjectKey(scope.Cluster),
+			*metav1.NewControllerRef(scope.Config, bootstrapv1.GroupVersion.WithKind("CK8sConfig")),
+		); err != nil {
+			conditions.MarkFalse(scope.Config, bootstrapv1.CertificatesAvailableCondition, bootstrapv1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+			return ctrl.Result{}, err
+		}
+	}
 	conditions.MarkTrue(scope.Config, bootstrapv1.CertificatesAvailableCondition)
 
 	authToken, err := token.Lookup(ctx, r.Client, client.ObjectKeyFromObject(scope.Cluster))
@@ -684,7 +805,7 @@ func (r *CK8sConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 		return ctrl.Result{}, err
 	}
 
-	files, err := r.resolveFiles(ctx, scope.Config)
+	files, err := r.resolveControlPlaneFiles(ctx, scope)
 	if err != nil {
 		conditions.MarkFalse(scope.Config, bootstrapv1.DataSecretAvailableCondition, bootstrapv1.DataSecretGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 		return ctrl.Result{}, err
@@ -697,7 +818,10 @@ func (r *CK8sConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 	}
 
 	microclusterPort := scope.Config.Spec.ControlPlaneConfig.GetMicroclusterPort()
-	ds, err := ck8s.RenderK8sdProxyDaemonSetManifest(ck8s.K8sdProxyDaemonSetInput{K8sdPort: microclusterPort})
+	ds, err := ck8s.RenderK8sdProxyDaemonSetManifest(ck8s.K8sdProxyDaemonSetInput{
+		K8sdPort: microclusterPort,
+		Config:   scope.Config.Spec.ControlPlaneConfig.K8sdProxy,
+	})
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to render k8sd-proxy daemonset: %w", err)
 	}
@@ -759,7 +883,7 @@ func (r *CK8sConfigReconciler) handleClusterNotInitialized(ctx context.Context,
 
 func (r *CK8sConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.CK8sInitLock == nil {
-		r.CK8sInitLock = locking.NewControlPlaneInitMutex(mgr.GetClient())
+		r.CK8sInitLock = locking.NewControlPlaneInitMutex(mgr.GetClient(), mgr.GetEventRecorderFor("ck8s-init-lock"))
 	}
 
 	if r.managementCluster == nil {
@@ -769,8 +893,18 @@ func (r *CK8sConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		}
 	}
 
+	clusterToCK8sConfigs, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &bootstrapv1.CK8sConfigList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to CK8sConfigs: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&bootstrapv1.CK8sConfig{}).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToCK8sConfigs),
+			builder.WithPredicates(predicates.ClusterPausedTransitions(mgr.GetScheme(), r.Log)),
+		).
 		Complete(r)
 }
 
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+)
+
+// ClusterCertificatesReconciler reconciles a Cluster object and orchestrates the certificates refresh
+// of the control plane first, and then of the MachineDeployments of the cluster.
+type ClusterCertificatesReconciler struct {
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	client.Client
+	Log logr.Logger
+}
+
+// clusterCertificatesScope is a struct that holds the context of the certificates refresh process.
+type clusterCertificatesScope struct {
+	cluster            *clusterv1.Cluster
+	clusterPatcher     certificates.Patcher
+	ttl                string
+	controlPlane       client.Object
+	machineDeployments []*clusterv1.MachineDeployment
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterCertificatesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-cluster-certificates-controller")
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}, builder.WithPredicates(clusterNotPausedOrPausedTransitions(mgr.GetScheme(), r.Log))).
+		// NOTE: The Cluster is not the controller of its MachineDeployments.
+		Owns(&clusterv1.MachineDeployment{}, builder.MatchEveryOwner, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8scontrolplanes,verbs=get;list;watch;update;patch
+
+// Reconcile handles the reconciliation of a Cluster object.
+func (r *ClusterCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("orchestrated_certificates_refresh", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("Cluster resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get Cluster: %w", err)
+	}
+
+	if isDeleted(cluster) {
+		log.V(1).Info("Cluster is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	if isPaused, err := paused.EnsurePausedCondition(ctx, r.Client, cluster, cluster); err != nil || isPaused {
+		if isPaused {
+			log.V(1).Info("Reconciliation is paused for this object")
+		}
+		return ctrl.Result{}, err
+	}
+
+	if certificates.GetRefreshInstructions(cluster) == "" {
+		log.V(1).Info("Cluster has no certificates refresh instructions, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
+	}
+
+	if !certificates.IsRefreshInProgress(cluster) {
+		if err := r.startRefresh(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to start certificates refresh: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	// Refresh the control plane machines first.
+	if scope.controlPlane != nil {
+		switch {
+		case certificates.GetRefreshInstructions(scope.controlPlane) != "":
+			log.V(1).Info("Control plane certificates are being refreshed, requeuing...")
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		case scope.controlPlane.GetAnnotations()[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshFailedStatus:
+			if err := r.markRefreshFailed(ctx, scope, "Certificates refresh failed for control plane %q", scope.controlPlane.GetName()); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as failed: %w", err)
+			}
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Then refresh the worker machines.
+	var (
+		refreshedMachineDeployments int
+		refreshing                  bool
+	)
+	for _, md := range scope.machineDeployments {
+		switch {
+		case certificates.GetRefreshInstructions(md) != "":
+			refreshing = true
+		case md.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshFailedStatus:
+			if err := r.markRefreshFailed(ctx, scope, "Certificates refresh failed for MachineDeployment %q", md.Name); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as failed: %w", err)
+			}
+			return ctrl.Result{}, nil
+		case md.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshDoneStatus:
+			refreshedMachineDeployments++
+		default:
+			if err := r.markObjectToRefresh(ctx, scope, "MachineDeployment", md); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark MachineDeployment %q to refresh certificates: %w", md.Name, err)
+			}
+			refreshing = true
+		}
+	}
+
+	if !refreshing && refreshedMachineDeployments == len(scope.machineDeployments) {
+		if err := r.markRefreshDone(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as done: %w", err)
+		}
+
+		log.V(1).Info("All machines have their certificates refreshed")
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// startRefresh validates the refresh request, resets the refresh status of the MachineDeployments,
+// triggers the refresh of the control plane and marks the Cluster as certificates refresh in-progress.
+func (r *ClusterCertificatesReconciler) startRefresh(ctx context.Context, scope *clusterCertificatesScope) error {
+	if _, err := utiltime.TTLToSeconds(scope.ttl); err != nil {
+		return r.markRefreshFailed(ctx, scope, "Invalid certificates TTL %q: %v", scope.ttl, err)
+	}
+	if _, err := certificates.GetMaxConcurrency(scope.cluster); err != nil {
+		return r.markRefreshFailed(ctx, scope, "Invalid value for annotation %q: %v", bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation, err)
+	}
+
+	for _, md := range scope.machineDeployments {
+		if err := certificates.ResetRefreshStatus(ctx, md, r.Client); err != nil {
+			return fmt.Errorf("failed to reset certificates refresh status of MachineDeployment %q: %w", md.Name, err)
+		}
+	}
+
+	if scope.controlPlane != nil {
+		if err := r.markObjectToRefresh(ctx, scope, scope.cluster.Spec.ControlPlaneRef.Kind, scope.controlPlane); err != nil {
+			return fmt.Errorf("failed to mark control plane to refresh certificates: %w", err)
+		}
+	}
+
+	if err := certificates.MarkRefreshInProgress(ctx, scope.cluster, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh in-progress: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Certificates refresh is in-progress. TTL: %s",
+		scope.ttl,
+	)
+	return nil
+}
+
+// markObjectToRefresh annotates the control plane or a MachineDeployment to refresh the certificates of its machines.
+// The max concurrency set on the Cluster, if any, is passed along.
+func (r *ClusterCertificatesReconciler) markObjectToRefresh(ctx context.Context, scope *clusterCertificatesScope, kind string, obj client.Object) error {
+	patchHelper, err := patch.NewHelper(obj, r.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	if v, ok := scope.cluster.Annotations[bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation]; ok {
+		objAnnotations := obj.GetAnnotations()
+		if objAnnotations == nil {
+			objAnnotations = map[string]string{}
+		}
+		objAnnotations[bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation] = v
+		obj.SetAnnotations(objAnnotations)
+	}
+
+	if err := certificates.MarkObjectToRefresh(ctx, obj, scope.ttl, patchHelper); err != nil {
+		return fmt.Errorf("failed to mark object to refresh certificates: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"%s %q is refreshing certificates",
+		kind,
+		obj.GetName(),
+	)
+	return nil
+}
+
+// markRefreshDone annotates the Cluster with certificates refresh done.
+func (r *ClusterCertificatesReconciler) markRefreshDone(ctx context.Context, scope *clusterCertificatesScope) error {
+	if err := certificates.MarkRefreshDone(ctx, scope.cluster, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh done: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshDoneEvent,
+		"Certificates refresh is done",
+	)
+	return nil
+}
+
+// markRefreshFailed annotates the Cluster with certificates refresh failed.
+func (r *ClusterCertificatesReconciler) markRefreshFailed(ctx context.Context, scope *clusterCertificatesScope, format string, args ...interface{}) error {
+	if err := certificates.MarkRefreshFailed(ctx, scope.cluster, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh failed: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeWarning,
+		bootstrapv1.CertificatesRefreshFailedEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// createScope creates a new clusterCertificatesScope.
+func (r *ClusterCertificatesReconciler) createScope(ctx context.Context, cluster *clusterv1.Cluster) (*clusterCertificatesScope, error) {
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	// NOTE: The control plane is handled as unstructured, so that the bootstrap provider
+	// does not depend on the control plane provider types.
+	var controlPlane client.Object
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		obj, err := external.Get(ctx, r.Client, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get control plane: %w", err)
+		}
+		controlPlane = obj
+	}
+
+	var mdList clusterv1.MachineDeploymentList
+	if err := r.List(ctx, &mdList, client.InNamespace(cluster.Namespace), client.MatchingLabels{
+		clusterv1.ClusterNameLabel: cluster.Name,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list MachineDeployments: %w", err)
+	}
+
+	machineDeployments := make([]*clusterv1.MachineDeployment, 0, len(mdList.Items))
+	for i := range mdList.Items {
+		machineDeployments = append(machineDeployments, &mdList.Items[i])
+	}
+	sort.Slice(machineDeployments, func(i, j int) bool {
+		return machineDeployments[i].Name < machineDeployments[j].Name
+	})
+
+	return &clusterCertificatesScope{
+		cluster:            cluster,
+		clusterPatcher:     patchHelper,
+		ttl:                certificates.GetRefreshInstructions(cluster),
+		controlPlane:       controlPlane,
+		machineDeployments: machineDeployments,
+	}, nil
+}
+
+// clusterNotPausedOrPausedTransitions returns a predicate that filters out the events of the Clusters whose
+// reconciliation is paused, except for the events that pause or unpause them.
+func clusterNotPausedOrPausedTransitions(scheme *runtime.Scheme, log logr.Logger) predicate.Funcs {
+	clusterNotPaused := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		cluster, ok := o.(*clusterv1.Cluster)
+		return ok && !annotations.IsPaused(cluster, cluster)
+	})
+	return predicates.Any(scheme, log, predicates.ClusterPausedTransitions(scheme, log), clusterNotPaused)
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestClusterNotPausedOrPausedTransitions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	NewWithT(t).Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	p := clusterNotPausedOrPausedTransitions(scheme, logr.Discard())
+
+	newCluster := func(paused bool, annotations map[string]string) *clusterv1.Cluster {
+		return &clusterv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default", Annotations: annotations},
+			Spec:       clusterv1.ClusterSpec{Paused: paused},
+		}
+	}
+	pausedAnnotation := map[string]string{clusterv1.PausedAnnotation: ""}
+
+	for _, tc := range []struct {
+		name     string
+		old      *clusterv1.Cluster
+		new      *clusterv1.Cluster
+		expected bool
+	}{
+		{name: "NotPaused", old: newCluster(false, nil), new: newCluster(false, map[string]string{"foo": "bar"}), expected: true},
+		{name: "Paused", old: newCluster(true, nil), new: newCluster(true, map[string]string{"foo": "bar"}), expected: false},
+		{name: "PausedAnnotation", old: newCluster(false, pausedAnnotation), new: newCluster(false, pausedAnnotation), expected: false},
+		{name: "Pause", old: newCluster(false, nil), new: newCluster(true, nil), expected: true},
+		{name: "Unpause", old: newCluster(true, nil), new: newCluster(false, nil), expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(p.Update(event.UpdateEvent{ObjectOld: tc.old, ObjectNew: tc.new})).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+// ClusterInPlaceUpgradeReconciler reconciles a Cluster object and orchestrates the in-place upgrade
+// of the control plane first, and then of the MachineDeployments of the cluster.
+type ClusterInPlaceUpgradeReconciler struct {
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	// controlPlaneTracker watches the kinds of the control planes of the Clusters, which are only known at runtime.
+	controlPlaneTracker *external.ObjectTracker
+	controlPlaneHandler handler.EventHandler
+
+	client.Client
+	Log logr.Logger
+}
+
+// clusterInPlaceUpgradeScope is a struct that holds the context of the upgrade process.
+type clusterInPlaceUpgradeScope struct {
+	cluster            *clusterv1.Cluster
+	clusterPatcher     inplace.Patcher
+	upgradeTo          string
+	controlPlane       *unstructured.Unstructured
+	machineDeployments []*clusterv1.MachineDeployment
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterInPlaceUpgradeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-cluster-inplace-upgrade-controller")
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Cluster{}, builder.WithPredicates(clusterNotPausedOrPausedTransitions(mgr.GetScheme(), r.Log))).
+		// NOTE: The Cluster is not the controller of its MachineDeployments.
+		Owns(&clusterv1.MachineDeployment{}, builder.MatchEveryOwner, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Build(r)
+	if err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	r.controlPlaneTracker = &external.ObjectTracker{
+		Controller:      c,
+		Cache:           mgr.GetCache(),
+		Scheme:          mgr.GetScheme(),
+		PredicateLogger: &r.Log,
+	}
+	r.controlPlaneHandler = handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &clusterv1.Cluster{})
+	return nil
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8scontrolplanes,verbs=get;list;watch;update;patch
+
+// Reconcile handles the reconciliation of a Cluster object.
+func (r *ClusterInPlaceUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("cluster_inplace_upgrade", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("Cluster resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get Cluster: %w", err)
+	}
+
+	if isDeleted(cluster) {
+		log.V(1).Info("Cluster is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	if isPaused, err := paused.EnsurePausedCondition(ctx, r.Client, cluster, cluster); err != nil || isPaused {
+		if isPaused {
+			log.V(1).Info("Reconciliation is paused for this object")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// NOTE: Unlike the CK8sControlPlane and the MachineDeployments, the Cluster is only reconciled while the
+	// `upgrade-to` annotation is set. Machines that join after the upgrade are handled by their own orchestrator.
+	if cluster.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] == "" {
+		log.V(1).Info("Cluster has no upgrade instructions, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, cluster)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
+	}
+
+	// Upgrade the control plane machines first.
+	if scope.controlPlane != nil && !isUpgradeDone(scope.controlPlane, scope.upgradeTo) {
+		switch {
+		case scope.controlPlane.GetAnnotations()[bootstrapv1.InPlaceUpgradeToAnnotation] != scope.upgradeTo:
+			if err := r.startUpgrade(ctx, scope); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to start in-place upgrade: %w", err)
+			}
+		case isUpgradeFailed(scope.controlPlane):
+			log.Info("Control plane upgrade failed, waiting for it to be retried...")
+			if err := r.markUpgradeFailed(ctx, scope, "In-place upgrade failed for control plane %q", scope.controlPlane.GetName()); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
+			}
+			return ctrl.Result{}, nil
+		default:
+			if err := r.markUpgradeInProgress(ctx, scope, "In-place upgrade is in-progress for control plane %q", scope.controlPlane.GetName()); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as in-progress: %w", err)
+			}
+		}
+
+		// NOTE: The control plane and the MachineDeployments are watched, so their progress does not need to be polled.
+		log.V(1).Info("Control plane is upgrading, waiting for it to be upgraded...")
+		return ctrl.Result{}, nil
+	}
+
+	// Then upgrade the worker machines.
+	var (
+		upgradedMachineDeployments int
+		failedMachineDeployment    *clusterv1.MachineDeployment
+	)
+	for _, md := range scope.machineDeployments {
+		switch {
+		case isUpgradeDone(md, scope.upgradeTo):
+			upgradedMachineDeployments++
+		case md.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] != scope.upgradeTo:
+			if scope.controlPlane == nil {
+				// NOTE: Without a control plane, the version skew is checked when the workers are upgraded.
+				if err := r.startUpgrade(ctx, scope); err != nil {
+					return ctrl.Result{}, fmt.Errorf("failed to start in-place upgrade: %w", err)
+				}
+				return ctrl.Result{}, nil
+			}
+			if err := r.markObjectToUpgrade(ctx, scope, "MachineDeployment", md); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark MachineDeployment %q to upgrade: %w", md.Name, err)
+			}
+		case isUpgradeFailed(md):
+			if failedMachineDeployment == nil {
+				failedMachineDeployment = md
+			}
+		}
+	}
+
+	if failedMachineDeployment != nil {
+		log.Info("MachineDeployment upgrade failed, waiting for it to be retried...", "machineDeployment", failedMachineDeployment.Name)
+		if err := r.markUpgradeFailed(ctx, scope, "In-place upgrade failed for MachineDeployment %q", failedMachineDeployment.Name); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if upgradedMachineDeployments == len(scope.machineDeployments) {
+		if err := r.markUpgradeDone(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as done: %w", err)
+		}
+
+		log.V(1).Info("All machines are upgraded")
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.markUpgradeInProgress(ctx, scope, "In-place upgrade is in-progress for the MachineDeployments"); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as in-progress: %w", err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// startUpgrade validates the version skew of the upgrade, and triggers the upgrade of the control plane,
+// or of the MachineDeployments if the cluster has no control plane. The upgrade is cancelled if it is invalid.
+func (r *ClusterInPlaceUpgradeReconciler) startUpgrade(ctx context.Context, scope *clusterInPlaceUpgradeScope) error {
+	var controlPlaneVersion string
+	if scope.controlPlane != nil {
+		specVersion, _, err := unstructured.NestedString(scope.controlPlane.Object, "spec", "version")
+		if err != nil {
+			return fmt.Errorf("failed to get control plane version: %w", err)
+		}
+		controlPlaneVersion = inplace.GetCurrentVersion(scope.controlPlane, specVersion)
+	}
+
+	workerVersions := make(map[string]string, len(scope.machineDeployments))
+	for _, md := range scope.machineDeployments {
+		workerVersions[fmt.Sprintf("MachineDeployment %q", md.Name)] = inplace.GetCurrentVersion(md, ptr.Deref(md.Spec.Template.Spec.Version, ""))
+	}
+
+	if err := inplace.CheckVersionSkew(scope.upgradeTo, controlPlaneVersion, workerVersions); err != nil {
+		return r.cancelUpgrade(ctx, scope, "Invalid in-place upgrade to %q: %v", scope.upgradeTo, err)
+	}
+
+	if scope.controlPlane != nil {
+		if err := r.markObjectToUpgrade(ctx, scope, scope.cluster.Spec.ControlPlaneRef.Kind, scope.controlPlane); err != nil {
+			return fmt.Errorf("failed to mark control plane to upgrade: %w", err)
+		}
+	} else {
+		for _, md := range scope.machineDeployments {
+			if isUpgradeDone(md, scope.upgradeTo) {
+				continue
+			}
+			if err := r.markObjectToUpgrade(ctx, scope, "MachineDeployment", md); err != nil {
+				return fmt.Errorf("failed to mark MachineDeployment %q to upgrade: %w", md.Name, err)
+			}
+		}
+	}
+
+	return r.markUpgradeInProgress(ctx, scope, "In-place upgrade to %q is in-progress", scope.upgradeTo)
+}
+
+// markObjectToUpgrade annotates the control plane or a MachineDeployment to upgrade its machines.
+// The upgrade status of a previous upgrade is reset, so that it is not mistaken for the status of this upgrade.
+func (r *ClusterInPlaceUpgradeReconciler) markObjectToUpgrade(ctx context.Context, scope *clusterInPlaceUpgradeScope, kind string, obj client.Object) error {
+	patchHelper, err := patch.NewHelper(obj, r.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	delete(objAnnotations, bootstrapv1.InPlaceUpgradeStatusAnnotation)
+	objAnnotations[bootstrapv1.InPlaceUpgradeToAnnotation] = scope.upgradeTo
+	obj.SetAnnotations(objAnnotations)
+
+	if err := patchHelper.Patch(ctx, obj); err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.InPlaceUpgradeInProgressEvent,
+		"%s %q is upgrading to %q",
+		kind,
+		obj.GetName(),
+		scope.upgradeTo,
+	)
+	return nil
+}
+
+// markUpgradeInProgress annotates the Cluster with in-place upgrade in-progress.
+func (r *ClusterInPlaceUpgradeReconciler) markUpgradeInProgress(ctx context.Context, scope *clusterInPlaceUpgradeScope, format string, args ...interface{}) error {
+	if scope.cluster.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeInProgressStatus {
+		return nil
+	}
+
+	if err := inplace.MarkUpgradeInProgress(ctx, scope.cluster, scope.upgradeTo, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with upgrade in-progress: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.InPlaceUpgradeInProgressEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// markUpgradeDone annotates the Cluster with in-place upgrade done.
+func (r *ClusterInPlaceUpgradeReconciler) markUpgradeDone(ctx context.Context, scope *clusterInPlaceUpgradeScope) error {
+	if err := inplace.MarkUpgradeDone(ctx, scope.cluster, scope.upgradeTo, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with upgrade done: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeNormal,
+		bootstrapv1.InPlaceUpgradeDoneEvent,
+		"In-place upgrade is done",
+	)
+	return nil
+}
+
+// markUpgradeFailed annotates the Cluster with in-place upgrade failed. The upgrade goes on, as the failed
+// machines are retried.
+func (r *ClusterInPlaceUpgradeReconciler) markUpgradeFailed(ctx context.Context, scope *clusterInPlaceUpgradeScope, format string, args ...interface{}) error {
+	if scope.cluster.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeFailedStatus {
+		return nil
+	}
+
+	if err := inplace.MarkUpgradeFailed(ctx, scope.cluster, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with upgrade failed: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeWarning,
+		bootstrapv1.InPlaceUpgradeFailedEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// cancelUpgrade annotates the Cluster with in-place upgrade failed, and removes the upgrade instructions.
+func (r *ClusterInPlaceUpgradeReconciler) cancelUpgrade(ctx context.Context, scope *clusterInPlaceUpgradeScope, format string, args ...interface{}) error {
+	delete(scope.cluster.Annotations, bootstrapv1.InPlaceUpgradeToAnnotation)
+	if err := inplace.MarkUpgradeFailed(ctx, scope.cluster, scope.clusterPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with upgrade failed: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.cluster,
+		corev1.EventTypeWarning,
+		bootstrapv1.InPlaceUpgradeCancelledEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// createScope creates a new clusterInPlaceUpgradeScope.
+func (r *ClusterInPlaceUpgradeReconciler) createScope(ctx context.Context, cluster *clusterv1.Cluster) (*clusterInPlaceUpgradeScope, error) {
+	patchHelper, err := patch.NewHelper(cluster, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	// NOTE: The control plane is handled as unstructured, so that the bootstrap provider
+	// does not depend on the control plane provider types.
+	var controlPlane *unstructured.Unstructured
+	if ref := cluster.Spec.ControlPlaneRef; ref != nil {
+		obj, err := external.Get(ctx, r.Client, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get control plane: %w", err)
+		}
+		controlPlane = obj
+
+		// NOTE: The control plane is owned by the Cluster, so that its upgrade progress is seen without polling.
+		if r.controlPlaneTracker != nil {
+			if err := r.controlPlaneTracker.Watch(r.Log, controlPlane, r.controlPlaneHandler); err != nil {
+				return nil, fmt.Errorf("failed to watch control plane: %w", err)
+			}
+		}
+	}
+
+	var mdList clusterv1.MachineDeploymentList
+	if err := r.List(ctx, &mdList, client.InNamespace(cluster.Namespace), client.MatchingLabels{
+		clusterv1.ClusterNameLabel: cluster.Name,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list MachineDeployments: %w", err)
+	}
+
+	machineDeployments := make([]*clusterv1.MachineDeployment, 0, len(mdList.Items))
+	for i := range mdList.Items {
+		machineDeployments = append(machineDeployments, &mdList.Items[i])
+	}
+	sort.Slice(machineDeployments, func(i, j int) bool {
+		return machineDeployments[i].Name < machineDeployments[j].Name
+	})
+
+	return &clusterInPlaceUpgradeScope{
+		cluster:            cluster,
+		clusterPatcher:     patchHelper,
+		upgradeTo:          cluster.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation],
+		controlPlane:       controlPlane,
+		machineDeployments: machineDeployments,
+	}, nil
+}
+
+// isUpgradeDone checks if the in-place upgrade of the object to the release is done.
+func isUpgradeDone(obj client.Object, release string) bool {
+	return inplace.IsUpgraded(obj, release) && obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeToAnnotation] == ""
+}
+
+// isUpgradeFailed checks if the in-place upgrade of the object failed.
+func isUpgradeFailed(obj client.Object) bool {
+	return obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeFailedStatus
+}
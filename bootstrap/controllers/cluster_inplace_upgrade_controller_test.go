@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+const testClusterRelease = "channel=1.31-classic/stable"
+
+func newUpgradeTestControlPlane(annotations map[string]string) *unstructured.Unstructured {
+	cp := &unstructured.Unstructured{}
+	cp.SetAPIVersion("controlplane.cluster.x-k8s.io/v1beta2")
+	cp.SetKind("CK8sControlPlane")
+	cp.SetNamespace("default")
+	cp.SetName("cp")
+	cp.SetAnnotations(annotations)
+	_ = unstructured.SetNestedField(cp.Object, "v1.30.1", "spec", "version")
+	return cp
+}
+
+func newUpgradeTestMachineDeployment(name string, annotations map[string]string) *clusterv1.MachineDeployment {
+	return &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{clusterv1.ClusterNameLabel: "cluster"},
+			Annotations: annotations,
+		},
+		Spec: clusterv1.MachineDeploymentSpec{
+			ClusterName: "cluster",
+			Template: clusterv1.MachineTemplateSpec{
+				Spec: clusterv1.MachineSpec{ClusterName: "cluster", Version: ptr.To("v1.30.1")},
+			},
+		},
+	}
+}
+
+func upgradeDoneAnnotations() map[string]string {
+	return map[string]string{
+		bootstrapv1.InPlaceUpgradeReleaseAnnotation: testClusterRelease,
+		bootstrapv1.InPlaceUpgradeStatusAnnotation:  bootstrapv1.InPlaceUpgradeDoneStatus,
+	}
+}
+
+func TestClusterInPlaceUpgradeReconcile(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// upgradeTo is the release the Cluster is upgraded to.
+		upgradeTo    string
+		controlPlane *unstructured.Unstructured
+		mds          []*clusterv1.MachineDeployment
+
+		expectControlPlaneUpgradeTo string
+		expectMDsUpgradeTo          map[string]string
+		expectClusterStatus         string
+		expectClusterUpgradeTo      string
+	}{
+		{
+			name:         "ControlPlaneFirst",
+			upgradeTo:    testClusterRelease,
+			controlPlane: newUpgradeTestControlPlane(nil),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", nil),
+			},
+			expectControlPlaneUpgradeTo: testClusterRelease,
+			expectMDsUpgradeTo:          map[string]string{"md-0": ""},
+			expectClusterStatus:         bootstrapv1.InPlaceUpgradeInProgressStatus,
+			expectClusterUpgradeTo:      testClusterRelease,
+		},
+		{
+			name:         "WaitForControlPlane",
+			upgradeTo:    testClusterRelease,
+			controlPlane: newUpgradeTestControlPlane(map[string]string{bootstrapv1.InPlaceUpgradeToAnnotation: testClusterRelease}),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", nil),
+			},
+			expectControlPlaneUpgradeTo: testClusterRelease,
+			expectMDsUpgradeTo:          map[string]string{"md-0": ""},
+			expectClusterStatus:         bootstrapv1.InPlaceUpgradeInProgressStatus,
+			expectClusterUpgradeTo:      testClusterRelease,
+		},
+		{
+			name:         "WorkersAfterControlPlane",
+			upgradeTo:    testClusterRelease,
+			controlPlane: newUpgradeTestControlPlane(upgradeDoneAnnotations()),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", nil),
+				newUpgradeTestMachineDeployment("md-1", upgradeDoneAnnotations()),
+			},
+			expectMDsUpgradeTo:     map[string]string{"md-0": testClusterRelease, "md-1": ""},
+			expectClusterStatus:    bootstrapv1.InPlaceUpgradeInProgressStatus,
+			expectClusterUpgradeTo: testClusterRelease,
+		},
+		{
+			name:         "SkewCancelled",
+			upgradeTo:    "channel=1.32-classic/stable",
+			controlPlane: newUpgradeTestControlPlane(nil),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", nil),
+			},
+			expectMDsUpgradeTo:  map[string]string{"md-0": ""},
+			expectClusterStatus: bootstrapv1.InPlaceUpgradeFailedStatus,
+		},
+		{
+			name:         "FailedMachineDeployment",
+			upgradeTo:    testClusterRelease,
+			controlPlane: newUpgradeTestControlPlane(upgradeDoneAnnotations()),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", upgradeDoneAnnotations()),
+				newUpgradeTestMachineDeployment("md-1", map[string]string{
+					bootstrapv1.InPlaceUpgradeToAnnotation:     testClusterRelease,
+					bootstrapv1.InPlaceUpgradeStatusAnnotation: bootstrapv1.InPlaceUpgradeFailedStatus,
+				}),
+			},
+			expectMDsUpgradeTo:     map[string]string{"md-0": "", "md-1": testClusterRelease},
+			expectClusterStatus:    bootstrapv1.InPlaceUpgradeFailedStatus,
+			expectClusterUpgradeTo: testClusterRelease,
+		},
+		{
+			name:         "Done",
+			upgradeTo:    testClusterRelease,
+			controlPlane: newUpgradeTestControlPlane(upgradeDoneAnnotations()),
+			mds: []*clusterv1.MachineDeployment{
+				newUpgradeTestMachineDeployment("md-0", upgradeDoneAnnotations()),
+			},
+			expectMDsUpgradeTo:  map[string]string{"md-0": ""},
+			expectClusterStatus: bootstrapv1.InPlaceUpgradeDoneStatus,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			scheme := runtime.NewScheme()
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster",
+					Namespace:   "default",
+					Annotations: map[string]string{bootstrapv1.InPlaceUpgradeToAnnotation: tc.upgradeTo},
+				},
+				Spec: clusterv1.ClusterSpec{
+					ControlPlaneRef: &corev1.ObjectReference{
+						APIVersion: tc.controlPlane.GetAPIVersion(),
+						Kind:       tc.controlPlane.GetKind(),
+						Namespace:  tc.controlPlane.GetNamespace(),
+						Name:       tc.controlPlane.GetName(),
+					},
+				},
+			}
+			objs := []client.Object{cluster, tc.controlPlane}
+			for _, md := range tc.mds {
+				objs = append(objs, md)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			r := &ClusterInPlaceUpgradeReconciler{
+				Client:   c,
+				Log:      logr.Discard(),
+				scheme:   scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+			g.Expect(cluster.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation]).To(Equal(tc.expectClusterStatus))
+			g.Expect(cluster.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation]).To(Equal(tc.expectClusterUpgradeTo))
+
+			cp := newUpgradeTestControlPlane(nil)
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(cp), cp)).To(Succeed())
+			g.Expect(cp.GetAnnotations()[bootstrapv1.InPlaceUpgradeToAnnotation]).To(Equal(tc.expectControlPlaneUpgradeTo))
+
+			for name, upgradeTo := range tc.expectMDsUpgradeTo {
+				md := &clusterv1.MachineDeployment{}
+				g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, md)).To(Succeed())
+				g.Expect(md.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation]).To(Equal(upgradeTo), "MachineDeployment %s", name)
+			}
+		})
+	}
+}
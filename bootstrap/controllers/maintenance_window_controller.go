@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+)
+
+// MaintenanceWindowReconciler reconciles a MachineDeployment object and restricts the remediation of its machines
+// by MachineHealthChecks to its maintenance windows.
+// NOTE: The machines of a MachineDeployment are remediated by Cluster API, so the remediation is held off with
+// the "cluster.x-k8s.io/skip-remediation" annotation on the machines while no maintenance window is open.
+type MaintenanceWindowReconciler struct {
+	client.Client
+	Log logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MaintenanceWindowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	clusterToMachineDeployments, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineDeploymentList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to MachineDeployments: %w", err)
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.MachineDeployment{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachineDeployments),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		// NOTE: the machines are owned by the MachineSets of the MachineDeployment, so they are mapped through
+		// their MachineDeployment name label instead.
+		Watches(
+			&clusterv1.Machine{},
+			handler.EnqueueRequestsFromMapFunc(machineToMachineDeployment),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile handles the reconciliation of a MachineDeployment object.
+func (r *MaintenanceWindowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("maintenance_window", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	md := &clusterv1.MachineDeployment{}
+	if err := r.Get(ctx, req.NamespacedName, md); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("MachineDeployment resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get MachineDeployment: %w", err)
+	}
+
+	if isDeleted(md) {
+		log.V(1).Info("MachineDeployment is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName}, cluster); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, md) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(md, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	// NOTE: Invalid annotations are reported on the MachineDeployment instead of failing the reconciliation,
+	// as they are only fixed by the user. The machines are left as-is until then.
+	windows, parseErr := maintenance.FromAnnotations(md)
+	switch {
+	case parseErr != nil:
+		log.Info("Invalid maintenance windows, skipping reconciliation", "error", parseErr.Error())
+		conditions.MarkFalse(md, bootstrapv1.MaintenanceWindowCondition, bootstrapv1.InvalidMaintenanceWindowReason, clusterv1.ConditionSeverityError, "%s", parseErr.Error())
+	case conditions.GetReason(md, bootstrapv1.MaintenanceWindowCondition) == bootstrapv1.InvalidMaintenanceWindowReason:
+		conditions.Delete(md, bootstrapv1.MaintenanceWindowCondition)
+	}
+	if err := patchHelper.Patch(ctx, md, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.MaintenanceWindowCondition}}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to patch MachineDeployment: %w", err)
+	}
+	if parseErr != nil {
+		return ctrl.Result{}, nil
+	}
+
+	machines, err := getMachineDeploymentMachines(ctx, r.Client, md)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get machines: %w", err)
+	}
+
+	now := time.Now()
+	open := windows.IsOpen(now)
+	for _, m := range machines {
+		if isDeleted(m) {
+			continue
+		}
+		if err := r.reconcileSkipRemediation(ctx, m, !open); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if open {
+		if closesAt := windows.ClosesAt(now); !closesAt.IsZero() {
+			return ctrl.Result{RequeueAfter: closesAt.Sub(now)}, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
+	log.V(1).Info("No maintenance window is open, the remediation of the machines is held off")
+	if next := windows.NextOpen(now); !next.IsZero() {
+		return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+	return ctrl.Result{RequeueAfter: time.Hour}, nil
+}
+
+// reconcileSkipRemediation sets or removes the skip remediation annotation of the machine. The annotation is only
+// removed if it was set by this controller.
+func (r *MaintenanceWindowReconciler) reconcileSkipRemediation(ctx context.Context, m *clusterv1.Machine, skip bool) error {
+	_, marked := m.Annotations[bootstrapv1.MaintenanceWindowSkipRemediationAnnotation]
+	if skip == marked || (skip && annotations.HasSkipRemediation(m)) {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(m, r.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	if skip {
+		annotations.AddAnnotations(m, map[string]string{
+			clusterv1.MachineSkipRemediationAnnotation:             "",
+			bootstrapv1.MaintenanceWindowSkipRemediationAnnotation: "",
+		})
+	} else {
+		delete(m.Annotations, clusterv1.MachineSkipRemediationAnnotation)
+		delete(m.Annotations, bootstrapv1.MaintenanceWindowSkipRemediationAnnotation)
+	}
+
+	if err := patchHelper.Patch(ctx, m); err != nil {
+		return fmt.Errorf("failed to patch machine %q: %w", m.Name, err)
+	}
+	return nil
+}
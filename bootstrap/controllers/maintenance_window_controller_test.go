@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+func TestMaintenanceWindowReconcile(t *testing.T) {
+	const (
+		// alwaysOpen is a window that opens every minute and stays open for an hour.
+		alwaysOpen = "* * * * * 1h"
+		// neverOpen is a window that only opens on the 31st of February.
+		neverOpen = "0 0 31 2 * 1m"
+	)
+
+	newMachine := func(name string, annotations map[string]string) *clusterv1.Machine {
+		return &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: annotations,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel:           "cluster",
+				clusterv1.MachineDeploymentNameLabel: "md",
+			},
+		}}
+	}
+	skipped := map[string]string{
+		clusterv1.MachineSkipRemediationAnnotation:             "",
+		bootstrapv1.MaintenanceWindowSkipRemediationAnnotation: "",
+	}
+	skippedByUser := map[string]string{clusterv1.MachineSkipRemediationAnnotation: ""}
+
+	setup := func(g *WithT, windows string, objs ...client.Object) (*MaintenanceWindowReconciler, client.Client) {
+		scheme := runtime.NewScheme()
+		g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+		md := &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "md",
+				Namespace:   "default",
+				Annotations: map[string]string{bootstrapv1.MaintenanceWindowsAnnotation: windows},
+			},
+			Spec: clusterv1.MachineDeploymentSpec{ClusterName: "cluster"},
+		}
+		cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		c := fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(append(objs, md, cluster)...).
+			WithStatusSubresource(&clusterv1.MachineDeployment{}).
+			Build()
+		return &MaintenanceWindowReconciler{Client: c, Log: logr.Discard()}, c
+	}
+	reconcileMD := func(g *WithT, r *MaintenanceWindowReconciler) ctrl.Result {
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "md"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		return result
+	}
+	getAnnotations := func(g *WithT, c client.Client, name string) map[string]string {
+		m := &clusterv1.Machine{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, m)).To(Succeed())
+		return m.Annotations
+	}
+
+	t.Run("Closed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := setup(g, neverOpen,
+			newMachine("worker-0", nil),
+			newMachine("worker-1", skippedByUser),
+		)
+
+		result := reconcileMD(g, r)
+		g.Expect(result.RequeueAfter).ToNot(BeZero())
+		g.Expect(getAnnotations(g, c, "worker-0")).To(Equal(skipped))
+		g.Expect(getAnnotations(g, c, "worker-1")).To(Equal(skippedByUser))
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := setup(g, alwaysOpen,
+			newMachine("worker-0", skipped),
+			newMachine("worker-1", skippedByUser),
+		)
+
+		result := reconcileMD(g, r)
+		g.Expect(result.RequeueAfter).ToNot(BeZero())
+		g.Expect(getAnnotations(g, c, "worker-0")).To(BeEmpty())
+		g.Expect(getAnnotations(g, c, "worker-1")).To(Equal(skippedByUser))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := setup(g, "0 22 * * 1-5", newMachine("worker-0", nil))
+
+		reconcileMD(g, r)
+		g.Expect(getAnnotations(g, c, "worker-0")).To(BeEmpty())
+
+		md := &clusterv1.MachineDeployment{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "md"}, md)).To(Succeed())
+		g.Expect(conditions.GetReason(md, bootstrapv1.MaintenanceWindowCondition)).To(Equal(bootstrapv1.InvalidMaintenanceWindowReason))
+
+		// The condition is removed once the annotation is fixed.
+		md.Annotations[bootstrapv1.MaintenanceWindowsAnnotation] = alwaysOpen
+		g.Expect(c.Update(context.Background(), md)).To(Succeed())
+
+		reconcileMD(g, r)
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "md"}, md)).To(Succeed())
+		g.Expect(conditions.Has(md, bootstrapv1.MaintenanceWindowCondition)).To(BeFalse())
+	})
+}
@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+)
+
+// MachineDeploymentCertificatesReconciler reconciles a MachineDeployment object and orchestrates
+// the certificates refresh of its machines.
+type MachineDeploymentCertificatesReconciler struct {
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	client.Client
+	Log logr.Logger
+}
+
+// machineDeploymentCertificatesScope is a struct that holds the context of the certificates refresh process.
+type machineDeploymentCertificatesScope struct {
+	machineDeployment *clusterv1.MachineDeployment
+	mdPatcher         certificates.Patcher
+	ttl               string
+	maxConcurrency    int
+	ownedMachines     []*clusterv1.Machine
+	windows           *maintenance.Windows
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MachineDeploymentCertificatesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-md-certificates-controller")
+
+	clusterToMachineDeployments, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineDeploymentList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to MachineDeployments: %w", err)
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.MachineDeployment{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachineDeployments),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		Watches(
+			&clusterv1.Machine{},
+			handler.EnqueueRequestsFromMapFunc(machineToMachineDeployment),
+			builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log)),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile handles the reconciliation of a MachineDeployment object.
+func (r *MachineDeploymentCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("orchestrated_certificates_refresh", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	md := &clusterv1.MachineDeployment{}
+	if err := r.Get(ctx, req.NamespacedName, md); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("MachineDeployment resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get MachineDeployment: %w", err)
+	}
+
+	if certificates.GetRefreshInstructions(md) == "" {
+		log.V(1).Info("MachineDeployment has no certificates refresh instructions, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	if isDeleted(md) {
+		log.V(1).Info("MachineDeployment is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: md.Namespace, Name: md.Spec.ClusterName}, cluster); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, md) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, md)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
+	}
+
+	if !certificates.IsRefreshInProgress(md) {
+		if err := r.startRefresh(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to start certificates refresh: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	progress := certificates.GetRefreshProgress(scope.ownedMachines)
+
+	if len(progress.Failed) > 0 {
+		log.Info("Certificates refresh failed for machine, stopping", "machine", progress.Failed[0].Name)
+		if err := r.markRefreshFailed(ctx, scope, "Certificates refresh failed for machine %q", progress.Failed[0].Name); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as failed: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if len(progress.Pending) == 0 && len(progress.Refreshing) == 0 {
+		if err := r.markRefreshDone(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as done: %w", err)
+		}
+
+		log.V(1).Info("All machines have their certificates refreshed")
+		return ctrl.Result{}, nil
+	}
+
+	// The certificates refresh of new machines only starts within a maintenance window.
+	if len(progress.Pending) > 0 && len(progress.Refreshing) < scope.maxConcurrency {
+		if result, wait, err := r.waitForMaintenanceWindow(ctx, scope); err != nil || wait {
+			log.V(1).Info("Waiting for a maintenance window to refresh machine certificates", "pending", len(progress.Pending))
+			return result, err
+		}
+	}
+
+	refreshing := len(progress.Refreshing)
+	for _, m := range progress.Pending {
+		if refreshing >= scope.maxConcurrency {
+			break
+		}
+
+		if isDeleted(m) {
+			log.V(1).Info("Machine is being deleted, skipping", "machine", m.Name)
+			continue
+		}
+
+		if err := r.markMachineToRefresh(ctx, scope, m); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark machine to refresh certificates: %w", err)
+		}
+
+		log.V(1).Info("Machine marked for certificates refresh", "machine", m.Name)
+		refreshing++
+	}
+
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// startRefresh validates the refresh request, resets the refresh status of the machines
+// and marks the MachineDeployment as certificates refresh in-progress.
+func (r *MachineDeploymentCertificatesReconciler) startRefresh(ctx context.Context, scope *machineDeploymentCertificatesScope) error {
+	if _, err := utiltime.TTLToSeconds(scope.ttl); err != nil {
+		return r.markRefreshFailed(ctx, scope, "Invalid certificates TTL %q: %v", scope.ttl, err)
+	}
+	if scope.maxConcurrency == 0 {
+		return r.markRefreshFailed(ctx, scope, "Invalid value for annotation %q", bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation)
+	}
+
+	for _, m := range scope.ownedMachines {
+		if err := certificates.ResetRefreshStatus(ctx, m, r.Client); err != nil {
+			return fmt.Errorf("failed to reset certificates refresh status of machine %q: %w", m.Name, err)
+		}
+	}
+
+	if err := certificates.MarkRefreshInProgress(ctx, scope.machineDeployment, scope.mdPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh in-progress: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.machineDeployment,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Certificates refresh is in-progress. TTL: %s",
+		scope.ttl,
+	)
+	return nil
+}
+
+// markRefreshDone annotates the MachineDeployment with certificates refresh done.
+func (r *MachineDeploymentCertificatesReconciler) markRefreshDone(ctx context.Context, scope *machineDeploymentCertificatesScope) error {
+	if err := certificates.MarkRefreshDone(ctx, scope.machineDeployment, scope.mdPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh done: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.machineDeployment,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshDoneEvent,
+		"Certificates refresh is done",
+	)
+	return nil
+}
+
+// markRefreshFailed annotates the MachineDeployment with certificates refresh failed.
+func (r *MachineDeploymentCertificatesReconciler) markRefreshFailed(ctx context.Context, scope *machineDeploymentCertificatesScope, format string, args ...interface{}) error {
+	if err := certificates.MarkRefreshFailed(ctx, scope.machineDeployment, scope.mdPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh failed: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.machineDeployment,
+		corev1.EventTypeWarning,
+		bootstrapv1.CertificatesRefreshFailedEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// markMachineToRefresh marks the machine to refresh its certificates.
+func (r *MachineDeploymentCertificatesReconciler) markMachineToRefresh(ctx context.Context, scope *machineDeploymentCertificatesScope, m *clusterv1.Machine) error {
+	if err := certificates.MarkMachineToRefresh(ctx, m, scope.ttl, r.Client); err != nil {
+		return fmt.Errorf("failed to mark machine to refresh certificates: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.machineDeployment,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Machine %q is refreshing certificates",
+		m.Name,
+	)
+
+	return nil
+}
+
+// waitForMaintenanceWindow checks if the certificates refresh of new machines may start, according to the
+// maintenance windows of the MachineDeployment, and reports the result on the MachineDeployment.
+func (r *MachineDeploymentCertificatesReconciler) waitForMaintenanceWindow(ctx context.Context, scope *machineDeploymentCertificatesScope) (ctrl.Result, bool, error) {
+	allowed, requeueAfter := maintenance.Allow(scope.machineDeployment, scope.windows, time.Now(), "the certificates refresh of the next machines")
+	if !allowed || conditions.Has(scope.machineDeployment, bootstrapv1.MaintenanceWindowCondition) {
+		if err := scope.mdPatcher.Patch(ctx, scope.machineDeployment, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.MaintenanceWindowCondition}}); err != nil {
+			return ctrl.Result{}, false, fmt.Errorf("failed to patch MachineDeployment: %w", err)
+		}
+	}
+
+	if !allowed {
+		return ctrl.Result{RequeueAfter: requeueAfter}, true, nil
+	}
+	return ctrl.Result{}, false, nil
+}
+
+// createScope creates a new machineDeploymentCertificatesScope.
+func (r *MachineDeploymentCertificatesReconciler) createScope(ctx context.Context, md *clusterv1.MachineDeployment) (*machineDeploymentCertificatesScope, error) {
+	patchHelper, err := patch.NewHelper(md, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	ownedMachines, err := getMachineDeploymentMachines(ctx, r.Client, md)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned machines: %w", err)
+	}
+
+	windows, err := maintenance.FromAnnotations(md)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance window: %w", err)
+	}
+
+	// NOTE: An invalid concurrency is reported when the refresh is started.
+	maxConcurrency, _ := certificates.GetMaxConcurrency(md)
+
+	return &machineDeploymentCertificatesScope{
+		machineDeployment: md,
+		mdPatcher:         patchHelper,
+		ttl:               certificates.GetRefreshInstructions(md),
+		maxConcurrency:    maxConcurrency,
+		ownedMachines:     ownedMachines,
+		windows:           windows,
+	}, nil
+}
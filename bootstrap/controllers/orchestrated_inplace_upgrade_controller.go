@@ -4,22 +4,32 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
 	"github.com/canonical/cluster-api-k8s/pkg/trace"
 	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
 )
@@ -32,14 +42,21 @@ type OrchestratedInPlaceUpgradeController struct {
 
 	client.Client
 	Log logr.Logger
+
+	K8sdDialTimeout time.Duration
+
+	managementCluster ck8s.ManagementCluster
 }
 
 // orchestratedInPlaceUpgradeScope is a struct that holds the context of the upgrade process.
 type orchestratedInPlaceUpgradeScope struct {
+	cluster           *clusterv1.Cluster
 	machineDeployment *clusterv1.MachineDeployment
 	mdPatcher         inplace.Patcher
 	upgradeTo         string
 	ownedMachines     []*clusterv1.Machine
+	healthGates       *inplace.HealthGates
+	windows           *maintenance.Windows
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -47,10 +64,20 @@ func (r *OrchestratedInPlaceUpgradeController) SetupWithManager(mgr ctrl.Manager
 	r.scheme = mgr.GetScheme()
 	r.recorder = mgr.GetEventRecorderFor("ck8s-md-orchestrated-inplace-upgrade-controller")
 
+	management := &ck8s.Management{
+		Client:          r.Client,
+		K8sdDialTimeout: r.K8sdDialTimeout,
+	}
 	if r.machineGetter == nil {
-		r.machineGetter = &ck8s.Management{
-			Client: r.Client,
-		}
+		r.machineGetter = management
+	}
+	if r.managementCluster == nil {
+		r.managementCluster = management
+	}
+
+	clusterToMachineDeployments, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineDeploymentList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to MachineDeployments: %w", err)
 	}
 
 	// NOTE(Hue): Initially, I tried to go with comprehensive predicates but there was two problems with that:
@@ -58,7 +85,12 @@ func (r *OrchestratedInPlaceUpgradeController) SetupWithManager(mgr ctrl.Manager
 	// 2. Sometimes the reconciliation was not getting triggered when it should have, debugging this
 	// through the predicates was a nightmare.
 	if err := ctrl.NewControllerManagedBy(mgr).
-		For(&clusterv1.MachineDeployment{}).
+		For(&clusterv1.MachineDeployment{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachineDeployments),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
 		Owns(&clusterv1.Machine{}).
 		Complete(r); err != nil {
 		return fmt.Errorf("failed to get new controller builder: %w", err)
@@ -67,10 +99,13 @@ func (r *OrchestratedInPlaceUpgradeController) SetupWithManager(mgr ctrl.Manager
 	return nil
 }
 
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinesets;machinesets/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8scontrolplanes,verbs=get;list;watch
 
 // Reconcile handles the reconciliation of a MachineDeployment object.
 func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -97,15 +132,59 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, nil
 	}
 
-	scope, err := r.createScope(ctx, machineDeployment)
+	cluster, err := r.getCluster(ctx, machineDeployment)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if annotations.IsPaused(cluster, machineDeployment) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, cluster, machineDeployment)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
 	}
 
+	// The preflight checks run before the upgrade starts, so that no machine is touched if they fail.
+	if inplace.NeedsPreflightChecks(machineDeployment, scope.ownedMachines) {
+		if result, passed, err := r.runPreflightChecks(ctx, scope); err != nil || !passed {
+			return result, err
+		}
+	}
+
 	// Starting the upgrade process
-	var upgradedMachines int
+	var (
+		upgradedMachines  int
+		failedMachine     *clusterv1.Machine
+		upgradingMachines []*clusterv1.Machine
+		pendingMachines   []*clusterv1.Machine
+
+		// unhealthyMachine is the upgraded machine that did not pass the health gates yet, failed ones first.
+		unhealthyMachine *clusterv1.Machine
+		unhealthyResult  *inplace.HealthCheckResult
+		checkedMachine   *clusterv1.Machine
+	)
 	for _, m := range scope.ownedMachines {
 		if inplace.IsUpgraded(m, scope.upgradeTo) {
+			if scope.healthGates != nil && inplace.NeedsHealthCheck(m, scope.upgradeTo) {
+				result, err := r.checkHealthGates(ctx, scope, m)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+
+				if !result.Healthy {
+					// NOTE: Machines are unavailable until they pass the health gates.
+					upgradingMachines = append(upgradingMachines, m)
+					if unhealthyMachine == nil || (result.Failed && !unhealthyResult.Failed) {
+						unhealthyMachine, unhealthyResult = m, result
+					}
+					continue
+				}
+				checkedMachine = m
+			}
+
 			log.V(1).Info("Machine is already upgraded", "machine", m.Name)
 			upgradedMachines++
 			continue
@@ -116,32 +195,44 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 		}
 
-		if inplace.IsMachineUpgradeFailed(m) {
-			log.Info("Machine upgrade failed for machine, requeuing...", "machine", m.Name)
-			if err := r.markUpgradeFailed(ctx, scope, m); err != nil {
-				return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
+		switch {
+		// NOTE: Rolled back machines are failed as well, so they halt the upgrade until they are retried.
+		case inplace.IsMachineUpgradeFailed(m) || inplace.IsMachineRolledBack(m):
+			if failedMachine == nil {
+				failedMachine = m
 			}
-
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
-		}
-
-		if inplace.IsMachineUpgrading(m) {
-			log.V(1).Info("Machine is upgrading, requeuing...", "machine", m.Name)
-			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		case inplace.IsMachineUpgrading(m):
+			upgradingMachines = append(upgradingMachines, m)
+		default:
+			pendingMachines = append(pendingMachines, m)
 		}
+	}
 
-		// Machine is not upgraded, mark it for upgrade
-		if err := r.markMachineToUpgrade(ctx, scope, m); err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to mark machine to upgrade: %w", err)
+	// No new upgrades are started as soon as a machine fails.
+	if failedMachine != nil {
+		log.Info("Machine upgrade failed for machine, requeuing...", "machine", failedMachine.Name)
+		if err := r.markUpgradeFailed(ctx, scope, failedMachine); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
 		}
 
-		log.V(1).Info("Machine marked for upgrade", "machine", m.Name)
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
 
-		if err := r.markUpgradeInProgress(ctx, scope, m); err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as in-progress: %w", err)
+	switch {
+	case unhealthyMachine != nil:
+		if err := r.markHealthGates(ctx, scope, unhealthyMachine, unhealthyResult); err != nil {
+			return ctrl.Result{}, err
 		}
+	case checkedMachine != nil:
+		if err := r.markHealthGates(ctx, scope, checkedMachine, &inplace.HealthCheckResult{Healthy: true}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	// No new upgrades are started while a machine does not pass the health gates within the timeout.
+	if unhealthyResult != nil && unhealthyResult.Failed {
+		log.Info("Machine did not pass the health gates, pausing the upgrade...", "machine", unhealthyMachine.Name)
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
 	if upgradedMachines == len(scope.ownedMachines) {
@@ -154,6 +245,34 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, nil
 	}
 
+	maxUnavailable, err := inplace.GetMaxUnavailable(scope.machineDeployment, len(scope.ownedMachines))
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get max unavailable machines: %w", err)
+	}
+
+	spread := inplace.IsSpreadFailureDomainsEnabled(scope.machineDeployment)
+	toUpgrade := inplace.SelectMachinesToUpgrade(pendingMachines, upgradingMachines, maxUnavailable-len(upgradingMachines), spread)
+	if len(toUpgrade) > 0 {
+		// The upgrade of new machines only starts within a maintenance window.
+		if result, wait, err := r.waitForMaintenanceWindow(ctx, scope); err != nil || wait {
+			log.V(1).Info("Waiting for a maintenance window to upgrade machines", "pending", len(pendingMachines))
+			return result, err
+		}
+	}
+
+	for _, m := range toUpgrade {
+		// Machine is not upgraded, mark it for upgrade
+		if err := r.markMachineToUpgrade(ctx, scope, m); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark machine to upgrade: %w", err)
+		}
+
+		log.V(1).Info("Machine marked for upgrade", "machine", m.Name)
+
+		if err := r.markUpgradeInProgress(ctx, scope, m); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as in-progress: %w", err)
+		}
+	}
+
 	// Not all the machines were upgraded, requeue.
 	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 }
@@ -205,8 +324,147 @@ func (r *OrchestratedInPlaceUpgradeController) markUpgradeFailed(ctx context.Con
 	return nil
 }
 
+// getWorkloadCluster gets the workload cluster, through the microcluster port of the machine.
+func (r *OrchestratedInPlaceUpgradeController) getWorkloadCluster(ctx context.Context, scope *orchestratedInPlaceUpgradeScope, m *clusterv1.Machine) (*ck8s.Workload, error) {
+	// Lookup the ck8s config used by the machine
+	config := &bootstrapv1.CK8sConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: m.Spec.Bootstrap.ConfigRef.Name}, config); err != nil {
+		return nil, fmt.Errorf("failed to get CK8sConfig of machine %q: %w", m.Name, err)
+	}
+
+	workload, err := r.managementCluster.GetWorkloadCluster(ctx, util.ObjectKey(scope.cluster), config.Spec.ControlPlaneConfig.GetMicroclusterPort())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workload cluster: %w", err)
+	}
+	return workload, nil
+}
+
+// checkHealthGates checks the health gates of the upgraded machine.
+func (r *OrchestratedInPlaceUpgradeController) checkHealthGates(ctx context.Context, scope *orchestratedInPlaceUpgradeScope, m *clusterv1.Machine) (*inplace.HealthCheckResult, error) {
+	workload, err := r.getWorkloadCluster(ctx, scope, m)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := inplace.CheckMachineHealth(ctx, m, scope.upgradeTo, scope.healthGates, workload, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check health of machine %q: %w", m.Name, err)
+	}
+	return result, nil
+}
+
+// markHealthGates reports the result of the health gates of the machine on the MachineDeployment.
+func (r *OrchestratedInPlaceUpgradeController) markHealthGates(ctx context.Context, scope *orchestratedInPlaceUpgradeScope, m *clusterv1.Machine, result *inplace.HealthCheckResult) error {
+	switch {
+	case result.Healthy:
+		conditions.MarkTrue(scope.machineDeployment, bootstrapv1.InPlaceUpgradeHealthyCondition)
+	case result.Failed:
+		if conditions.GetReason(scope.machineDeployment, bootstrapv1.InPlaceUpgradeHealthyCondition) != bootstrapv1.InPlaceUpgradeHealthCheckFailedReason {
+			r.recorder.Eventf(
+				scope.machineDeployment,
+				corev1.EventTypeWarning,
+				bootstrapv1.InPlaceUpgradeHealthCheckFailedEvent,
+				"Machine %q did not pass the health gates: %s",
+				m.Name,
+				result.Message,
+			)
+		}
+		conditions.MarkFalse(scope.machineDeployment, bootstrapv1.InPlaceUpgradeHealthyCondition, bootstrapv1.InPlaceUpgradeHealthCheckFailedReason, clusterv1.ConditionSeverityError, "Machine %q did not pass the health gates: %s", m.Name, result.Message)
+	default:
+		conditions.MarkFalse(scope.machineDeployment, bootstrapv1.InPlaceUpgradeHealthyCondition, bootstrapv1.InPlaceUpgradeHealthCheckPendingReason, clusterv1.ConditionSeverityInfo, "Waiting for machine %q to pass the health gates: %s", m.Name, result.Message)
+	}
+
+	if err := scope.mdPatcher.Patch(ctx, scope.machineDeployment, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.InPlaceUpgradeHealthyCondition}}); err != nil {
+		return fmt.Errorf("failed to patch MachineDeployment: %w", err)
+	}
+	return nil
+}
+
+// runPreflightChecks checks that the in-place upgrade can start, and reports the result on the MachineDeployment.
+func (r *OrchestratedInPlaceUpgradeController) runPreflightChecks(ctx context.Context, scope *orchestratedInPlaceUpgradeScope) (ctrl.Result, bool, error) {
+	controlPlaneVersion, err := r.getControlPlaneVersion(ctx, scope.cluster)
+	if err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to get control plane version: %w", err)
+	}
+
+	result := inplace.RunPreflightChecks(ctx, r.Client, inplace.Preflight{
+		Cluster:             scope.cluster,
+		Object:              scope.machineDeployment,
+		Machines:            scope.ownedMachines,
+		Release:             scope.upgradeTo,
+		ControlPlaneVersion: controlPlaneVersion,
+		Workers:             true,
+	})
+
+	if len(result.Reasons) == 0 {
+		// NOTE: The skipped checks are reported, so that passing them is not mistaken for checking them.
+		conditions.Set(scope.machineDeployment, &clusterv1.Condition{
+			Type:    bootstrapv1.InPlaceUpgradePreflightCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  bootstrapv1.InPlaceUpgradePreflightChecksSkippedReason,
+			Message: fmt.Sprintf("Skipped: %s", strings.Join(result.Skipped, "; ")),
+		})
+	} else {
+		message := strings.Join(result.Reasons, "; ")
+		if conditions.GetMessage(scope.machineDeployment, bootstrapv1.InPlaceUpgradePreflightCondition) != message {
+			r.recorder.Eventf(
+				scope.machineDeployment,
+				corev1.EventTypeWarning,
+				bootstrapv1.InPlaceUpgradePreflightFailedEvent,
+				"In-place upgrade to %q did not pass the preflight checks: %s",
+				scope.upgradeTo,
+				message,
+			)
+		}
+		conditions.MarkFalse(scope.machineDeployment, bootstrapv1.InPlaceUpgradePreflightCondition, bootstrapv1.InPlaceUpgradePreflightFailedReason, clusterv1.ConditionSeverityError, "%s", message)
+	}
+
+	if err := scope.mdPatcher.Patch(ctx, scope.machineDeployment, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.InPlaceUpgradePreflightCondition}}); err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to patch MachineDeployment: %w", err)
+	}
+
+	if len(result.Reasons) > 0 {
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, false, nil
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// getControlPlaneVersion returns the current Kubernetes version of the control plane of the cluster, if any.
+func (r *OrchestratedInPlaceUpgradeController) getControlPlaneVersion(ctx context.Context, cluster *clusterv1.Cluster) (string, error) {
+	if cluster.Spec.ControlPlaneRef == nil {
+		return "", nil
+	}
+
+	controlPlane, err := external.Get(ctx, r.Client, cluster.Spec.ControlPlaneRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to get control plane: %w", err)
+	}
+
+	specVersion, _, err := unstructured.NestedString(controlPlane.Object, "spec", "version")
+	if err != nil {
+		return "", fmt.Errorf("failed to get control plane version: %w", err)
+	}
+	return inplace.GetCurrentVersion(controlPlane, specVersion), nil
+}
+
+// waitForMaintenanceWindow checks if the upgrade of new machines may start, according to the maintenance windows
+// of the MachineDeployment, and reports the result on the MachineDeployment.
+func (r *OrchestratedInPlaceUpgradeController) waitForMaintenanceWindow(ctx context.Context, scope *orchestratedInPlaceUpgradeScope) (ctrl.Result, bool, error) {
+	allowed, requeueAfter := maintenance.Allow(scope.machineDeployment, scope.windows, time.Now(), "the in-place upgrade of the next machines")
+	if !allowed || conditions.Has(scope.machineDeployment, bootstrapv1.MaintenanceWindowCondition) {
+		if err := scope.mdPatcher.Patch(ctx, scope.machineDeployment, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.MaintenanceWindowCondition}}); err != nil {
+			return ctrl.Result{}, false, fmt.Errorf("failed to patch MachineDeployment: %w", err)
+		}
+	}
+
+	if !allowed {
+		return ctrl.Result{RequeueAfter: requeueAfter}, true, nil
+	}
+	return ctrl.Result{}, false, nil
+}
+
 // createScope creates a new MachineDeploymentUpgradeScope.
-func (r *OrchestratedInPlaceUpgradeController) createScope(ctx context.Context, md *clusterv1.MachineDeployment) (*orchestratedInPlaceUpgradeScope, error) {
+func (r *OrchestratedInPlaceUpgradeController) createScope(ctx context.Context, cluster *clusterv1.Cluster, md *clusterv1.MachineDeployment) (*orchestratedInPlaceUpgradeScope, error) {
 	patchHelper, err := patch.NewHelper(md, r.Client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
@@ -217,11 +475,31 @@ func (r *OrchestratedInPlaceUpgradeController) createScope(ctx context.Context,
 		return nil, fmt.Errorf("failed to get owned machines: %w", err)
 	}
 
+	// NOTE: The machines selected by a CK8sUpgradePlan are left to the plan.
+	selectedByPlan, err := inplace.SelectedByUpgradePlan(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upgrade plans: %w", err)
+	}
+	ownedMachines = slices.DeleteFunc(ownedMachines, selectedByPlan)
+
+	healthGates, err := inplace.GetHealthGates(md)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health gates: %w", err)
+	}
+
+	windows, err := maintenance.FromAnnotations(md)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance windows: %w", err)
+	}
+
 	return &orchestratedInPlaceUpgradeScope{
+		cluster:           cluster,
 		machineDeployment: md,
 		upgradeTo:         inplace.GetUpgradeInstructions(md),
 		ownedMachines:     ownedMachines,
 		mdPatcher:         patchHelper,
+		healthGates:       healthGates,
+		windows:           windows,
 	}, nil
 }
 
@@ -289,9 +567,9 @@ func (r *OrchestratedInPlaceUpgradeController) getOwnedMachines(ctx context.Cont
 
 	// NOTE(Hue): Sorting machines by their UID to make sure we have a deterministic order.
 	// This is to (kind of) make sure we upgrade the machines in the same order every time.
-	// Meaning that if in the previous reconciliation we annotated a machine with upgrade-to,
-	// In the next reconciliation we will make sure that upgrade was successful before moving
-	// to the next machine.
+	// Meaning that if in the previous reconciliation we annotated machines with upgrade-to,
+	// In the next reconciliation we will make sure that no more than maxUnavailable machines
+	// are upgrading before moving to the next machines.
 	// This is not the most robust way to do this, but it's good enough.
 	// A better way to do this might be to use some kind of lock (via a secret or something),
 	// similar to control plane init lock.
@@ -311,7 +589,7 @@ func (r *OrchestratedInPlaceUpgradeController) getOwnedMachines(ctx context.Cont
 
 // markMachineToUpgrade marks the machine to upgrade.
 func (r *OrchestratedInPlaceUpgradeController) markMachineToUpgrade(ctx context.Context, scope *orchestratedInPlaceUpgradeScope, m *clusterv1.Machine) error {
-	if err := inplace.MarkMachineToUpgrade(ctx, m, scope.upgradeTo, r.Client); err != nil {
+	if err := inplace.MarkMachineToUpgrade(ctx, m, scope.upgradeTo, scope.machineDeployment, r.Client); err != nil {
 		return fmt.Errorf("failed to mark machine to upgrade: %w", err)
 	}
 
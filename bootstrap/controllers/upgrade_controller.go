@@ -13,16 +13,24 @@ import (
 	"k8s.io/client-go/tools/record"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
 	"github.com/canonical/cluster-api-k8s/pkg/token"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
 )
 
+// rollbackVerifyTimeout is how long the node has to become Ready on its previous version after a rollback.
+const rollbackVerifyTimeout = 10 * time.Minute
+
 // InPlaceUpgradeReconciler reconciles machines and performs in-place upgrades based on annotations.
 type InPlaceUpgradeReconciler struct {
 	client.Client
@@ -33,15 +41,31 @@ type InPlaceUpgradeReconciler struct {
 	K8sdDialTimeout time.Duration
 
 	managementCluster ck8s.ManagementCluster
+	upgradeLock       inplace.UpgradeLock
 }
 
 func (r *InPlaceUpgradeReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	if _, err := ctrl.NewControllerManagedBy(mgr).For(&clusterv1.Machine{}).Build(r); err != nil {
+	clusterToMachines, err := util.ClusterToTypedObjectsMapper(mgr.GetClient(), &clusterv1.MachineList{}, mgr.GetScheme())
+	if err != nil {
+		return fmt.Errorf("failed to create mapper for Cluster to Machines: %w", err)
+	}
+
+	if _, err := ctrl.NewControllerManagedBy(mgr).
+		For(&clusterv1.Machine{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToMachines),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		Build(r); err != nil {
 		return fmt.Errorf("failed setting up with a controller manager: %w", err)
 	}
 
 	r.Scheme = mgr.GetScheme()
 	r.recorder = mgr.GetEventRecorderFor("ck8s-in-place-upgrade-controller")
+	if r.upgradeLock == nil {
+		r.upgradeLock = inplace.NewUpgradeLock(r.Client, r.recorder)
+	}
 
 	if r.managementCluster == nil {
 		r.managementCluster = &ck8s.Management{
@@ -63,9 +87,10 @@ type UpgradeScope struct {
 
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigs,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigs/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets;events;configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;update;patch
 
 func (r *InPlaceUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("namespace", req.Namespace, "machine", req.Name)
@@ -96,6 +121,11 @@ func (r *InPlaceUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	if annotations.IsPaused(cluster, m) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
 	// Get the workload cluster for the machine
 	workloadCluster, err := r.getWorkloadClusterForMachine(ctx, util.ObjectKey(cluster), m)
 	if err != nil {
@@ -135,6 +165,12 @@ func (r *InPlaceUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			return r.handleUpgradeDone(ctx, scope)
 		case bootstrapv1.InPlaceUpgradeFailedStatus:
 			return r.handleUpgradeFailed(ctx, scope)
+		case bootstrapv1.InPlaceUpgradeRollingBackStatus:
+			return r.handleRollbackInProgress(ctx, scope, changeID)
+		case bootstrapv1.InPlaceUpgradeRolledBackStatus:
+			// NOTE: Rolled back upgrades are not retried, so that the node stays on its previous version
+			// until the status is set to "failed".
+			return ctrl.Result{}, nil
 		default:
 			log.Info("Found invalid in-place upgrade status, marking as failed")
 			if err := r.markUpgradeFailed(ctx, scope, "invalid in-place upgrade status"); err != nil {
@@ -210,18 +246,64 @@ func (r *InPlaceUpgradeReconciler) handleUpgradeRequest(ctx context.Context, sco
 		return ctrl.Result{}, fmt.Errorf("failed to lookup node token: %w", err)
 	}
 
+	// Resolve a Kubernetes version to the snap channel or revision to refresh to
+	release, err := inplace.ResolveRelease(ctx, r.Client, scope.Machine, scope.UpgradeOption)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to resolve in-place upgrade release: %w", err)
+	}
+
 	mAnnotations := scope.Machine.GetAnnotations()
 
+	// Record the release of the previous in-place upgrade, to refresh back to it if the upgrade is rolled back.
+	// NOTE: It is kept across retries, since the release annotation is removed by the first attempt.
+	if release, ok := mAnnotations[bootstrapv1.InPlaceUpgradeReleaseAnnotation]; ok && inplace.IsRollbackEnabled(scope.Machine) {
+		if _, ok := mAnnotations[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation]; !ok {
+			mAnnotations[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation] = release
+		}
+	}
+
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeStatusAnnotation)
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeChangeIDAnnotation)
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeReleaseAnnotation)
+	delete(mAnnotations, bootstrapv1.InPlaceUpgradeRolledBackAtAnnotation)
 	scope.Machine.SetAnnotations(mAnnotations)
 	if err := scope.PatchHelper.Patch(ctx, scope.Machine); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to patch machine annotations: %w", err)
 	}
 
+	// Record the version of the node, to verify it if the upgrade is rolled back
+	if nodeRef := scope.Machine.Status.NodeRef; nodeRef != nil && inplace.IsRollbackEnabled(scope.Machine) {
+		version, err := scope.WorkloadCluster.GetNodeVersion(ctx, nodeRef.Name)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get node version: %w", err)
+		}
+		mAnnotations[bootstrapv1.InPlaceUpgradePreviousVersionAnnotation] = version
+		if _, ok := mAnnotations[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation]; !ok {
+			mAnnotations[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation] = "version=" + version
+		}
+		scope.Machine.SetAnnotations(mAnnotations)
+	}
+
+	// Drain the node before the upgrade, if enabled
+	if inplace.IsDrainEnabled(scope.Machine) {
+		drained, err := r.drainNode(ctx, scope)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to drain node: %w", err)
+		}
+		if !drained {
+			// The upgrade lock is renewed for as long as the node is draining
+			if err := r.upgradeLock.Renew(ctx, scope.Cluster, scope.Machine); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+			}
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
 	// Perform the in-place upgrade through snap refresh
-	changeID, err := scope.WorkloadCluster.RefreshMachine(ctx, scope.Machine, nodeToken, scope.UpgradeOption)
+	if release != scope.UpgradeOption {
+		scope.Log.Info("Resolved in-place upgrade release", "upgradeTo", scope.UpgradeOption, "release", release)
+	}
+	changeID, err := scope.WorkloadCluster.RefreshMachine(ctx, scope.Machine, nodeToken, release)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to refresh machine: %w", err)
 	}
@@ -231,9 +313,88 @@ func (r *InPlaceUpgradeReconciler) handleUpgradeRequest(ctx context.Context, sco
 		return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
 	}
 
+	// Renew the upgrade lock, in case it is held by the machine
+	if err := r.upgradeLock.Renew(ctx, scope.Cluster, scope.Machine); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// drainNode cordons the node of the machine and evicts its pods. It returns true once the node is drained, or once
+// the NodeDrainTimeout of the machine is exceeded.
+func (r *InPlaceUpgradeReconciler) drainNode(ctx context.Context, scope *UpgradeScope) (bool, error) {
+	if scope.Machine.Status.NodeRef == nil {
+		scope.Log.Info("Machine has no node, skipping drain")
+		return true, nil
+	}
+	nodeName := scope.Machine.Status.NodeRef.Name
+
+	if err := scope.WorkloadCluster.CordonNode(ctx, nodeName); err != nil {
+		return false, err
+	}
+
+	mAnnotations := scope.Machine.GetAnnotations()
+	startedAt, err := time.Parse(time.RFC3339, mAnnotations[bootstrapv1.InPlaceUpgradeDrainStartedAtAnnotation])
+	if err != nil {
+		startedAt = time.Now()
+		mAnnotations[bootstrapv1.InPlaceUpgradeDrainStartedAtAnnotation] = startedAt.Format(time.RFC3339)
+		scope.Machine.SetAnnotations(mAnnotations)
+		if err := scope.PatchHelper.Patch(ctx, scope.Machine); err != nil {
+			return false, fmt.Errorf("failed to patch machine annotations: %w", err)
+		}
+
+		r.recorder.Eventf(scope.Machine, corev1.EventTypeNormal, bootstrapv1.InPlaceUpgradeDrainingEvent, "Draining node %q before in place upgrade", nodeName)
+	}
+
+	result, err := scope.WorkloadCluster.DrainNode(ctx, nodeName)
+	if err != nil {
+		return false, err
+	}
+	if result.PodsRemaining == 0 {
+		scope.Log.Info("Node drained", "node", nodeName)
+		return true, nil
+	}
+
+	// NOTE: Same as Cluster API, the upgrade proceeds once the NodeDrainTimeout is exceeded. There is no timeout if unset.
+	if timeout := scope.Machine.Spec.NodeDrainTimeout; timeout != nil && timeout.Duration > 0 && time.Since(startedAt) > timeout.Duration {
+		r.recorder.Eventf(scope.Machine, corev1.EventTypeWarning, bootstrapv1.InPlaceUpgradeDrainTimeoutEvent, "Node %q was not drained within %s, %d pods remaining", nodeName, timeout.Duration, result.PodsRemaining)
+		return true, nil
+	}
+
+	scope.Log.Info("Waiting for node to be drained", "node", nodeName, "podsRemaining", result.PodsRemaining, "podsBlockedByPDB", result.PodsBlocked)
+	return false, nil
+}
+
+// uncordonNode uncordons the node that was drained before the upgrade, once it is Ready.
+// It returns true if there is nothing left to uncordon.
+func (r *InPlaceUpgradeReconciler) uncordonNode(ctx context.Context, scope *UpgradeScope) (bool, error) {
+	mAnnotations := scope.Machine.GetAnnotations()
+	if _, ok := mAnnotations[bootstrapv1.InPlaceUpgradeDrainStartedAtAnnotation]; !ok {
+		return true, nil
+	}
+
+	if nodeRef := scope.Machine.Status.NodeRef; nodeRef != nil {
+		ready, err := scope.WorkloadCluster.IsNodeReady(ctx, nodeRef.Name)
+		if err != nil {
+			return false, err
+		}
+		if !ready {
+			return false, nil
+		}
+
+		if err := scope.WorkloadCluster.UncordonNode(ctx, nodeRef.Name); err != nil {
+			return false, err
+		}
+		r.recorder.Eventf(scope.Machine, corev1.EventTypeNormal, bootstrapv1.InPlaceUpgradeUncordonedEvent, "Uncordoned node %q after in place upgrade", nodeRef.Name)
+	}
+
+	// NOTE: The annotation is removed when the upgrade status is patched.
+	delete(mAnnotations, bootstrapv1.InPlaceUpgradeDrainStartedAtAnnotation)
+	scope.Machine.SetAnnotations(mAnnotations)
+	return true, nil
+}
+
 func (r *InPlaceUpgradeReconciler) handleUpgradeInProgress(ctx context.Context, scope *UpgradeScope, changeID string) (reconcile.Result, error) {
 	// Lookup the node token for the machine
 	nodeToken, err := token.LookupNodeToken(ctx, r.Client, util.ObjectKey(scope.Cluster), scope.Machine.Name)
@@ -248,38 +409,228 @@ func (r *InPlaceUpgradeReconciler) handleUpgradeInProgress(ctx context.Context,
 	}
 
 	if !status.Completed {
+		// The upgrade lock is renewed for as long as the machine is upgrading
+		if err := r.upgradeLock.Renew(ctx, scope.Cluster, scope.Machine); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+		}
+
 		scope.Log.Info("In-place upgrade still in progress, requeuing...")
 		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 	}
 
-	switch status.Status {
-	case "Done":
-		scope.Log.Info("In-place upgrade completed successfully")
-		if err := r.markUpgradeDone(ctx, scope); err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
+	if status.Status == "Error" {
+		// Roll back the failed upgrade, if enabled. The node stays cordoned until the rollback completes.
+		if inplace.IsRollbackEnabled(scope.Machine) {
+			scope.Log.Info("In-place upgrade failed, rolling back", "error", status.ErrorMessage)
+			return r.startRollback(ctx, scope, nodeToken, status.ErrorMessage)
 		}
-	case "Error":
+
+		// The node stays cordoned until the upgrade is retried successfully
 		scope.Log.Info("In-place upgrade failed", "error", status.ErrorMessage)
 		if err := r.markUpgradeFailed(ctx, scope, status.ErrorMessage); err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
 		}
-	default:
+		return ctrl.Result{}, nil
+	}
+
+	if status.Status != "Done" {
 		scope.Log.Info("Found invalid refresh status, marking as failed")
 		if err := r.markUpgradeFailed(ctx, scope, "invalid refresh status"); err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
 		}
+		return ctrl.Result{}, nil
+	}
+
+	// Uncordon the node drained before the upgrade, once it is Ready again
+	uncordoned, err := r.uncordonNode(ctx, scope)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to uncordon node: %w", err)
+	}
+	if !uncordoned {
+		// The upgrade lock is renewed for as long as the node is waiting to be uncordoned
+		if err := r.upgradeLock.Renew(ctx, scope.Cluster, scope.Machine); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+		}
+
+		scope.Log.Info("Waiting for node to be Ready before uncordoning, requeuing...")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	scope.Log.Info("In-place upgrade completed successfully")
+	if err := r.markUpgradeDone(ctx, scope); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// startRollback refreshes the k8s snap of the machine back to its previous release after a failed upgrade.
+// If the rollback cannot be started, the upgrade is marked as failed instead.
+func (r *InPlaceUpgradeReconciler) startRollback(ctx context.Context, scope *UpgradeScope, nodeToken string, failure string) (reconcile.Result, error) {
+	changeID, err := r.refreshToPreviousRelease(ctx, scope, nodeToken)
+	if err != nil {
+		scope.Log.Error(err, "Failed to roll back in-place upgrade")
+		if err := r.markUpgradeFailed(ctx, scope, fmt.Sprintf("%s (rollback failed: %v)", failure, err)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	mAnnotations := scope.Machine.GetAnnotations()
+	mAnnotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] = bootstrapv1.InPlaceUpgradeRollingBackStatus
+	mAnnotations[bootstrapv1.InPlaceUpgradeChangeIDAnnotation] = changeID
+	mAnnotations[bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation] = time.Now().Format(time.RFC1123Z)
+	scope.Machine.SetAnnotations(mAnnotations)
+	if err := scope.PatchHelper.Patch(ctx, scope.Machine); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to patch machine annotations: %w", err)
+	}
+
+	r.recorder.Eventf(scope.Machine, corev1.EventTypeWarning, bootstrapv1.InPlaceUpgradeRollingBackEvent, "Failed to perform in place upgrade with %s: %s. Rolling back to %s", scope.UpgradeOption, failure, scope.Machine.GetAnnotations()[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation])
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// refreshToPreviousRelease refreshes the k8s snap of the machine to the release recorded before the upgrade,
+// and returns the ID of the change.
+func (r *InPlaceUpgradeReconciler) refreshToPreviousRelease(ctx context.Context, scope *UpgradeScope, nodeToken string) (string, error) {
+	previous, ok := scope.Machine.GetAnnotations()[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation]
+	if !ok {
+		return "", fmt.Errorf("the release of the machine before the upgrade is unknown")
+	}
+
+	release, err := inplace.ResolveRelease(ctx, r.Client, scope.Machine, previous)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve previous release %q: %w", previous, err)
+	}
+
+	return scope.WorkloadCluster.RefreshMachine(ctx, scope.Machine, nodeToken, release)
+}
+
+func (r *InPlaceUpgradeReconciler) handleRollbackInProgress(ctx context.Context, scope *UpgradeScope, changeID string) (reconcile.Result, error) {
+	// Lookup the node token for the machine
+	nodeToken, err := token.LookupNodeToken(ctx, r.Client, util.ObjectKey(scope.Cluster), scope.Machine.Name)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to lookup node token: %w", err)
+	}
+
+	status, err := scope.WorkloadCluster.GetRefreshStatusForMachine(ctx, scope.Machine, nodeToken, changeID)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get rollback status for machine: %w", err)
+	}
+
+	// The upgrade lock is renewed for as long as the machine is rolling back
+	if err := r.upgradeLock.Renew(ctx, scope.Cluster, scope.Machine); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+	}
+
+	if !status.Completed {
+		scope.Log.Info("In-place upgrade rollback still in progress, requeuing...")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	if status.Status != "Done" {
+		scope.Log.Info("In-place upgrade rollback failed", "error", status.ErrorMessage)
+		if err := r.markUpgradeFailed(ctx, scope, fmt.Sprintf("rollback failed: %s", status.ErrorMessage)); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Verify that the node is Ready on its previous version
+	verified, err := r.verifyRollback(ctx, scope)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to verify rollback: %w", err)
+	}
+	if !verified {
+		failedAt, err := time.Parse(time.RFC1123Z, scope.Machine.GetAnnotations()[bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation])
+		if err == nil && time.Since(failedAt) > rollbackVerifyTimeout {
+			if err := r.markUpgradeFailed(ctx, scope, "rollback failed: node did not become Ready on its previous version"); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
+			}
+			return ctrl.Result{}, nil
+		}
+
+		scope.Log.Info("Waiting for node to be Ready on its previous version, requeuing...")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	if _, err := r.uncordonNode(ctx, scope); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to uncordon node: %w", err)
+	}
+
+	if err := r.markRolledBack(ctx, scope); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to mark in place upgrade status: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// verifyRollback checks if the node of the machine is Ready on the minor version it had before the upgrade.
+// NOTE: Only the minor version is compared, since k8sd does not report the revision of the k8s snap, so the machine
+// is refreshed back to a release rather than to the exact revision it had, which may be a newer patch version.
+func (r *InPlaceUpgradeReconciler) verifyRollback(ctx context.Context, scope *UpgradeScope) (bool, error) {
+	nodeRef := scope.Machine.Status.NodeRef
+	if nodeRef == nil {
+		return true, nil
+	}
+
+	ready, err := scope.WorkloadCluster.IsNodeReady(ctx, nodeRef.Name)
+	if err != nil || !ready {
+		return false, err
+	}
+
+	previousVersion := scope.Machine.GetAnnotations()[bootstrapv1.InPlaceUpgradePreviousVersionAnnotation]
+	if previousVersion == "" {
+		return true, nil
+	}
+	version, err := scope.WorkloadCluster.GetNodeVersion(ctx, nodeRef.Name)
+	if err != nil {
+		return false, err
+	}
+	return inplace.IsSameMinorVersion(version, previousVersion), nil
+}
+
+func (r *InPlaceUpgradeReconciler) markRolledBack(ctx context.Context, scope *UpgradeScope) error {
+	mAnnotations := scope.Machine.GetAnnotations()
+
+	mAnnotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] = bootstrapv1.InPlaceUpgradeRolledBackStatus
+	mAnnotations[bootstrapv1.InPlaceUpgradeRolledBackAtAnnotation] = time.Now().Format(time.RFC1123Z)
+	scope.Machine.SetAnnotations(mAnnotations)
+	if err := scope.PatchHelper.Patch(ctx, scope.Machine); err != nil {
+		return fmt.Errorf("failed to patch machine annotations: %w", err)
+	}
+
+	r.recorder.Eventf(scope.Machine, corev1.EventTypeWarning, bootstrapv1.InPlaceUpgradeRolledBackEvent, "Rolled back in place upgrade with %s to %s", scope.UpgradeOption, mAnnotations[bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation])
+	return nil
+}
+
 func (r *InPlaceUpgradeReconciler) handleUpgradeDone(ctx context.Context, scope *UpgradeScope) (reconcile.Result, error) {
+	// Record the Kubernetes version the machine was upgraded to, so that it matches the view of Cluster API.
+	// NOTE: The version is the one reported by the kubelet of the node after the refresh, since the release the
+	// requested version is resolved to may install another patch version.
+	if nodeRef := scope.Machine.Status.NodeRef; nodeRef != nil && inplace.GetUpgradeVersion(scope.UpgradeOption) != "" {
+		version, err := scope.WorkloadCluster.GetNodeVersion(ctx, nodeRef.Name)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get node version: %w", err)
+		}
+		if err := r.updateConfigVersion(ctx, scope.Machine, version); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update bootstrap config version: %w", err)
+		}
+		scope.Machine.Spec.Version = &version
+	}
+
+	// Record the release the k8s snap was refreshed to, which differs from the upgrade option for "version=" releases
+	release, err := inplace.ResolveRelease(ctx, r.Client, scope.Machine, scope.UpgradeOption)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to resolve in-place upgrade release: %w", err)
+	}
+
 	mAnnotations := scope.Machine.GetAnnotations()
 
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeToAnnotation)
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeChangeIDAnnotation)
 	delete(mAnnotations, bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation)
+	delete(mAnnotations, bootstrapv1.InPlaceUpgradePreviousReleaseAnnotation)
 	mAnnotations[bootstrapv1.InPlaceUpgradeReleaseAnnotation] = scope.UpgradeOption
+	mAnnotations[bootstrapv1.InPlaceUpgradeRefreshedToAnnotation] = release
 	scope.Machine.SetAnnotations(mAnnotations)
 	if err := scope.PatchHelper.Patch(ctx, scope.Machine); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to patch machine annotations: %w", err)
@@ -288,6 +639,32 @@ func (r *InPlaceUpgradeReconciler) handleUpgradeDone(ctx context.Context, scope
 	return ctrl.Result{}, nil
 }
 
+// updateConfigVersion sets the Kubernetes version of the CK8sConfig of the machine, if any.
+func (r *InPlaceUpgradeReconciler) updateConfigVersion(ctx context.Context, m *clusterv1.Machine, version string) error {
+	configRef := m.Spec.Bootstrap.ConfigRef
+	if configRef == nil || configRef.Kind != "CK8sConfig" {
+		return nil
+	}
+
+	config := &bootstrapv1.CK8sConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: configRef.Name}, config); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if config.Spec.Version == version {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(config, r.Client)
+	if err != nil {
+		return fmt.Errorf("failed to create patch helper for config: %w", err)
+	}
+	config.Spec.Version = version
+	return patchHelper.Patch(ctx, config)
+}
+
 func (r *InPlaceUpgradeReconciler) handleUpgradeFailed(ctx context.Context, scope *UpgradeScope) (reconcile.Result, error) {
 	mAnnotations := scope.Machine.GetAnnotations()
 
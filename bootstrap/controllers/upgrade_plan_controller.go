@@ -0,0 +1,640 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/controllers/external"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+// UpgradePlanReconciler reconciles a CK8sUpgradePlan object and upgrades the selected machines in-place.
+// The machines are upgraded through the in-place upgrade annotations, which are an implementation detail
+// of the plan.
+type UpgradePlanReconciler struct {
+	recorder      record.EventRecorder
+	machineGetter inplace.MachineGetter
+
+	client.Client
+	Log logr.Logger
+
+	K8sdDialTimeout time.Duration
+
+	managementCluster ck8s.ManagementCluster
+}
+
+// upgradePlanScope is a struct that holds the context of the upgrade process.
+type upgradePlanScope struct {
+	plan        *bootstrapv1.CK8sUpgradePlan
+	cluster     *clusterv1.Cluster
+	release     string
+	machines    []*clusterv1.Machine
+	healthGates *inplace.HealthGates
+
+	// policy carries the upgrade policy of the plan as annotations, so that it is propagated to the machines.
+	policy client.Object
+
+	// windows are the maintenance windows of the control plane and MachineDeployments owning the machines,
+	// by orchestratorKey.
+	windows map[string]*maintenance.Windows
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UpgradePlanReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.recorder = mgr.GetEventRecorderFor("ck8s-upgrade-plan-controller")
+
+	management := &ck8s.Management{
+		Client:          r.Client,
+		K8sdDialTimeout: r.K8sdDialTimeout,
+	}
+	if r.machineGetter == nil {
+		r.machineGetter = management
+	}
+	if r.managementCluster == nil {
+		r.managementCluster = management
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&bootstrapv1.CK8sUpgradePlan{}).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterToUpgradePlans),
+			builder.WithPredicates(predicates.ClusterPausedTransitions(mgr.GetScheme(), r.Log)),
+		).
+		Watches(
+			&clusterv1.Machine{},
+			handler.EnqueueRequestsFromMapFunc(r.machineToUpgradePlans),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8supgradeplans;ck8supgradeplans/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=bootstrap.cluster.x-k8s.io,resources=ck8sconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8scontrolplanes,verbs=get;list;watch
+
+// Reconcile handles the reconciliation of a CK8sUpgradePlan object.
+func (r *UpgradePlanReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("upgrade_plan", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	plan := &bootstrapv1.CK8sUpgradePlan{}
+	if err := r.Get(ctx, req.NamespacedName, plan); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("CK8sUpgradePlan resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get CK8sUpgradePlan: %w", err)
+	}
+
+	if isDeleted(plan) {
+		log.V(1).Info("CK8sUpgradePlan is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: plan.Namespace, Name: plan.Spec.ClusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Info("Cluster not found, requeuing...", "cluster", plan.Spec.ClusterName)
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if isPaused, err := paused.EnsurePausedCondition(ctx, r.Client, cluster, plan); err != nil || isPaused {
+		if isPaused {
+			log.V(1).Info("Reconciliation is paused for this object")
+		}
+		return ctrl.Result{}, err
+	}
+
+	patchHelper, err := patch.NewHelper(plan, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+	defer func() {
+		plan.Status.ObservedGeneration = plan.Generation
+		if err := patchHelper.Patch(ctx, plan, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.UpgradePlanReadyCondition}}); err != nil {
+			log.Error(err, "Failed to patch CK8sUpgradePlan")
+			if rerr == nil {
+				rerr = err
+			}
+		}
+	}()
+
+	release, err := plan.Spec.Target.GetRelease()
+	if err != nil {
+		plan.Status.Phase = bootstrapv1.UpgradePlanFailedPhase
+		conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.UpgradePlanInvalidTargetReason, clusterv1.ConditionSeverityError, "Invalid target: %v", err)
+		return ctrl.Result{}, nil
+	}
+	if plan.Status.Release != release {
+		// A new target restarts the plan.
+		plan.Status.StartedAt = nil
+		plan.Status.CompletedAt = nil
+		plan.Status.Release = release
+	}
+
+	healthGates, err := inplace.HealthGatesFromSpec(plan.Spec.HealthGates)
+	if err != nil {
+		plan.Status.Phase = bootstrapv1.UpgradePlanFailedPhase
+		conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.UpgradePlanInvalidTargetReason, clusterv1.ConditionSeverityError, "Invalid health gates: %v", err)
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, plan, cluster, release, healthGates)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
+	}
+
+	conflict, err := r.reconcileOrchestrators(ctx, scope)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile in-place upgrade annotations: %w", err)
+	}
+	if conflict != "" {
+		log.Info("Machines are upgraded by another in-place upgrade, requeuing...", "conflict", conflict)
+		conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.UpgradePlanConflictReason, clusterv1.ConditionSeverityWarning, "%s", conflict)
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	var (
+		statuses = make([]bootstrapv1.MachineUpgradeStatus, 0, len(scope.machines))
+		failed   *bootstrapv1.MachineUpgradeStatus
+
+		upgradedMachines            int
+		upgradingControlPlane       bool
+		upgradingWorkers            int
+		pendingControlPlaneMachines []*clusterv1.Machine
+		pendingWorkerMachines       []*clusterv1.Machine
+	)
+	for _, m := range scope.machines {
+		status, err := r.getMachineUpgradeStatus(ctx, scope, m)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		statuses = append(statuses, status)
+
+		switch status.Phase {
+		case bootstrapv1.MachineUpgradeSucceededPhase:
+			upgradedMachines++
+			continue
+		case bootstrapv1.MachineUpgradeFailedPhase, bootstrapv1.MachineUpgradeRolledBackPhase:
+			if failed == nil {
+				failed = &status
+			}
+		}
+
+		isControlPlane := util.IsControlPlaneMachine(m)
+		switch {
+		case status.Phase != bootstrapv1.MachineUpgradePendingPhase && isControlPlane:
+			upgradingControlPlane = true
+		case status.Phase != bootstrapv1.MachineUpgradePendingPhase:
+			upgradingWorkers++
+		case isControlPlane:
+			pendingControlPlaneMachines = append(pendingControlPlaneMachines, m)
+		default:
+			pendingWorkerMachines = append(pendingWorkerMachines, m)
+		}
+	}
+
+	// No new upgrades are started as soon as a machine fails, or does not pass the health gates.
+	if failed != nil {
+		setMachineUpgradeStatuses(plan, statuses)
+		plan.Status.Phase = bootstrapv1.UpgradePlanFailedPhase
+		if conditions.GetReason(plan, bootstrapv1.UpgradePlanReadyCondition) != bootstrapv1.UpgradePlanFailedReason {
+			r.recorder.Eventf(plan, corev1.EventTypeWarning, bootstrapv1.InPlaceUpgradeFailedEvent, "In-place upgrade failed for machine %q: %s", failed.Name, failed.LastError)
+		}
+		conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.UpgradePlanFailedReason, clusterv1.ConditionSeverityError, "In-place upgrade failed for machine %q: %s", failed.Name, failed.LastError)
+
+		log.Info("Machine upgrade failed, requeuing...", "machine", failed.Name)
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	// The control plane machines are upgraded first, one at a time. New upgrades only start within the
+	// maintenance windows of the control plane or MachineDeployment owning the machines.
+	var (
+		toUpgrade     []*clusterv1.Machine
+		nextOpen      time.Time
+		waitingWindow bool
+	)
+	switch {
+	case len(pendingControlPlaneMachines) > 0:
+		if !upgradingControlPlane {
+			toUpgrade, nextOpen, waitingWindow = filterMaintenanceWindows(scope, pendingControlPlaneMachines, time.Now())
+			toUpgrade = toUpgrade[:min(1, len(toUpgrade))]
+		}
+	case !upgradingControlPlane:
+		if slots := scope.plan.Spec.GetMaxConcurrency() - upgradingWorkers; slots > 0 {
+			toUpgrade, nextOpen, waitingWindow = filterMaintenanceWindows(scope, pendingWorkerMachines, time.Now())
+			toUpgrade = toUpgrade[:min(slots, len(toUpgrade))]
+		}
+	}
+
+	for _, m := range toUpgrade {
+		if err := r.markMachineToUpgrade(ctx, scope, m); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark machine to upgrade: %w", err)
+		}
+		for i := range statuses {
+			if statuses[i].Name == m.Name {
+				statuses[i].Phase = bootstrapv1.MachineUpgradeUpgradingPhase
+			}
+		}
+		log.V(1).Info("Machine marked for upgrade", "machine", m.Name)
+	}
+
+	setMachineUpgradeStatuses(plan, statuses)
+	plan.Status.UpgradedMachines = int32(upgradedMachines)
+
+	if upgradedMachines == len(scope.machines) {
+		if plan.Status.Phase != bootstrapv1.UpgradePlanCompletedPhase {
+			plan.Status.CompletedAt = ptrNow()
+			r.recorder.Eventf(plan, corev1.EventTypeNormal, bootstrapv1.InPlaceUpgradeDoneEvent, "In-place upgrade to %q is done", scope.release)
+		}
+		plan.Status.Phase = bootstrapv1.UpgradePlanCompletedPhase
+		conditions.MarkTrue(plan, bootstrapv1.UpgradePlanReadyCondition)
+
+		// NOTE: Machines that are selected afterwards are upgraded when they are created or updated.
+		log.V(1).Info("All machines are upgraded")
+		return ctrl.Result{}, nil
+	}
+
+	if plan.Status.StartedAt == nil {
+		plan.Status.StartedAt = ptrNow()
+	}
+	plan.Status.CompletedAt = nil
+	plan.Status.Phase = bootstrapv1.UpgradePlanInProgressPhase
+
+	if waitingWindow && len(toUpgrade) == 0 && !upgradingControlPlane && upgradingWorkers == 0 {
+		requeueAfter := time.Hour
+		if !nextOpen.IsZero() {
+			requeueAfter = max(time.Until(nextOpen), time.Minute)
+		}
+		log.V(1).Info("Waiting for a maintenance window to upgrade machines", "requeueAfter", requeueAfter)
+		conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.WaitingForMaintenanceWindowReason, clusterv1.ConditionSeverityInfo, "%d of %d machines are upgraded to %q, waiting for a maintenance window to upgrade the next machines", upgradedMachines, len(scope.machines), scope.release)
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	conditions.MarkFalse(plan, bootstrapv1.UpgradePlanReadyCondition, bootstrapv1.UpgradePlanInProgressReason, clusterv1.ConditionSeverityInfo, "%d of %d machines are upgraded to %q", upgradedMachines, len(scope.machines), scope.release)
+
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// getMachineUpgradeStatus returns the progress of the upgrade of the machine, checking its health gates
+// once it is upgraded.
+func (r *UpgradePlanReconciler) getMachineUpgradeStatus(ctx context.Context, scope *upgradePlanScope, m *clusterv1.Machine) (bootstrapv1.MachineUpgradeStatus, error) {
+	status := bootstrapv1.MachineUpgradeStatus{Name: m.Name}
+
+	switch {
+	case inplace.IsUpgraded(m, scope.release) && inplace.GetUpgradeInstructions(m) == scope.release:
+		status.Phase = bootstrapv1.MachineUpgradeSucceededPhase
+		if scope.healthGates == nil || !inplace.NeedsHealthCheck(m, scope.release) {
+			return status, nil
+		}
+
+		result, err := r.checkHealthGates(ctx, scope, m)
+		if err != nil {
+			return status, err
+		}
+		switch {
+		case result.Failed:
+			status.Phase = bootstrapv1.MachineUpgradeFailedPhase
+			status.LastError = fmt.Sprintf("did not pass the health gates: %s", result.Message)
+		case !result.Healthy:
+			status.Phase = bootstrapv1.MachineUpgradeHealthCheckingPhase
+			status.LastError = result.Message
+		}
+	case inplace.IsMachineRolledBack(m):
+		status.Phase = bootstrapv1.MachineUpgradeRolledBackPhase
+		status.LastError = fmt.Sprintf("rolled back at %s", m.Annotations[bootstrapv1.InPlaceUpgradeRolledBackAtAnnotation])
+	case inplace.IsMachineUpgradeFailed(m):
+		status.Phase = bootstrapv1.MachineUpgradeFailedPhase
+		status.LastError = fmt.Sprintf("last attempt failed at %s", m.Annotations[bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation])
+	case inplace.IsMachineUpgrading(m):
+		status.Phase = bootstrapv1.MachineUpgradeUpgradingPhase
+	default:
+		status.Phase = bootstrapv1.MachineUpgradePendingPhase
+	}
+
+	return status, nil
+}
+
+// checkHealthGates checks the health gates of the upgraded machine.
+func (r *UpgradePlanReconciler) checkHealthGates(ctx context.Context, scope *upgradePlanScope, m *clusterv1.Machine) (*inplace.HealthCheckResult, error) {
+	// Lookup the ck8s config used by the machine
+	config := &bootstrapv1.CK8sConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: m.Namespace, Name: m.Spec.Bootstrap.ConfigRef.Name}, config); err != nil {
+		return nil, fmt.Errorf("failed to get CK8sConfig of machine %q: %w", m.Name, err)
+	}
+
+	workload, err := r.managementCluster.GetWorkloadCluster(ctx, util.ObjectKey(scope.cluster), config.Spec.ControlPlaneConfig.GetMicroclusterPort())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workload cluster: %w", err)
+	}
+
+	result, err := inplace.CheckMachineHealth(ctx, m, scope.release, scope.healthGates, workload, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check health of machine %q: %w", m.Name, err)
+	}
+	return result, nil
+}
+
+// markMachineToUpgrade marks the machine to upgrade, with the upgrade policy of the plan.
+func (r *UpgradePlanReconciler) markMachineToUpgrade(ctx context.Context, scope *upgradePlanScope, m *clusterv1.Machine) error {
+	if err := inplace.MarkMachineToUpgrade(ctx, m, scope.release, scope.policy, r.Client); err != nil {
+		return fmt.Errorf("failed to mark machine to upgrade: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.plan,
+		corev1.EventTypeNormal,
+		bootstrapv1.InPlaceUpgradeInProgressEvent,
+		"Machine %q is upgrading to %q",
+		m.Name,
+		scope.release,
+	)
+	return nil
+}
+
+// reconcileOrchestrators checks the in-place upgrade annotations of the control plane and MachineDeployments
+// owning the selected machines, and returns a message describing the conflict if one of them is being upgraded
+// in-place. The annotations are left as-is.
+// It also collects the maintenance windows of the control plane and MachineDeployments into the scope.
+func (r *UpgradePlanReconciler) reconcileOrchestrators(ctx context.Context, scope *upgradePlanScope) (string, error) {
+	var (
+		orchestrators []client.Object
+		seen          = map[string]bool{}
+	)
+	for _, m := range scope.machines {
+		var (
+			kind = orchestratorKey(scope.cluster, m)
+			obj  client.Object
+		)
+		switch {
+		case util.IsControlPlaneMachine(m) && scope.cluster.Spec.ControlPlaneRef != nil:
+			if !seen[kind] {
+				controlPlane, err := external.Get(ctx, r.Client, scope.cluster.Spec.ControlPlaneRef)
+				if err != nil {
+					return "", fmt.Errorf("failed to get control plane: %w", err)
+				}
+				obj = controlPlane
+			}
+		case m.Labels[clusterv1.MachineDeploymentNameLabel] != "":
+			if !seen[kind] {
+				md := &clusterv1.MachineDeployment{}
+				if err := r.Get(ctx, client.ObjectKey{Namespace: m.Namespace, Name: m.Labels[clusterv1.MachineDeploymentNameLabel]}, md); err != nil {
+					if apierrors.IsNotFound(err) {
+						continue
+					}
+					return "", fmt.Errorf("failed to get MachineDeployment: %w", err)
+				}
+				obj = md
+			}
+		}
+		if obj == nil {
+			continue
+		}
+		seen[kind] = true
+		orchestrators = append(orchestrators, obj)
+
+		windows, err := getMaintenanceWindows(obj)
+		if err != nil {
+			return "", fmt.Errorf("failed to get maintenance windows of %q: %w", obj.GetName(), err)
+		}
+		scope.windows[kind] = windows
+	}
+
+	// NOTE: The control plane and MachineDeployments leave the machines selected by the plan to the plan, so
+	// the release they were last upgraded to does not conflict. An in-place upgrade requested on one of them is
+	// only waited for, so that the machines it upgrades are not upgraded again by the plan right away.
+	var conflicts []string
+	for _, obj := range orchestrators {
+		if _, requested := obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeToAnnotation]; !requested {
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%s %q", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()))
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Sprintf(
+			"In-place upgrade is requested by the %q annotation on %s; the plan proceeds once the upgrade is done or the annotation is removed",
+			bootstrapv1.InPlaceUpgradeToAnnotation,
+			strings.Join(conflicts, ", "),
+		), nil
+	}
+	return "", nil
+}
+
+// createScope creates a new upgradePlanScope.
+func (r *UpgradePlanReconciler) createScope(ctx context.Context, plan *bootstrapv1.CK8sUpgradePlan, cluster *clusterv1.Cluster, release string, healthGates *inplace.HealthGates) (*upgradePlanScope, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&plan.Spec.MachineSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse machine selector: %w", err)
+	}
+
+	machinesCollection, err := r.machineGetter.GetMachinesForCluster(ctx, util.ObjectKey(cluster), func(m *clusterv1.Machine) bool {
+		return selector.Matches(labels.Set(m.Labels)) && !isDeleted(m)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster machines: %w", err)
+	}
+
+	machines := machinesCollection.UnsortedList()
+	sort.Slice(machines, func(i, j int) bool {
+		if cpi, cpj := util.IsControlPlaneMachine(machines[i]), util.IsControlPlaneMachine(machines[j]); cpi != cpj {
+			return cpi
+		}
+		return machines[i].Name < machines[j].Name
+	})
+
+	policy := &metav1.PartialObjectMetadata{}
+	policy.Annotations = map[string]string{}
+	if plan.Spec.Drain {
+		policy.Annotations[bootstrapv1.InPlaceUpgradeDrainAnnotation] = "true"
+	}
+	if plan.Spec.Rollback {
+		policy.Annotations[bootstrapv1.InPlaceUpgradeRollbackAnnotation] = "true"
+	}
+	if plan.Spec.Risk != "" {
+		policy.Annotations[bootstrapv1.InPlaceUpgradeRiskAnnotation] = plan.Spec.Risk
+	}
+	if plan.Spec.VersionMap != "" {
+		policy.Annotations[bootstrapv1.InPlaceUpgradeVersionMapAnnotation] = plan.Spec.VersionMap
+	}
+
+	return &upgradePlanScope{
+		plan:        plan,
+		cluster:     cluster,
+		release:     release,
+		machines:    machines,
+		healthGates: healthGates,
+		policy:      policy,
+		windows:     map[string]*maintenance.Windows{},
+	}, nil
+}
+
+// filterMaintenanceWindows returns the machines that may start upgrading now, according to the maintenance
+// windows of the control plane or MachineDeployment owning them, and when the next window of the others opens.
+func filterMaintenanceWindows(scope *upgradePlanScope, machines []*clusterv1.Machine, now time.Time) ([]*clusterv1.Machine, time.Time, bool) {
+	var (
+		allowed  []*clusterv1.Machine
+		nextOpen time.Time
+		waiting  bool
+	)
+	for _, m := range machines {
+		windows := scope.windows[orchestratorKey(scope.cluster, m)]
+		if windows.IsOpen(now) {
+			allowed = append(allowed, m)
+			continue
+		}
+
+		waiting = true
+		if next := windows.NextOpen(now); !next.IsZero() && (nextOpen.IsZero() || next.Before(nextOpen)) {
+			nextOpen = next
+		}
+	}
+	return allowed, nextOpen, waiting
+}
+
+// orchestratorKey identifies the control plane or MachineDeployment owning the machine.
+func orchestratorKey(cluster *clusterv1.Cluster, m *clusterv1.Machine) string {
+	if util.IsControlPlaneMachine(m) && cluster.Spec.ControlPlaneRef != nil {
+		return cluster.Spec.ControlPlaneRef.Kind
+	}
+	return "MachineDeployment/" + m.Labels[clusterv1.MachineDeploymentNameLabel]
+}
+
+// getMaintenanceWindows returns the maintenance windows of the control plane, from its spec, or of the
+// MachineDeployment, from its annotations.
+func getMaintenanceWindows(obj client.Object) (*maintenance.Windows, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return maintenance.FromAnnotations(obj)
+	}
+
+	value, found, err := unstructured.NestedMap(u.Object, "spec", "maintenanceWindow")
+	if err != nil || !found {
+		return nil, err
+	}
+	policy := &bootstrapv1.MaintenanceWindowPolicy{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(value, policy); err != nil {
+		return nil, fmt.Errorf("failed to convert maintenance window: %w", err)
+	}
+	return maintenance.New(policy)
+}
+
+// machineToUpgradePlans maps a Machine to the CK8sUpgradePlans of its cluster.
+func (r *UpgradePlanReconciler) machineToUpgradePlans(ctx context.Context, o client.Object) []reconcile.Request {
+	m, ok := o.(*clusterv1.Machine)
+	if !ok {
+		return nil
+	}
+	return r.upgradePlansForCluster(ctx, m.Namespace, m.Spec.ClusterName)
+}
+
+// clusterToUpgradePlans maps a Cluster to its CK8sUpgradePlans, so that they resume once the Cluster is unpaused.
+func (r *UpgradePlanReconciler) clusterToUpgradePlans(ctx context.Context, o client.Object) []reconcile.Request {
+	cluster, ok := o.(*clusterv1.Cluster)
+	if !ok {
+		return nil
+	}
+	return r.upgradePlansForCluster(ctx, cluster.Namespace, cluster.Name)
+}
+
+// upgradePlansForCluster returns the requests for the CK8sUpgradePlans referencing a cluster.
+func (r *UpgradePlanReconciler) upgradePlansForCluster(ctx context.Context, namespace string, clusterName string) []reconcile.Request {
+	var plans bootstrapv1.CK8sUpgradePlanList
+	if err := r.List(ctx, &plans, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, "Failed to list CK8sUpgradePlans", "cluster", clusterName)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, plan := range plans.Items {
+		if plan.Spec.ClusterName == clusterName {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&plan)})
+		}
+	}
+	return requests
+}
+
+// setMachineUpgradeStatuses merges the progress of the selected machines into the status of the plan,
+// keeping the history of the machines that are no longer selected.
+func setMachineUpgradeStatuses(plan *bootstrapv1.CK8sUpgradePlan, statuses []bootstrapv1.MachineUpgradeStatus) {
+	previous := make(map[string]bootstrapv1.MachineUpgradeStatus, len(plan.Status.Machines))
+	for _, status := range plan.Status.Machines {
+		previous[status.Name] = status
+	}
+
+	for _, status := range statuses {
+		prev, ok := previous[status.Name]
+		if ok && prev.Phase == status.Phase {
+			status.StartedAt, status.CompletedAt = prev.StartedAt, prev.CompletedAt
+		} else {
+			status.StartedAt = prev.StartedAt
+			switch status.Phase {
+			case bootstrapv1.MachineUpgradePendingPhase:
+				status.StartedAt = nil
+			case bootstrapv1.MachineUpgradeSucceededPhase:
+				status.CompletedAt = ptrNow()
+			}
+			if status.StartedAt == nil && status.Phase != bootstrapv1.MachineUpgradePendingPhase {
+				status.StartedAt = ptrNow()
+			}
+		}
+		if status.LastError == "" && status.Phase != bootstrapv1.MachineUpgradeSucceededPhase {
+			status.LastError = prev.LastError
+		}
+		previous[status.Name] = status
+	}
+
+	machines := make([]bootstrapv1.MachineUpgradeStatus, 0, len(previous))
+	for _, status := range previous {
+		machines = append(machines, status)
+	}
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+	plan.Status.Machines = machines
+}
+
+// ptrNow returns a pointer to the current time.
+func ptrNow() *metav1.Time {
+	now := metav1.Now()
+	return &now
+}
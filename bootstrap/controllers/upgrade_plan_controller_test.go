@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+)
+
+const testPlanRelease = "channel=1.32-classic/stable"
+
+func newPlanTestMachine(name string, controlPlane bool, annotations map[string]string) *clusterv1.Machine {
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{clusterv1.ClusterNameLabel: "cluster"},
+			Annotations: annotations,
+		},
+		Spec: clusterv1.MachineSpec{ClusterName: "cluster"},
+	}
+	if controlPlane {
+		m.Labels[clusterv1.MachineControlPlaneLabel] = ""
+	} else {
+		m.Labels[clusterv1.MachineDeploymentNameLabel] = "md"
+	}
+	return m
+}
+
+func upgradedAnnotations() map[string]string {
+	return map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: testPlanRelease}
+}
+
+func newPlanTestReconciler(g *WithT, objs ...client.Object) (*UpgradePlanReconciler, client.Client) {
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(bootstrapv1.AddToScheme(scheme)).To(Succeed())
+
+	plan := &bootstrapv1.CK8sUpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "plan", Namespace: "default"},
+		Spec: bootstrapv1.CK8sUpgradePlanSpec{
+			ClusterName:    "cluster",
+			Target:         bootstrapv1.UpgradeTarget{Channel: "1.32-classic/stable"},
+			MaxConcurrency: ptr.To[int32](2),
+		},
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(objs, plan, cluster)...).
+		WithStatusSubresource(&bootstrapv1.CK8sUpgradePlan{}).
+		Build()
+
+	return &UpgradePlanReconciler{
+		Client:        c,
+		Log:           logr.Discard(),
+		recorder:      record.NewFakeRecorder(32),
+		machineGetter: &ck8s.Management{Client: c},
+	}, c
+}
+
+func reconcilePlan(g *WithT, r *UpgradePlanReconciler, c client.Client) *bootstrapv1.CK8sUpgradePlan {
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "plan"}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plan := &bootstrapv1.CK8sUpgradePlan{}
+	g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "plan"}, plan)).To(Succeed())
+	return plan
+}
+
+// getMarkedMachines returns the names of the machines marked for upgrade by the plan.
+func getMarkedMachines(g *WithT, c client.Client) []string {
+	machines := &clusterv1.MachineList{}
+	g.Expect(c.List(context.Background(), machines)).To(Succeed())
+
+	var marked []string
+	for _, m := range machines.Items {
+		if m.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] == testPlanRelease {
+			marked = append(marked, m.Name)
+		}
+	}
+	return marked
+}
+
+func TestUpgradePlanReconcile(t *testing.T) {
+	t.Run("ControlPlaneFirst", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := newPlanTestReconciler(g,
+			newPlanTestMachine("cp-0", true, nil),
+			newPlanTestMachine("cp-1", true, nil),
+			newPlanTestMachine("worker-0", false, nil),
+			newPlanTestMachine("worker-1", false, nil),
+		)
+
+		plan := reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(ConsistOf("cp-0"))
+		g.Expect(plan.Status.Phase).To(Equal(bootstrapv1.UpgradePlanInProgressPhase))
+		g.Expect(plan.Status.Release).To(Equal(testPlanRelease))
+
+		// The next control plane machine waits for the first one to be upgraded.
+		reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(ConsistOf("cp-0"))
+	})
+
+	t.Run("MaxConcurrency", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := newPlanTestReconciler(g,
+			newPlanTestMachine("cp-0", true, upgradedAnnotations()),
+			newPlanTestMachine("worker-0", false, nil),
+			newPlanTestMachine("worker-1", false, nil),
+			newPlanTestMachine("worker-2", false, nil),
+		)
+
+		plan := reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(ConsistOf("worker-0", "worker-1"))
+		g.Expect(plan.Status.UpgradedMachines).To(Equal(int32(1)))
+
+		// No more workers are upgraded until one of the upgrading workers is done.
+		reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(ConsistOf("worker-0", "worker-1"))
+	})
+
+	t.Run("StopOnFailure", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := newPlanTestReconciler(g,
+			newPlanTestMachine("cp-0", true, upgradedAnnotations()),
+			newPlanTestMachine("worker-0", false, map[string]string{
+				bootstrapv1.InPlaceUpgradeStatusAnnotation:              bootstrapv1.InPlaceUpgradeFailedStatus,
+				bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation: "Mon, 02 Jan 2006 15:04:05 -0700",
+			}),
+			newPlanTestMachine("worker-1", false, nil),
+		)
+
+		plan := reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(BeEmpty())
+		g.Expect(plan.Status.Phase).To(Equal(bootstrapv1.UpgradePlanFailedPhase))
+		g.Expect(conditions.GetReason(plan, bootstrapv1.UpgradePlanReadyCondition)).To(Equal(bootstrapv1.UpgradePlanFailedReason))
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := newPlanTestReconciler(g,
+			newPlanTestMachine("cp-0", true, upgradedAnnotations()),
+			newPlanTestMachine("worker-0", false, upgradedAnnotations()),
+		)
+
+		plan := reconcilePlan(g, r, c)
+		g.Expect(plan.Status.Phase).To(Equal(bootstrapv1.UpgradePlanCompletedPhase))
+		g.Expect(plan.Status.UpgradedMachines).To(Equal(int32(2)))
+		g.Expect(conditions.IsTrue(plan, bootstrapv1.UpgradePlanReadyCondition)).To(BeTrue())
+	})
+
+	t.Run("Paused", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r, c := newPlanTestReconciler(g,
+			newPlanTestMachine("cp-0", true, nil),
+		)
+		cluster := &clusterv1.Cluster{}
+		g.Expect(c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "cluster"}, cluster)).To(Succeed())
+		cluster.Spec.Paused = true
+		g.Expect(c.Update(context.Background(), cluster)).To(Succeed())
+
+		plan := reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(BeEmpty())
+		g.Expect(conditions.IsTrue(plan, paused.PausedCondition)).To(BeTrue())
+
+		// The plan resumes once the Cluster is unpaused.
+		cluster.Spec.Paused = false
+		g.Expect(c.Update(context.Background(), cluster)).To(Succeed())
+
+		plan = reconcilePlan(g, r, c)
+		g.Expect(getMarkedMachines(g, c)).To(ConsistOf("cp-0"))
+		g.Expect(conditions.Has(plan, paused.PausedCondition)).To(BeFalse())
+	})
+}
+
+func TestUpgradePlanReconcileOrchestrators(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		conflict    bool
+	}{
+		{
+			name: "NoAnnotations",
+		},
+		{
+			name:        "SameRelease",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: testPlanRelease},
+		},
+		{
+			name:        "OtherRelease",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.31-classic/stable"},
+		},
+		{
+			name:        "UpgradeRequested",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeToAnnotation: testPlanRelease},
+			conflict:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			md := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "md", Namespace: "default", Annotations: tc.annotations},
+				Spec:       clusterv1.MachineDeploymentSpec{ClusterName: "cluster"},
+			}
+			r, c := newPlanTestReconciler(g, md, newPlanTestMachine("worker-0", false, nil))
+
+			plan := reconcilePlan(g, r, c)
+			if tc.conflict {
+				g.Expect(conditions.GetReason(plan, bootstrapv1.UpgradePlanReadyCondition)).To(Equal(bootstrapv1.UpgradePlanConflictReason))
+				g.Expect(getMarkedMachines(g, c)).To(BeEmpty())
+			} else {
+				g.Expect(getMarkedMachines(g, c)).To(ConsistOf("worker-0"))
+			}
+
+			// The annotations of the MachineDeployment are left as-is.
+			g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(md), md)).To(Succeed())
+			g.Expect(md.Annotations).To(Equal(tc.annotations))
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -29,6 +30,7 @@ import (
 	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -87,6 +89,12 @@ func main() {
 		Cache: cache.Options{
 			SyncPeriod: &syncPeriod,
 		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// Referenced ConfigMaps, such as audit policies, are read from the API server, so that not every ConfigMap is cached.
+				DisableFor: []client.Object{&corev1.ConfigMap{}},
+			},
+		},
 		Controller: config.Controller{
 			// TODO: avoid duplicate controller names.
 			SkipNameValidation: ptr.To(true),
@@ -127,13 +135,63 @@ func main() {
 	}
 
 	if err = (&controllers.OrchestratedInPlaceUpgradeController{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("OrchestratedInPlaceUpgrade"),
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("OrchestratedInPlaceUpgrade"),
+		K8sdDialTimeout: k8sdDialTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OrchestratedInPlaceUpgrade")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.MachineDeploymentCertificatesReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("MachineDeploymentCertificates"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MachineDeploymentCertificates")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ClusterCertificatesReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ClusterCertificates"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterCertificates")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ClusterInPlaceUpgradeReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ClusterInPlaceUpgrade"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterInPlaceUpgrade")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.UpgradePlanReconciler{
+		Client:          mgr.GetClient(),
+		Log:             ctrl.Log.WithName("controllers").WithName("UpgradePlan"),
+		K8sdDialTimeout: k8sdDialTimeout,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UpgradePlan")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.CertificatesRenewalReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("CertificatesRenewal"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertificatesRenewal")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.MaintenanceWindowReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("MaintenanceWindow"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MaintenanceWindow")
+		os.Exit(1)
+	}
+
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err = (&bootstrapv1.CK8sConfig{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "CK8sConfig")
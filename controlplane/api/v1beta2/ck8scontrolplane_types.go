@@ -88,6 +88,15 @@ type CK8sControlPlaneSpec struct {
 	// +optional
 	// +kubebuilder:default={rollingUpdate: {maxSurge: 1}}
 	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// CertificatesRenewal configures the automatic renewal of the control plane machine certificates.
+	// +optional
+	CertificatesRenewal *bootstrapv1.CertificatesRenewalPolicy `json:"certificatesRenewal,omitempty"`
+
+	// MaintenanceWindow restricts the disruptive operations on the control plane machines, such as
+	// rollouts, scale downs, remediations, in-place upgrades and certificates refreshes, to maintenance windows.
+	// +optional
+	MaintenanceWindow *bootstrapv1.MaintenanceWindowPolicy `json:"maintenanceWindow,omitempty"`
 }
 
 // MachineTemplate contains information about how machines should be shaped
@@ -248,6 +257,74 @@ type CK8sControlPlaneStatus struct {
 	// LastRemediation stores info about last remediation performed.
 	// +optional
 	LastRemediation *LastRemediationStatus `json:"lastRemediation,omitempty"`
+
+	// CertificatesExpiryDate is the earliest certificates expiry date across the control plane machines.
+	// +optional
+	CertificatesExpiryDate *metav1.Time `json:"certificatesExpiryDate,omitempty"`
+
+	// VersionReplicas is the number of control plane machines running each Kubernetes version.
+	// +optional
+	VersionReplicas []VersionReplicas `json:"versionReplicas,omitempty"`
+
+	// InPlaceUpgrade reports the progress of the in-place upgrade of the control plane machines, if any.
+	// +optional
+	InPlaceUpgrade *InPlaceUpgradeStatus `json:"inPlaceUpgrade,omitempty"`
+
+	// Machines holds per-machine details about the control plane machines.
+	// +optional
+	Machines []MachineStatus `json:"machines,omitempty"`
+}
+
+// VersionReplicas is the number of machines running a specific Kubernetes version.
+type VersionReplicas struct {
+	// Version is the Kubernetes version of the machines.
+	Version string `json:"version"`
+
+	// Replicas is the number of machines running this version.
+	Replicas int32 `json:"replicas"`
+}
+
+// InPlaceUpgradeStatus summarizes the in-place upgrade of the control plane machines.
+type InPlaceUpgradeStatus struct {
+	// Release is the release the control plane machines are upgraded to.
+	// +optional
+	Release string `json:"release,omitempty"`
+
+	// State is the in-place upgrade status of the CK8sControlPlane (in-progress, done or failed).
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// Pending is the number of machines that have not started the upgrade yet.
+	Pending int32 `json:"pending"`
+
+	// InProgress is the number of machines currently being upgraded.
+	InProgress int32 `json:"inProgress"`
+
+	// Done is the number of machines already upgraded to the release.
+	Done int32 `json:"done"`
+
+	// Failed is the number of machines whose last upgrade attempt failed.
+	Failed int32 `json:"failed"`
+}
+
+// MachineStatus holds details about a single control plane machine.
+type MachineStatus struct {
+	// Name is the name of the machine.
+	Name string `json:"name"`
+
+	// Version is the Kubernetes version of the machine.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// SnapSource is the snap channel, revision or local path the k8s snap of the machine was last refreshed to by
+	// an in-place upgrade, or else installed from, e.g. "channel=1.31-classic/stable" or "revision=1234".
+	// It is not the revision installed by snapd, which k8sd does not report.
+	// +optional
+	SnapSource string `json:"snapSource,omitempty"`
+
+	// CertificatesExpiryDate is the expiry date of the machine certificates.
+	// +optional
+	CertificatesExpiryDate *metav1.Time `json:"certificatesExpiryDate,omitempty"`
 }
 
 // LastRemediationStatus  stores info about last remediation performed.
@@ -276,6 +353,8 @@ type LastRemediationStatus struct {
 // +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyReplicas",description="Total number of fully running and ready control plane machines"
 // +kubebuilder:printcolumn:name="Updated",type=integer,JSONPath=".status.updatedReplicas",description="Total number of non-terminated machines targeted by this control plane that have the desired template spec"
 // +kubebuilder:printcolumn:name="Unavailable",type=integer,JSONPath=".status.unavailableReplicas",description="Total number of unavailable machines targeted by this control plane"
+// +kubebuilder:printcolumn:name="Certificates Expiry",type=string,format=date-time,JSONPath=".status.certificatesExpiryDate",description="Earliest certificates expiry date across the control plane machines"
+// +kubebuilder:printcolumn:name="Upgrade",type=string,JSONPath=".status.inPlaceUpgrade.state",description="State of the in-place upgrade of the control plane"
 
 // CK8sControlPlane is the Schema for the ck8scontrolplanes API.
 type CK8sControlPlane struct {
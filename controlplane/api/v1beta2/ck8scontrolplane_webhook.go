@@ -23,8 +23,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
 )
 
 // SetupWebhookWithManager will setup the webhooks for the CK8sControlPlane.
@@ -43,13 +46,13 @@ var _ admission.CustomDefaulter = &CK8sControlPlane{}
 var _ admission.CustomValidator = &CK8sControlPlane{}
 
 // ValidateCreate will do any extra validation when creating a CK8sControlPlane.
-func (in *CK8sControlPlane) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
-	return []string{}, nil
+func (in *CK8sControlPlane) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sControlPlane(obj)
 }
 
 // ValidateUpdate will do any extra validation when updating a CK8sControlPlane.
-func (in *CK8sControlPlane) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
-	return []string{}, nil
+func (in *CK8sControlPlane) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sControlPlane(newObj)
 }
 
 // ValidateDelete allows you to add any extra validation when deleting.
@@ -68,6 +71,26 @@ func (in *CK8sControlPlane) Default(_ context.Context, obj runtime.Object) error
 	return nil
 }
 
+func validateCK8sControlPlane(obj runtime.Object) error {
+	c, ok := obj.(*CK8sControlPlane)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a CK8sControlPlane but got a %T", obj))
+	}
+
+	fldPath := field.NewPath("spec", "spec", "controlPlane")
+	allErrs := c.Spec.CK8sConfigSpec.ControlPlaneConfig.ValidateAuthentication(fldPath)
+	allErrs = append(allErrs, c.Spec.CK8sConfigSpec.ControlPlaneConfig.ValidateAudit(fldPath)...)
+	allErrs = append(allErrs, c.Spec.CK8sConfigSpec.ControlPlaneConfig.ValidateEncryptionAtRest(fldPath)...)
+	allErrs = append(allErrs, c.Spec.CertificatesRenewal.Validate(field.NewPath("spec", "certificatesRenewal"))...)
+	if _, err := maintenance.New(c.Spec.MaintenanceWindow); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "maintenanceWindow"), c.Spec.MaintenanceWindow, err.Error()))
+	}
+	if len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("CK8sControlPlane").GroupKind(), c.Name, allErrs)
+	}
+	return nil
+}
+
 func defaultCK8sControlPlaneSpec(s *CK8sControlPlaneSpec, namespace string) {
 	if s.Replicas == nil {
 		replicas := int32(1)
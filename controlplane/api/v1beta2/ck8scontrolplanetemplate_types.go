@@ -60,6 +60,15 @@ type CK8sControlPlaneTemplateResourceSpec struct {
 	// +optional
 	// +kubebuilder:default={rollingUpdate: {maxSurge: 1}}
 	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// CertificatesRenewal configures the automatic renewal of the control plane machine certificates.
+	// +optional
+	CertificatesRenewal *bootstrapv1beta2.CertificatesRenewalPolicy `json:"certificatesRenewal,omitempty"`
+
+	// MaintenanceWindow restricts the disruptive operations on the control plane machines, such as
+	// rollouts, scale downs, remediations, in-place upgrades and certificates refreshes, to maintenance windows.
+	// +optional
+	MaintenanceWindow *bootstrapv1beta2.MaintenanceWindowPolicy `json:"maintenanceWindow,omitempty"`
 }
 
 // +kubebuilder:object:root=true
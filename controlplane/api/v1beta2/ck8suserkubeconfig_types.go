@@ -0,0 +1,123 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+)
+
+const (
+	// DefaultUserKubeconfigTTL is the default validity of the client certificate of a user kubeconfig.
+	DefaultUserKubeconfigTTL = "24h"
+
+	// MaxUserKubeconfigTTL is the maximum validity of the client certificate of a user kubeconfig.
+	// It is kept short since the certificate cannot be revoked.
+	MaxUserKubeconfigTTL = "7d"
+
+	// PrivilegedIdentityPrefix is the prefix of the users and groups reserved for the cluster components,
+	// such as system:masters, which cannot be requested by a user kubeconfig.
+	PrivilegedIdentityPrefix = "system:"
+
+	// UserKubeconfigSecretSuffix is the suffix of the name of the Secret holding a user kubeconfig.
+	UserKubeconfigSecretSuffix = "user-kubeconfig"
+)
+
+// CK8sUserKubeconfigSpec defines the desired state of CK8sUserKubeconfig.
+type CK8sUserKubeconfigSpec struct {
+	// ClusterName is the name of the Cluster, in the same namespace, the kubeconfig grants access to.
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// User is the name of the user the client certificate is issued for.
+	// It is used as the common name of the certificate.
+	// Users prefixed with "system:" are rejected.
+	// +kubebuilder:validation:MinLength=1
+	User string `json:"user"`
+
+	// Groups are the groups the user belongs to.
+	// They are used as the organizations of the certificate.
+	// Groups prefixed with "system:", such as system:masters, are rejected.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// TTL is how long the client certificate is valid for. It is a number followed by a unit:
+	// y (years), mo (months), d (days) or any unit supported by time.ParseDuration. Defaults to "24h" and cannot exceed "7d".
+	// The certificate is renewed once less than a third of its validity remains.
+	// +optional
+	TTL string `json:"ttl,omitempty"`
+}
+
+// GetTTL returns the TTL field.
+// If the field is not set, it returns DefaultUserKubeconfigTTL.
+func (s *CK8sUserKubeconfigSpec) GetTTL() string {
+	if s.TTL == "" {
+		return DefaultUserKubeconfigTTL
+	}
+	return s.TTL
+}
+
+// CK8sUserKubeconfigStatus defines the observed state of CK8sUserKubeconfig.
+type CK8sUserKubeconfigStatus struct {
+	// Ready denotes that the kubeconfig is available in the Secret.
+	// +optional
+	Ready bool `json:"ready"`
+
+	// SecretName is the name of the Secret holding the kubeconfig, under the "value" key.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// ExpiryDate is the expiry date of the client certificate of the kubeconfig.
+	// +optional
+	ExpiryDate *metav1.Time `json:"expiryDate,omitempty"`
+
+	// ObservedGeneration is the latest generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions defines current service state of the CK8sUserKubeconfig.
+	// +optional
+	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=".spec.clusterName",description="Cluster the kubeconfig grants access to"
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=".spec.user",description="User the client certificate is issued for"
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=".status.ready",description="The kubeconfig is available in the Secret"
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=".status.secretName",description="Secret holding the kubeconfig"
+// +kubebuilder:printcolumn:name="Expiry",type=string,format=date-time,JSONPath=".status.expiryDate",description="Expiry date of the client certificate"
+
+// CK8sUserKubeconfig is the Schema for the ck8suserkubeconfigs API.
+// It requests a kubeconfig for a user of a workload cluster, whose client certificate is signed by the
+// client CA of the cluster. The kubeconfig is written to a Secret owned by the CK8sUserKubeconfig, so that
+// it is deleted with it. Deleting it does not revoke the client certificate, which stays valid until it expires.
+// NOTE: the user gets exactly the permissions granted by RBAC in the workload cluster to its name and groups,
+// so access to this resource should be restricted to those allowed to request them.
+type CK8sUserKubeconfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CK8sUserKubeconfigSpec   `json:"spec,omitempty"`
+	Status CK8sUserKubeconfigStatus `json:"status,omitempty"`
+}
+
+func (in *CK8sUserKubeconfig) GetConditions() clusterv1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *CK8sUserKubeconfig) SetConditions(conditions clusterv1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+// +kubebuilder:object:root=true
+
+// CK8sUserKubeconfigList contains a list of CK8sUserKubeconfig.
+type CK8sUserKubeconfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CK8sUserKubeconfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CK8sUserKubeconfig{}, &CK8sUserKubeconfigList{})
+}
@@ -0,0 +1,83 @@
+package v1beta2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+)
+
+// SetupWebhookWithManager will setup the webhooks for the CK8sUserKubeconfig.
+func (in *CK8sUserKubeconfig) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(in).
+		WithValidator(&CK8sUserKubeconfig{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1beta2-ck8suserkubeconfig,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=ck8suserkubeconfigs,versions=v1beta2,name=validation.ck8suserkubeconfig.controlplane.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1;v1beta1
+
+var _ admission.CustomValidator = &CK8sUserKubeconfig{}
+
+// ValidateCreate will do any extra validation when creating a CK8sUserKubeconfig.
+func (in *CK8sUserKubeconfig) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sUserKubeconfig(obj)
+}
+
+// ValidateUpdate will do any extra validation when updating a CK8sUserKubeconfig.
+func (in *CK8sUserKubeconfig) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return []string{}, validateCK8sUserKubeconfig(newObj)
+}
+
+// ValidateDelete allows you to add any extra validation when deleting.
+func (in *CK8sUserKubeconfig) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return []string{}, nil
+}
+
+func validateCK8sUserKubeconfig(obj runtime.Object) error {
+	uk, ok := obj.(*CK8sUserKubeconfig)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a CK8sUserKubeconfig but got a %T", obj))
+	}
+
+	if allErrs := uk.Spec.Validate(field.NewPath("spec")); len(allErrs) > 0 {
+		return apierrors.NewInvalid(GroupVersion.WithKind("CK8sUserKubeconfig").GroupKind(), uk.Name, allErrs)
+	}
+	return nil
+}
+
+// Validate checks that the kubeconfig is not issued for a privileged identity of the cluster, and that its
+// TTL does not exceed MaxUserKubeconfigTTL.
+func (s *CK8sUserKubeconfigSpec) Validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if strings.HasPrefix(s.User, PrivilegedIdentityPrefix) {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("user"), fmt.Sprintf("users prefixed with %q are reserved for the cluster components", PrivilegedIdentityPrefix)))
+	}
+	for i, group := range s.Groups {
+		if strings.HasPrefix(group, PrivilegedIdentityPrefix) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("groups").Index(i), fmt.Sprintf("groups prefixed with %q, such as system:masters, grant privileged access to the cluster", PrivilegedIdentityPrefix)))
+		}
+	}
+
+	ttl, err := utiltime.TTLToSeconds(s.GetTTL())
+	if err != nil {
+		return append(allErrs, field.Invalid(fldPath.Child("ttl"), s.TTL, err.Error()))
+	}
+	maxTTL, _ := utiltime.TTLToSeconds(MaxUserKubeconfigTTL)
+	switch {
+	case ttl <= 0:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), s.TTL, "must be positive"))
+	case ttl > maxTTL:
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), s.TTL, fmt.Sprintf("must not exceed %s", MaxUserKubeconfigTTL)))
+	}
+
+	return allErrs
+}
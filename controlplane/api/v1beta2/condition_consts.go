@@ -104,3 +104,58 @@ const (
 	// TokenGenerationFailedReason documents that the token required for nodes to join the cluster could not be generated.
 	TokenGenerationFailedReason = "TokenGenerationFailed"
 )
+
+const (
+	// KubeconfigAvailableCondition documents whether the kubeconfig secret used to access the workload cluster
+	// holds a valid client certificate. It is not part of the Ready condition of the CK8sControlPlane.
+	KubeconfigAvailableCondition clusterv1.ConditionType = "KubeconfigAvailable"
+
+	// KubeconfigRotationFailedReason (Severity=Warning) documents a failure to regenerate the kubeconfig secret
+	// before its client certificate expires; the controller retries on the next reconciliation.
+	KubeconfigRotationFailedReason = "KubeconfigRotationFailed"
+)
+
+const (
+	// K8sdProxyAvailableCondition documents whether the k8sd-proxy daemonset of the workload cluster matches
+	// the k8sd-proxy configuration of the control plane.
+	K8sdProxyAvailableCondition clusterv1.ConditionType = "K8sdProxyAvailable"
+
+	// K8sdProxyReconciliationFailedReason (Severity=Warning) documents a failure to create or update the k8sd-proxy
+	// daemonset in the workload cluster; the controller retries on the next reconciliation.
+	K8sdProxyReconciliationFailedReason = "K8sdProxyReconciliationFailed"
+)
+
+const (
+	// CertificatesRenewalCondition documents the status of the automatic certificates renewal
+	// of the control plane machines.
+	CertificatesRenewalCondition clusterv1.ConditionType = "CertificatesRenewal"
+
+	// CertificatesRenewalInProgressReason (Severity=Info) documents a control plane machine having its
+	// certificates renewed because they are about to expire.
+	CertificatesRenewalInProgressReason = "CertificatesRenewalInProgress"
+
+	// CertificatesRenewalFailedReason (Severity=Warning) documents a failure to renew the certificates
+	// of a control plane machine; the renewal of the other machines is halted until the renewal of the machine is retried.
+	CertificatesRenewalFailedReason = "CertificatesRenewalFailed"
+)
+
+// Conditions and condition Reasons for the CK8sUserKubeconfig object.
+
+const (
+	// UserKubeconfigAvailableCondition documents that the kubeconfig requested by a CK8sUserKubeconfig is
+	// available in its Secret.
+	UserKubeconfigAvailableCondition clusterv1.ConditionType = "UserKubeconfigAvailable"
+
+	// WaitingForClusterReason (Severity=Info) documents a CK8sUserKubeconfig waiting for its Cluster to exist
+	// and to have a control plane endpoint and certificate authorities.
+	WaitingForClusterReason = "WaitingForCluster"
+
+	// InvalidUserKubeconfigReason (Severity=Error) documents a CK8sUserKubeconfig with an invalid spec,
+	// e.g. a malformed TTL; it is retried once the spec is fixed.
+	InvalidUserKubeconfigReason = "InvalidUserKubeconfig"
+
+	// UserKubeconfigGenerationFailedReason (Severity=Warning) documents a failure to issue the kubeconfig
+	// of a CK8sUserKubeconfig; those kind of errors are usually temporary and the controller automatically
+	// recover from them.
+	UserKubeconfigGenerationFailedReason = "UserKubeconfigGenerationFailed"
+)
@@ -21,6 +21,7 @@ limitations under the License.
 package v1beta2
 
 import (
+	apiv1beta2 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -142,6 +143,16 @@ func (in *CK8sControlPlaneSpec) DeepCopyInto(out *CK8sControlPlaneSpec) {
 		*out = new(RolloutStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CertificatesRenewal != nil {
+		in, out := &in.CertificatesRenewal, &out.CertificatesRenewal
+		*out = new(apiv1beta2.CertificatesRenewalPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(apiv1beta2.MaintenanceWindowPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sControlPlaneSpec.
@@ -179,6 +190,27 @@ func (in *CK8sControlPlaneStatus) DeepCopyInto(out *CK8sControlPlaneStatus) {
 		*out = new(LastRemediationStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CertificatesExpiryDate != nil {
+		in, out := &in.CertificatesExpiryDate, &out.CertificatesExpiryDate
+		*out = (*in).DeepCopy()
+	}
+	if in.VersionReplicas != nil {
+		in, out := &in.VersionReplicas, &out.VersionReplicas
+		*out = make([]VersionReplicas, len(*in))
+		copy(*out, *in)
+	}
+	if in.InPlaceUpgrade != nil {
+		in, out := &in.InPlaceUpgrade, &out.InPlaceUpgrade
+		*out = new(InPlaceUpgradeStatus)
+		**out = **in
+	}
+	if in.Machines != nil {
+		in, out := &in.Machines, &out.Machines
+		*out = make([]MachineStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sControlPlaneStatus.
@@ -285,6 +317,16 @@ func (in *CK8sControlPlaneTemplateResourceSpec) DeepCopyInto(out *CK8sControlPla
 		*out = new(RolloutStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CertificatesRenewal != nil {
+		in, out := &in.CertificatesRenewal, &out.CertificatesRenewal
+		*out = new(apiv1beta2.CertificatesRenewalPolicy)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(apiv1beta2.MaintenanceWindowPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sControlPlaneTemplateResourceSpec.
@@ -313,6 +355,126 @@ func (in *CK8sControlPlaneTemplateSpec) DeepCopy() *CK8sControlPlaneTemplateSpec
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CK8sUserKubeconfig) DeepCopyInto(out *CK8sUserKubeconfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sUserKubeconfig.
+func (in *CK8sUserKubeconfig) DeepCopy() *CK8sUserKubeconfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CK8sUserKubeconfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CK8sUserKubeconfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CK8sUserKubeconfigList) DeepCopyInto(out *CK8sUserKubeconfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CK8sUserKubeconfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sUserKubeconfigList.
+func (in *CK8sUserKubeconfigList) DeepCopy() *CK8sUserKubeconfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(CK8sUserKubeconfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CK8sUserKubeconfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CK8sUserKubeconfigSpec) DeepCopyInto(out *CK8sUserKubeconfigSpec) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sUserKubeconfigSpec.
+func (in *CK8sUserKubeconfigSpec) DeepCopy() *CK8sUserKubeconfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CK8sUserKubeconfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CK8sUserKubeconfigStatus) DeepCopyInto(out *CK8sUserKubeconfigStatus) {
+	*out = *in
+	if in.ExpiryDate != nil {
+		in, out := &in.ExpiryDate, &out.ExpiryDate
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1beta1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CK8sUserKubeconfigStatus.
+func (in *CK8sUserKubeconfigStatus) DeepCopy() *CK8sUserKubeconfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CK8sUserKubeconfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InPlaceUpgradeStatus) DeepCopyInto(out *InPlaceUpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InPlaceUpgradeStatus.
+func (in *InPlaceUpgradeStatus) DeepCopy() *InPlaceUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InPlaceUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LastRemediationStatus) DeepCopyInto(out *LastRemediationStatus) {
 	*out = *in
@@ -329,6 +491,25 @@ func (in *LastRemediationStatus) DeepCopy() *LastRemediationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineStatus) DeepCopyInto(out *MachineStatus) {
+	*out = *in
+	if in.CertificatesExpiryDate != nil {
+		in, out := &in.CertificatesExpiryDate, &out.CertificatesExpiryDate
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineStatus.
+func (in *MachineStatus) DeepCopy() *MachineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemediationStrategy) DeepCopyInto(out *RemediationStrategy) {
 	*out = *in
@@ -394,3 +575,18 @@ func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VersionReplicas) DeepCopyInto(out *VersionReplicas) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VersionReplicas.
+func (in *VersionReplicas) DeepCopy() *VersionReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(VersionReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
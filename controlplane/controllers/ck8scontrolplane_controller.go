@@ -33,7 +33,7 @@ import (
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util"
-	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/collections"
 	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
@@ -46,9 +46,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
 	"github.com/canonical/cluster-api-k8s/pkg/kubeconfig"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
 	"github.com/canonical/cluster-api-k8s/pkg/secret"
 	"github.com/canonical/cluster-api-k8s/pkg/token"
 )
@@ -69,6 +72,7 @@ type CK8sControlPlaneReconciler struct {
 
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
 // +kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io;bootstrap.cluster.x-k8s.io;controlplane.cluster.x-k8s.io,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
@@ -98,9 +102,11 @@ func (r *CK8sControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	}
 	logger = logger.WithValues("cluster", cluster.Name)
 
-	if annotations.IsPaused(cluster, kcp) {
-		logger.Info("Reconciliation is paused for this object")
-		return reconcile.Result{}, nil
+	if isPaused, err := paused.EnsurePausedCondition(ctx, r.Client, cluster, kcp); err != nil || isPaused {
+		if isPaused {
+			logger.Info("Reconciliation is paused for this object")
+		}
+		return reconcile.Result{}, err
 	}
 
 	// Wait for the cluster infrastructure to be ready before creating machines
@@ -238,6 +244,9 @@ func (r *CK8sControlPlaneReconciler) reconcileDelete(ctx context.Context, cluste
 
 func patchCK8sControlPlane(ctx context.Context, patchHelper *patch.Helper, kcp *controlplanev1.CK8sControlPlane) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
+	// NOTE: KubeconfigAvailableCondition is not part of the summary, as a failed rotation of the kubeconfig
+	// client certificate leaves the current one valid. Neither is K8sdProxyAvailableCondition, as the k8sd-proxy
+	// daemonset deployed when the cluster was initialized keeps working until it is updated.
 	conditions.SetSummary(kcp,
 		conditions.WithConditions(
 			controlplanev1.MachinesSpecUpToDateCondition,
@@ -261,12 +270,19 @@ func patchCK8sControlPlane(ctx context.Context, patchHelper *patch.Helper, kcp *
 			controlplanev1.AvailableCondition,
 			controlplanev1.CertificatesAvailableCondition,
 			controlplanev1.TokenAvailableCondition,
+			controlplanev1.KubeconfigAvailableCondition,
+			controlplanev1.K8sdProxyAvailableCondition,
+			bootstrapv1.MaintenanceWindowCondition,
 		}},
 		patch.WithStatusObservedGeneration{},
 	)
 }
 
 func (r *CK8sControlPlaneReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, log *logr.Logger) error {
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &controlplanev1.CK8sControlPlane{}, auditPolicyConfigMapField, auditPolicyConfigMapName); err != nil {
+		return fmt.Errorf("failed to index CK8sControlPlanes by audit policy ConfigMap: %w", err)
+	}
+
 	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.CK8sControlPlane{}).
 		Owns(&clusterv1.Machine{}).
@@ -281,6 +297,12 @@ func (r *CK8sControlPlaneReconciler) SetupWithManager(ctx context.Context, mgr c
 				),
 			),
 		).
+		// NOTE: Only the metadata of the ConfigMaps is cached, the policies are read when reconciling.
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.auditPolicyToCK8sControlPlanes),
+			builder.OnlyMetadata,
+		).
 		Build(r)
 	if err != nil {
 		return fmt.Errorf("failed setting up with a controller manager: %w", err)
@@ -309,7 +331,13 @@ func (r *CK8sControlPlaneReconciler) SetupWithManager(ctx context.Context, mgr c
 
 // ClusterToCK8sControlPlane is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
 // for CK8sControlPlane based on updates to a Cluster.
-func (r *CK8sControlPlaneReconciler) ClusterToCK8sControlPlane(_ context.Context, o client.Object) []ctrl.Request {
+func (r *CK8sControlPlaneReconciler) ClusterToCK8sControlPlane(ctx context.Context, o client.Object) []ctrl.Request {
+	return clusterToCK8sControlPlane(ctx, o)
+}
+
+// clusterToCK8sControlPlane maps a Cluster to its CK8sControlPlane. It is shared by the controllers
+// reconciling CK8sControlPlanes, so that they resume once the Cluster is unpaused.
+func clusterToCK8sControlPlane(_ context.Context, o client.Object) []ctrl.Request {
 	c, ok := o.(*clusterv1.Cluster)
 	if !ok {
 		panic(fmt.Sprintf("Expected a Cluster but got a %T", o))
@@ -323,6 +351,37 @@ func (r *CK8sControlPlaneReconciler) ClusterToCK8sControlPlane(_ context.Context
 	return nil
 }
 
+// auditPolicyConfigMapField is the field index of the CK8sControlPlanes by the name of the ConfigMap holding their audit policy.
+const auditPolicyConfigMapField = "spec.spec.controlPlaneConfig.audit.policyConfigMapRef.name"
+
+// auditPolicyConfigMapName is a client.IndexerFunc returning the name of the ConfigMap holding the audit policy of a CK8sControlPlane.
+func auditPolicyConfigMapName(o client.Object) []string {
+	kcp, ok := o.(*controlplanev1.CK8sControlPlane)
+	if !ok {
+		return nil
+	}
+	if audit := kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.Audit; audit != nil && audit.PolicyConfigMapRef.Name != "" {
+		return []string{audit.PolicyConfigMapRef.Name}
+	}
+	return nil
+}
+
+// auditPolicyToCK8sControlPlanes is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for CK8sControlPlanes whose audit policy is held by a ConfigMap, so that policy changes are rolled out.
+func (r *CK8sControlPlaneReconciler) auditPolicyToCK8sControlPlanes(ctx context.Context, o client.Object) []ctrl.Request {
+	kcps := &controlplanev1.CK8sControlPlaneList{}
+	if err := r.List(ctx, kcps, client.InNamespace(o.GetNamespace()), client.MatchingFields{auditPolicyConfigMapField: o.GetName()}); err != nil {
+		r.Log.Error(err, "Failed to list CK8sControlPlanes", "namespace", o.GetNamespace())
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(kcps.Items))
+	for _, kcp := range kcps.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&kcp)})
+	}
+	return requests
+}
+
 // updateStatus is called after every reconcilitation loop in a defer statement to always make sure we have the
 // resource status subresourcs up-to-date.
 func (r *CK8sControlPlaneReconciler) updateStatus(ctx context.Context, kcp *controlplanev1.CK8sControlPlane, cluster *clusterv1.Cluster) error {
@@ -357,6 +416,12 @@ func (r *CK8sControlPlaneReconciler) updateStatus(ctx context.Context, kcp *cont
 		kcp.Status.Version = lowestVersion
 	}
 
+	// surface certificates expiry and in-place upgrade progress from the machine annotations
+	kcp.Status.CertificatesExpiryDate = controlPlane.CertificatesExpiryDate()
+	kcp.Status.VersionReplicas = controlPlane.VersionReplicas()
+	kcp.Status.InPlaceUpgrade = controlPlane.InPlaceUpgradeStatus()
+	kcp.Status.Machines = controlPlane.MachineStatuses()
+
 	// Return early if the deletion timestamp is set, because we don't want to try to connect to the workload cluster
 	// and we don't want to report resize condition (because it is set to deleting into reconcile delete).
 	if !kcp.DeletionTimestamp.IsZero() {
@@ -454,6 +519,13 @@ func (r *CK8sControlPlaneReconciler) reconcile(ctx context.Context, cluster *clu
 		conditions.MarkFalse(kcp, controlplanev1.CertificatesAvailableCondition, controlplanev1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
 		return reconcile.Result{}, err
 	}
+	if kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.EncryptionAtRest != nil {
+		if err := secret.LookupOrGenerateEncryptionKey(ctx, r.Client, util.ObjectKey(cluster), *controllerRef); err != nil {
+			logger.Error(err, "unable to lookup or create encryption keys")
+			conditions.MarkFalse(kcp, controlplanev1.CertificatesAvailableCondition, controlplanev1.CertificatesGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+			return reconcile.Result{}, err
+		}
+	}
 	conditions.MarkTrue(kcp, controlplanev1.CertificatesAvailableCondition)
 
 	if err := token.Reconcile(ctx, r.Client, client.ObjectKeyFromObject(cluster), kcp); err != nil {
@@ -515,6 +587,9 @@ func (r *CK8sControlPlaneReconciler) reconcile(ctx context.Context, cluster *clu
 		return reconcile.Result{}, err
 	}
 
+	// Keep the k8sd-proxy daemonset in line with the k8sd-proxy configuration.
+	r.reconcileK8sdProxy(ctx, controlPlane)
+
 	// Reconcile unhealthy machines by triggering deletion and requeue if it is considered safe to remediate,
 	// otherwise continue with the other KCP operations.
 	if result, err := r.reconcileUnhealthyMachines(ctx, controlPlane); err != nil || !result.IsZero() {
@@ -525,6 +600,10 @@ func (r *CK8sControlPlaneReconciler) reconcile(ctx context.Context, cluster *clu
 	needRollout := controlPlane.MachinesNeedingRollout()
 	switch {
 	case len(needRollout) > 0:
+		if result, wait, err := waitForMaintenanceWindow(kcp, "the rollout of the control plane machines"); err != nil || wait {
+			logger.Info("Waiting for a maintenance window to roll out Control Plane machines", "needRollout", needRollout.Names())
+			return result, err
+		}
 		logger.Info("Rolling out Control Plane machines", "needRollout", needRollout.Names())
 		conditions.MarkFalse(controlPlane.KCP, controlplanev1.MachinesSpecUpToDateCondition, controlplanev1.RollingUpdateInProgressReason, clusterv1.ConditionSeverityWarning, "Rolling %d replicas with outdated spec (%d replicas up to date)", len(needRollout), len(controlPlane.Machines)-len(needRollout))
 		return r.upgradeControlPlane(ctx, cluster, kcp, controlPlane, needRollout)
@@ -555,6 +634,10 @@ func (r *CK8sControlPlaneReconciler) reconcile(ctx context.Context, cluster *clu
 		return r.scaleUpControlPlane(ctx, cluster, kcp, controlPlane)
 	// We are scaling down
 	case numMachines > desiredReplicas:
+		if result, wait, err := waitForMaintenanceWindow(kcp, "the scale down of the control plane"); err != nil || wait {
+			logger.Info("Waiting for a maintenance window to scale down control plane", "Desired", desiredReplicas, "Existing", numMachines)
+			return result, err
+		}
 		logger.Info("Scaling down control plane", "Desired", desiredReplicas, "Existing", numMachines)
 		// The last parameter (i.e. machines needing to be rolled out) should always be empty here.
 		return r.scaleDownControlPlane(ctx, cluster, kcp, controlPlane, collections.Machines{})
@@ -631,20 +714,28 @@ func (r *CK8sControlPlaneReconciler) reconcileKubeconfig(ctx context.Context, cl
 		return reconcile.Result{}, nil
 	}
 
-	/**
-	// TODO rotation
-	needsRotation, err := kubeconfig.NeedsClientCertRotation(configSecret, certs.ClientCertificateRenewalDuration)
+	needsRotation, err := kubeconfig.NeedsClientCertRotation(ctx, r.Client, configSecret, certs.ClientCertificateRenewalDuration)
 	if err != nil {
-		return err
+		conditions.MarkFalse(kcp, controlplanev1.KubeconfigAvailableCondition, controlplanev1.KubeconfigRotationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return reconcile.Result{}, fmt.Errorf("failed to check kubeconfig client certificate expiry: %w", err)
 	}
 
 	if needsRotation {
-		r.Log.Info("rotating kubeconfig secret")
-		if err := kubeconfig.RegenerateSecret(ctx, r.Client, configSecret); err != nil {
-			return fmt.Errorf("failed to regenerate kubeconfig")
+		logger := r.Log.WithValues("namespace", kcp.Namespace, "CK8sControlPlane", kcp.Name, "cluster", clusterName.Name)
+		logger.Info("Rotating kubeconfig secret", "expiry", configSecret.Annotations[kubeconfig.ClientCertificateExpiryAnnotation])
+		if err := kubeconfig.RegenerateSecret(ctx, r.Client, configSecret, endpoint.String()); err != nil {
+			if errors.Is(err, kubeconfig.ErrDependentCertificateNotFound) {
+				return ctrl.Result{RequeueAfter: dependentCertRequeueAfter}, nil
+			}
+			r.recorder.Eventf(kcp, corev1.EventTypeWarning, "KubeconfigRotationFailed",
+				"Failed to regenerate kubeconfig secret %s/%s: %v", configSecret.Namespace, configSecret.Name, err)
+			conditions.MarkFalse(kcp, controlplanev1.KubeconfigAvailableCondition, controlplanev1.KubeconfigRotationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+			return reconcile.Result{}, fmt.Errorf("failed to regenerate kubeconfig: %w", err)
 		}
+		r.recorder.Eventf(kcp, corev1.EventTypeNormal, "KubeconfigRotated",
+			"Regenerated kubeconfig secret %s/%s, client certificate expires at %s", configSecret.Namespace, configSecret.Name, configSecret.Annotations[kubeconfig.ClientCertificateExpiryAnnotation])
 	}
-	**/
+	conditions.MarkTrue(kcp, controlplanev1.KubeconfigAvailableCondition)
 
 	return reconcile.Result{}, nil
 }
@@ -675,6 +766,34 @@ func (r *CK8sControlPlaneReconciler) reconcileControlPlaneConditions(ctx context
 	return nil
 }
 
+// reconcileK8sdProxy creates or updates the k8sd-proxy daemonset of the workload cluster, which is first deployed
+// when the cluster is initialized. Failures are reported in the K8sdProxyAvailable condition, without blocking
+// the other operations of the control plane.
+func (r *CK8sControlPlaneReconciler) reconcileK8sdProxy(ctx context.Context, controlPlane *ck8s.ControlPlane) {
+	kcp := controlPlane.KCP
+	if !kcp.Status.Initialized {
+		return
+	}
+
+	logger := r.Log.WithValues("namespace", kcp.Namespace, "CK8sControlPlane", kcp.Name)
+
+	microclusterPort := kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.GetMicroclusterPort()
+	workloadCluster, err := r.managementCluster.GetWorkloadCluster(ctx, util.ObjectKey(controlPlane.Cluster), microclusterPort)
+	if err == nil {
+		err = workloadCluster.ReconcileK8sdProxy(ctx, ck8s.K8sdProxyDaemonSetInput{
+			K8sdPort: microclusterPort,
+			Config:   kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.K8sdProxy,
+		})
+	}
+	if err != nil {
+		logger.Error(err, "failed to reconcile k8sd-proxy")
+		conditions.MarkFalse(kcp, controlplanev1.K8sdProxyAvailableCondition, controlplanev1.K8sdProxyReconciliationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+		return
+	}
+
+	conditions.MarkTrue(kcp, controlplanev1.K8sdProxyAvailableCondition)
+}
+
 func (r *CK8sControlPlaneReconciler) syncMachines(ctx context.Context, kcp *controlplanev1.CK8sControlPlane, controlPlane *ck8s.ControlPlane) error {
 	for machineName := range controlPlane.Machines {
 		m := controlPlane.Machines[machineName]
@@ -708,6 +827,20 @@ func (r *CK8sControlPlaneReconciler) syncMachines(ctx context.Context, kcp *cont
 	return nil
 }
 
+// waitForMaintenanceWindow checks if a new disruptive operation may start on the control plane machines,
+// according to the maintenance window policy of the CK8sControlPlane. Otherwise, it returns when to check again.
+func waitForMaintenanceWindow(kcp *controlplanev1.CK8sControlPlane, operation string) (ctrl.Result, bool, error) {
+	windows, err := maintenance.New(kcp.Spec.MaintenanceWindow)
+	if err != nil {
+		return ctrl.Result{}, true, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+
+	if allowed, requeueAfter := maintenance.Allow(kcp, windows, time.Now(), operation); !allowed {
+		return ctrl.Result{RequeueAfter: requeueAfter}, true, nil
+	}
+	return ctrl.Result{}, false, nil
+}
+
 func (r *CK8sControlPlaneReconciler) upgradeControlPlane(
 	ctx context.Context,
 	cluster *clusterv1.Cluster,
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+)
+
+func TestAuditPolicyToCK8sControlPlanes(t *testing.T) {
+	g := NewWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(controlplanev1.AddToScheme(scheme)).To(Succeed())
+
+	newKCP := func(namespace, name, policy string) *controlplanev1.CK8sControlPlane {
+		kcp := &controlplanev1.CK8sControlPlane{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		if policy != "" {
+			kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.Audit = &bootstrapv1.AuditConfig{
+				PolicyConfigMapRef: bootstrapv1.ConfigMapRef{Name: policy},
+			}
+		}
+		return kcp
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&controlplanev1.CK8sControlPlane{}, auditPolicyConfigMapField, auditPolicyConfigMapName).
+		WithObjects(
+			newKCP("default", "audited", "policy"),
+			newKCP("default", "other-policy", "other"),
+			newKCP("default", "not-audited", ""),
+			newKCP("other", "other-namespace", "policy"),
+		).
+		Build()
+	r := &CK8sControlPlaneReconciler{Client: c, Log: logr.Discard()}
+
+	policy := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default"}}
+	g.Expect(r.auditPolicyToCK8sControlPlanes(context.Background(), policy)).To(ConsistOf(
+		ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "audited"}},
+	))
+
+	unrelated := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}}
+	g.Expect(r.auditPolicyToCK8sControlPlanes(context.Background(), unrelated)).To(BeEmpty())
+}
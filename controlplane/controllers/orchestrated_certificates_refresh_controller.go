@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+// ControlPlaneCertificatesReconciler reconciles a CK8sControlPlane object and orchestrates
+// the certificates refresh of the control plane machines.
+type ControlPlaneCertificatesReconciler struct {
+	scheme        *runtime.Scheme
+	recorder      record.EventRecorder
+	machineGetter inplace.MachineGetter
+
+	client.Client
+	Log logr.Logger
+}
+
+// controlPlaneCertificatesScope is a struct that holds the context of the certificates refresh process.
+type controlPlaneCertificatesScope struct {
+	ck8sControlPlane *controlplanev1.CK8sControlPlane
+	ck8sPatcher      certificates.Patcher
+	ttl              string
+	maxConcurrency   int
+	ownedMachines    []*clusterv1.Machine
+	windows          *maintenance.Windows
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ControlPlaneCertificatesReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-cp-certificates-controller")
+	r.machineGetter = &ck8s.Management{
+		Client: r.Client,
+	}
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&controlplanev1.CK8sControlPlane{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToCK8sControlPlane),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
+		Owns(&clusterv1.Machine{}).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8scontrolplanes;ck8scontrolplanes/status,verbs=get;list;watch;update;patch
+
+// Reconcile handles the reconciliation of a CK8sControlPlane object.
+func (r *ControlPlaneCertificatesReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("orchestrated_certificates_refresh", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	ck8sCP := &controlplanev1.CK8sControlPlane{}
+	if err := r.Get(ctx, req.NamespacedName, ck8sCP); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("CK8sControlPlane resource not found. Ignoring since the object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get CK8sControlPlane: %w", err)
+	}
+
+	if certificates.GetRefreshInstructions(ck8sCP) == "" {
+		log.V(1).Info("CK8sControlPlane has no certificates refresh instructions, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	if isDeleted(ck8sCP) {
+		log.V(1).Info("CK8sControlPlane is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	cluster, err := util.GetOwnerCluster(ctx, r.Client, ck8sCP.ObjectMeta)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	if cluster == nil {
+		log.V(1).Info("Cluster Controller has not yet set OwnerRef")
+		return ctrl.Result{}, nil
+	}
+
+	if annotations.IsPaused(cluster, ck8sCP) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	scope, err := r.createScope(ctx, cluster, ck8sCP)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
+	}
+
+	if !certificates.IsRefreshInProgress(ck8sCP) {
+		if err := r.startRefresh(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to start certificates refresh: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	progress := certificates.GetRefreshProgress(scope.ownedMachines)
+
+	if len(progress.Failed) > 0 {
+		log.Info("Certificates refresh failed for machine, stopping", "machine", progress.Failed[0].Name)
+		if err := r.markRefreshFailed(ctx, scope, "Certificates refresh failed for machine %q", progress.Failed[0].Name); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as failed: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if len(progress.Pending) == 0 && len(progress.Refreshing) == 0 {
+		if err := r.markRefreshDone(ctx, scope); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark certificates refresh as done: %w", err)
+		}
+
+		log.V(1).Info("All machines have their certificates refreshed")
+		return ctrl.Result{}, nil
+	}
+
+	// The certificates refresh of new machines only starts within a maintenance window.
+	if len(progress.Pending) > 0 && len(progress.Refreshing) < scope.maxConcurrency {
+		if result, wait, err := r.waitForMaintenanceWindow(ctx, scope); err != nil || wait {
+			log.V(1).Info("Waiting for a maintenance window to refresh machine certificates", "pending", len(progress.Pending))
+			return result, err
+		}
+	}
+
+	refreshing := len(progress.Refreshing)
+	for _, m := range progress.Pending {
+		if refreshing >= scope.maxConcurrency {
+			break
+		}
+
+		if isDeleted(m) {
+			log.V(1).Info("Machine is being deleted, skipping", "machine", m.Name)
+			continue
+		}
+
+		if err := r.markMachineToRefresh(ctx, scope, m); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to mark machine to refresh certificates: %w", err)
+		}
+
+		log.V(1).Info("Machine marked for certificates refresh", "machine", m.Name)
+		refreshing++
+	}
+
+	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+}
+
+// startRefresh validates the refresh request, resets the refresh status of the machines
+// and marks the CK8sControlPlane as certificates refresh in-progress.
+func (r *ControlPlaneCertificatesReconciler) startRefresh(ctx context.Context, scope *controlPlaneCertificatesScope) error {
+	if _, err := utiltime.TTLToSeconds(scope.ttl); err != nil {
+		return r.markRefreshFailed(ctx, scope, "Invalid certificates TTL %q: %v", scope.ttl, err)
+	}
+	if scope.maxConcurrency == 0 {
+		return r.markRefreshFailed(ctx, scope, "Invalid value for annotation %q", bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation)
+	}
+
+	for _, m := range scope.ownedMachines {
+		if err := certificates.ResetRefreshStatus(ctx, m, r.Client); err != nil {
+			return fmt.Errorf("failed to reset certificates refresh status of machine %q: %w", m.Name, err)
+		}
+	}
+
+	if err := certificates.MarkRefreshInProgress(ctx, scope.ck8sControlPlane, scope.ck8sPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh in-progress: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.ck8sControlPlane,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Certificates refresh is in-progress. TTL: %s",
+		scope.ttl,
+	)
+	return nil
+}
+
+// markRefreshDone annotates the CK8sControlPlane with certificates refresh done.
+func (r *ControlPlaneCertificatesReconciler) markRefreshDone(ctx context.Context, scope *controlPlaneCertificatesScope) error {
+	if err := certificates.MarkRefreshDone(ctx, scope.ck8sControlPlane, scope.ck8sPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh done: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.ck8sControlPlane,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshDoneEvent,
+		"Certificates refresh is done",
+	)
+	return nil
+}
+
+// markRefreshFailed annotates the CK8sControlPlane with certificates refresh failed.
+func (r *ControlPlaneCertificatesReconciler) markRefreshFailed(ctx context.Context, scope *controlPlaneCertificatesScope, format string, args ...interface{}) error {
+	if err := certificates.MarkRefreshFailed(ctx, scope.ck8sControlPlane, scope.ck8sPatcher); err != nil {
+		return fmt.Errorf("failed to mark object with certificates refresh failed: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.ck8sControlPlane,
+		corev1.EventTypeWarning,
+		bootstrapv1.CertificatesRefreshFailedEvent,
+		format,
+		args...,
+	)
+	return nil
+}
+
+// markMachineToRefresh marks the machine to refresh its certificates.
+func (r *ControlPlaneCertificatesReconciler) markMachineToRefresh(ctx context.Context, scope *controlPlaneCertificatesScope, m *clusterv1.Machine) error {
+	if err := certificates.MarkMachineToRefresh(ctx, m, scope.ttl, r.Client); err != nil {
+		return fmt.Errorf("failed to mark machine to refresh certificates: %w", err)
+	}
+
+	r.recorder.Eventf(
+		scope.ck8sControlPlane,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Machine %q is refreshing certificates",
+		m.Name,
+	)
+
+	return nil
+}
+
+// waitForMaintenanceWindow checks if the certificates refresh of new machines may start, according to the
+// maintenance windows of the CK8sControlPlane, and reports the result on the CK8sControlPlane.
+func (r *ControlPlaneCertificatesReconciler) waitForMaintenanceWindow(ctx context.Context, scope *controlPlaneCertificatesScope) (ctrl.Result, bool, error) {
+	allowed, requeueAfter := maintenance.Allow(scope.ck8sControlPlane, scope.windows, time.Now(), "the certificates refresh of the next machines")
+	if !allowed || conditions.Has(scope.ck8sControlPlane, bootstrapv1.MaintenanceWindowCondition) {
+		if err := scope.ck8sPatcher.Patch(ctx, scope.ck8sControlPlane, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.MaintenanceWindowCondition}}); err != nil {
+			return ctrl.Result{}, false, fmt.Errorf("failed to patch CK8sControlPlane: %w", err)
+		}
+	}
+
+	if !allowed {
+		return ctrl.Result{RequeueAfter: requeueAfter}, true, nil
+	}
+	return ctrl.Result{}, false, nil
+}
+
+// createScope creates a new controlPlaneCertificatesScope.
+func (r *ControlPlaneCertificatesReconciler) createScope(ctx context.Context, cluster *clusterv1.Cluster, ck8sCP *controlplanev1.CK8sControlPlane) (*controlPlaneCertificatesScope, error) {
+	patchHelper, err := patch.NewHelper(ck8sCP, r.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	ownedMachines, err := r.machineGetter.GetMachinesForCluster(ctx, client.ObjectKeyFromObject(cluster), collections.OwnedMachines(ck8sCP))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned machines: %w", err)
+	}
+
+	windows, err := maintenance.New(ck8sCP.Spec.MaintenanceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance window: %w", err)
+	}
+
+	// NOTE: An invalid concurrency is reported when the refresh is started.
+	maxConcurrency, _ := certificates.GetMaxConcurrency(ck8sCP)
+
+	return &controlPlaneCertificatesScope{
+		ck8sControlPlane: ck8sCP,
+		ck8sPatcher:      patchHelper,
+		ttl:              certificates.GetRefreshInstructions(ck8sCP),
+		maxConcurrency:   maxConcurrency,
+		ownedMachines:    ownedMachines.UnsortedList(),
+		windows:          windows,
+	}, nil
+}
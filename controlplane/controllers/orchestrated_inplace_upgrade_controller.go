@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -10,16 +11,23 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/annotations"
 	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/cluster-api/util/conditions"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/ck8s"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
 	"github.com/canonical/cluster-api-k8s/pkg/trace"
 	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
 )
@@ -33,6 +41,10 @@ type OrchestratedInPlaceUpgradeController struct {
 	client.Client
 	Log  logr.Logger
 	lock inplace.UpgradeLock
+
+	K8sdDialTimeout time.Duration
+
+	managementCluster ck8s.ManagementCluster
 }
 
 // OrchestratedInPlaceUpgradeScope is a struct that holds the context of the upgrade process.
@@ -42,19 +54,31 @@ type OrchestratedInPlaceUpgradeScope struct {
 	ck8sPatcher      inplace.Patcher
 	upgradeTo        string
 	ownedMachines    collections.Machines
+	healthGates      *inplace.HealthGates
+	windows          *maintenance.Windows
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *OrchestratedInPlaceUpgradeController) SetupWithManager(mgr ctrl.Manager) error {
 	r.scheme = mgr.GetScheme()
 	r.recorder = mgr.GetEventRecorderFor("ck8s-cp-orchestrated-inplace-upgrade-controller")
-	r.machineGetter = &ck8s.Management{
-		Client: r.Client,
+	management := &ck8s.Management{
+		Client:          r.Client,
+		K8sdDialTimeout: r.K8sdDialTimeout,
+	}
+	r.machineGetter = management
+	if r.managementCluster == nil {
+		r.managementCluster = management
 	}
-	r.lock = inplace.NewUpgradeLock(r.Client)
+	r.lock = inplace.NewUpgradeLock(r.Client, r.recorder)
 
 	if err := ctrl.NewControllerManagedBy(mgr).
-		For(&controlplanev1.CK8sControlPlane{}).
+		For(&controlplanev1.CK8sControlPlane{}, builder.WithPredicates(predicates.ResourceNotPaused(mgr.GetScheme(), r.Log))).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(clusterToCK8sControlPlane),
+			builder.WithPredicates(predicates.ClusterUnpaused(mgr.GetScheme(), r.Log)),
+		).
 		Owns(&clusterv1.Machine{}).
 		Complete(r); err != nil {
 		return fmt.Errorf("failed to get new controller builder: %w", err)
@@ -64,9 +88,12 @@ func (r *OrchestratedInPlaceUpgradeController) SetupWithManager(mgr ctrl.Manager
 }
 
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;delete;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinesets;machinesets/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=machinedeployments;machinedeployments/status,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
 
 // Reconcile handles the reconciliation of a CK8sControlPlane object.
@@ -104,6 +131,30 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, fmt.Errorf("failed to create scope: %w", err)
 	}
 
+	if annotations.IsPaused(scope.cluster, ck8sCP) {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// The preflight checks run before the upgrade starts, so that no machine is touched if they fail.
+	if inplace.NeedsPreflightChecks(ck8sCP, scope.ownedMachines.UnsortedList()) {
+		if result, passed, err := r.runPreflightChecks(ctx, scope); err != nil || !passed {
+			return result, err
+		}
+	}
+
+	// A rolled back machine halts the upgrade, until the upgrade of the machine is retried.
+	for _, m := range scope.ownedMachines {
+		if inplace.IsMachineRolledBack(m) {
+			log.Info("Machine upgrade was rolled back, halting the upgrade...", "machine", m.Name)
+			if err := r.markUpgradeFailed(ctx, scope, m); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
+			}
+
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+	}
+
 	upgradingMachine, err := r.lock.IsLocked(ctx, scope.cluster)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to check if upgrade is locked: %w", err)
@@ -123,6 +174,17 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 		}
 
 		if inplace.IsUpgraded(upgradingMachine, scope.upgradeTo) {
+			// NOTE: The lock is held until the machine passes the health gates, so that no other machine is upgraded.
+			if scope.healthGates != nil && inplace.NeedsHealthCheck(upgradingMachine, scope.upgradeTo) {
+				if err := r.lock.Renew(ctx, scope.cluster, upgradingMachine); err != nil {
+					return ctrl.Result{}, fmt.Errorf("failed to renew upgrade lock: %w", err)
+				}
+
+				if result, healthy, err := r.reconcileHealthGates(ctx, scope, upgradingMachine); err != nil || !healthy {
+					return result, err
+				}
+			}
+
 			if err := r.lock.Unlock(ctx, scope.cluster); err != nil {
 				return ctrl.Result{}, fmt.Errorf("failed to unlock upgrade: %w", err)
 			}
@@ -147,6 +209,12 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 	var upgradedMachines int
 	for _, m := range scope.ownedMachines {
 		if inplace.IsUpgraded(m, scope.upgradeTo) {
+			if scope.healthGates != nil && inplace.NeedsHealthCheck(m, scope.upgradeTo) {
+				if result, healthy, err := r.reconcileHealthGates(ctx, scope, m); err != nil || !healthy {
+					return result, err
+				}
+			}
+
 			log.V(1).Info("Machine is already upgraded", "machine", m.Name)
 			upgradedMachines++
 			continue
@@ -157,6 +225,26 @@ func (r *OrchestratedInPlaceUpgradeController) Reconcile(ctx context.Context, re
 			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
 		}
 
+		// NOTE: The machine is still upgrading but the lock expired, as the upgrade was not renewed in time.
+		// The upgrade is marked as failed and the machine locked again, so that its retries are tracked.
+		if inplace.IsMachineUpgrading(m) && inplace.GetUpgradeInstructions(m) == scope.upgradeTo {
+			log.Info("Upgrade lock expired while machine was upgrading, marking upgrade as failed...", "machine", m.Name)
+			if err := r.markUpgradeFailed(ctx, scope, m); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to mark upgrade as failed: %w", err)
+			}
+			if err := r.lock.Lock(ctx, scope.cluster, m); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to lock upgrade for machine %q: %w", m.Name, err)
+			}
+
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		// The upgrade of a new machine only starts within a maintenance window.
+		if result, wait, err := r.waitForMaintenanceWindow(ctx, scope); err != nil || wait {
+			log.V(1).Info("Waiting for a maintenance window to upgrade machine", "machine", m.Name)
+			return result, err
+		}
+
 		// Lock the process for the machine and start the upgrade
 		if err := r.lock.Lock(ctx, scope.cluster, m); err != nil {
 			return ctrl.Result{}, fmt.Errorf("failed to lock upgrade for machine %q: %w", m.Name, err)
@@ -205,6 +293,8 @@ func (r *OrchestratedInPlaceUpgradeController) markUpgradeInProgress(ctx context
 
 // markUpgradeDone annotates the CK8sControlPlane with in-place upgrade done.
 func (r *OrchestratedInPlaceUpgradeController) markUpgradeDone(ctx context.Context, scope *OrchestratedInPlaceUpgradeScope) error {
+	// NOTE: The spec of the CK8sControlPlane is left as-is. The version of the upgraded machines is reported by its
+	// status, and the new machines are upgraded in-place to the release once they join.
 	if err := inplace.MarkUpgradeDone(ctx, scope.ck8sControlPlane, scope.upgradeTo, scope.ck8sPatcher); err != nil {
 		return fmt.Errorf("failed to mark object with upgrade done: %w", err)
 	}
@@ -234,6 +324,133 @@ func (r *OrchestratedInPlaceUpgradeController) markUpgradeFailed(ctx context.Con
 	return nil
 }
 
+// reconcileHealthGates checks the health gates of the upgraded machine, and reports the result on the
+// CK8sControlPlane. The upgrade is paused until the machine passes the health gates.
+func (r *OrchestratedInPlaceUpgradeController) reconcileHealthGates(ctx context.Context, scope *OrchestratedInPlaceUpgradeScope, m *clusterv1.Machine) (ctrl.Result, bool, error) {
+	microclusterPort := scope.ck8sControlPlane.Spec.CK8sConfigSpec.ControlPlaneConfig.GetMicroclusterPort()
+	workload, err := r.managementCluster.GetWorkloadCluster(ctx, util.ObjectKey(scope.cluster), microclusterPort)
+	if err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to get workload cluster: %w", err)
+	}
+
+	result, err := inplace.CheckMachineHealth(ctx, m, scope.upgradeTo, scope.healthGates, workload, r.Client)
+	if err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to check health of machine %q: %w", m.Name, err)
+	}
+
+	switch {
+	case result.Healthy:
+		conditions.MarkTrue(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradeHealthyCondition)
+	case result.Failed:
+		if conditions.GetReason(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradeHealthyCondition) != bootstrapv1.InPlaceUpgradeHealthCheckFailedReason {
+			r.recorder.Eventf(
+				scope.ck8sControlPlane,
+				corev1.EventTypeWarning,
+				bootstrapv1.InPlaceUpgradeHealthCheckFailedEvent,
+				"Machine %q did not pass the health gates: %s",
+				m.Name,
+				result.Message,
+			)
+		}
+		conditions.MarkFalse(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradeHealthyCondition, bootstrapv1.InPlaceUpgradeHealthCheckFailedReason, clusterv1.ConditionSeverityError, "Machine %q did not pass the health gates: %s", m.Name, result.Message)
+	default:
+		conditions.MarkFalse(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradeHealthyCondition, bootstrapv1.InPlaceUpgradeHealthCheckPendingReason, clusterv1.ConditionSeverityInfo, "Waiting for machine %q to pass the health gates: %s", m.Name, result.Message)
+	}
+
+	if err := scope.ck8sPatcher.Patch(ctx, scope.ck8sControlPlane, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.InPlaceUpgradeHealthyCondition}}); err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to patch CK8sControlPlane: %w", err)
+	}
+
+	switch {
+	case result.Healthy:
+		return ctrl.Result{}, true, nil
+	case result.Failed:
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, false, nil
+	default:
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, false, nil
+	}
+}
+
+// runPreflightChecks checks that the in-place upgrade can start, and reports the result on the CK8sControlPlane.
+func (r *OrchestratedInPlaceUpgradeController) runPreflightChecks(ctx context.Context, scope *OrchestratedInPlaceUpgradeScope) (ctrl.Result, bool, error) {
+	workerVersions, err := r.getWorkerVersions(ctx, scope.cluster)
+	if err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to get worker versions: %w", err)
+	}
+
+	result := inplace.RunPreflightChecks(ctx, r.Client, inplace.Preflight{
+		Cluster:             scope.cluster,
+		Object:              scope.ck8sControlPlane,
+		Machines:            scope.ownedMachines.SortedByCreationTimestamp(),
+		Release:             scope.upgradeTo,
+		ControlPlaneVersion: inplace.GetCurrentVersion(scope.ck8sControlPlane, scope.ck8sControlPlane.Spec.Version),
+		WorkerVersions:      workerVersions,
+	})
+
+	if len(result.Reasons) == 0 {
+		// NOTE: The skipped checks are reported, so that passing them is not mistaken for checking them.
+		conditions.Set(scope.ck8sControlPlane, &clusterv1.Condition{
+			Type:    bootstrapv1.InPlaceUpgradePreflightCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  bootstrapv1.InPlaceUpgradePreflightChecksSkippedReason,
+			Message: fmt.Sprintf("Skipped: %s", strings.Join(result.Skipped, "; ")),
+		})
+	} else {
+		message := strings.Join(result.Reasons, "; ")
+		if conditions.GetMessage(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradePreflightCondition) != message {
+			r.recorder.Eventf(
+				scope.ck8sControlPlane,
+				corev1.EventTypeWarning,
+				bootstrapv1.InPlaceUpgradePreflightFailedEvent,
+				"In-place upgrade to %q did not pass the preflight checks: %s",
+				scope.upgradeTo,
+				message,
+			)
+		}
+		conditions.MarkFalse(scope.ck8sControlPlane, bootstrapv1.InPlaceUpgradePreflightCondition, bootstrapv1.InPlaceUpgradePreflightFailedReason, clusterv1.ConditionSeverityError, "%s", message)
+	}
+
+	if err := scope.ck8sPatcher.Patch(ctx, scope.ck8sControlPlane, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.InPlaceUpgradePreflightCondition}}); err != nil {
+		return ctrl.Result{}, false, fmt.Errorf("failed to patch CK8sControlPlane: %w", err)
+	}
+
+	if len(result.Reasons) > 0 {
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, false, nil
+	}
+	return ctrl.Result{}, true, nil
+}
+
+// getWorkerVersions returns the current Kubernetes versions of the MachineDeployments of the cluster, by name.
+func (r *OrchestratedInPlaceUpgradeController) getWorkerVersions(ctx context.Context, cluster *clusterv1.Cluster) (map[string]string, error) {
+	mdList := &clusterv1.MachineDeploymentList{}
+	if err := r.List(ctx, mdList, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.ClusterNameLabel: cluster.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list MachineDeployments: %w", err)
+	}
+
+	workerVersions := make(map[string]string, len(mdList.Items))
+	for i := range mdList.Items {
+		md := &mdList.Items[i]
+		workerVersions[fmt.Sprintf("MachineDeployment %q", md.Name)] = inplace.GetCurrentVersion(md, ptr.Deref(md.Spec.Template.Spec.Version, ""))
+	}
+	return workerVersions, nil
+}
+
+// waitForMaintenanceWindow checks if the upgrade of a new machine may start, according to the maintenance window
+// policy of the CK8sControlPlane, and reports the result on the CK8sControlPlane.
+func (r *OrchestratedInPlaceUpgradeController) waitForMaintenanceWindow(ctx context.Context, scope *OrchestratedInPlaceUpgradeScope) (ctrl.Result, bool, error) {
+	allowed, requeueAfter := maintenance.Allow(scope.ck8sControlPlane, scope.windows, time.Now(), "the in-place upgrade of the next machine")
+	if !allowed || conditions.Has(scope.ck8sControlPlane, bootstrapv1.MaintenanceWindowCondition) {
+		if err := scope.ck8sPatcher.Patch(ctx, scope.ck8sControlPlane, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{bootstrapv1.MaintenanceWindowCondition}}); err != nil {
+			return ctrl.Result{}, false, fmt.Errorf("failed to patch CK8sControlPlane: %w", err)
+		}
+	}
+
+	if !allowed {
+		return ctrl.Result{RequeueAfter: requeueAfter}, true, nil
+	}
+	return ctrl.Result{}, false, nil
+}
+
 // createScope creates a new OrchestratedInPlaceUpgradeScope.
 func (r *OrchestratedInPlaceUpgradeController) createScope(ctx context.Context, ck8sCP *controlplanev1.CK8sControlPlane) (*OrchestratedInPlaceUpgradeScope, error) {
 	patchHelper, err := patch.NewHelper(ck8sCP, r.Client)
@@ -251,12 +468,31 @@ func (r *OrchestratedInPlaceUpgradeController) createScope(ctx context.Context,
 		return nil, fmt.Errorf("failed to get owned machines: %w", err)
 	}
 
+	// NOTE: The machines selected by a CK8sUpgradePlan are left to the plan.
+	selectedByPlan, err := inplace.SelectedByUpgradePlan(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upgrade plans: %w", err)
+	}
+	ownedMachines = ownedMachines.Filter(collections.Not(selectedByPlan))
+
+	healthGates, err := inplace.GetHealthGates(ck8sCP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health gates: %w", err)
+	}
+
+	windows, err := maintenance.New(ck8sCP.Spec.MaintenanceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance window: %w", err)
+	}
+
 	return &OrchestratedInPlaceUpgradeScope{
 		cluster:          cluster,
 		ck8sControlPlane: ck8sCP,
 		upgradeTo:        inplace.GetUpgradeInstructions(ck8sCP),
 		ownedMachines:    ownedMachines,
 		ck8sPatcher:      patchHelper,
+		healthGates:      healthGates,
+		windows:          windows,
 	}, nil
 }
 
@@ -272,7 +508,7 @@ func (r *OrchestratedInPlaceUpgradeController) getControlPlaneMachines(ctx conte
 
 // markMachineToUpgrade marks the machine to upgrade.
 func (r *OrchestratedInPlaceUpgradeController) markMachineToUpgrade(ctx context.Context, scope *OrchestratedInPlaceUpgradeScope, m *clusterv1.Machine) error {
-	if err := inplace.MarkMachineToUpgrade(ctx, m, scope.upgradeTo, r.Client); err != nil {
+	if err := inplace.MarkMachineToUpgrade(ctx, m, scope.upgradeTo, scope.ck8sControlPlane, r.Client); err != nil {
 		return fmt.Errorf("failed to mark machine to inplace upgrade: %w", err)
 	}
 
@@ -163,6 +163,13 @@ func (r *CK8sControlPlaneReconciler) reconcileUnhealthyMachines(ctx context.Cont
 		}
 	}
 
+	// Remediation deletes the machine, so it only starts within a maintenance window.
+	if result, wait, err := waitForMaintenanceWindow(controlPlane.KCP, "the remediation of unhealthy machines"); err != nil || wait {
+		log.Info("A control plane machine needs remediation, but no maintenance window is open. Skipping remediation")
+		conditions.MarkFalse(machineToBeRemediated, clusterv1.MachineOwnerRemediatedCondition, clusterv1.WaitingForRemediationReason, clusterv1.ConditionSeverityWarning, "KCP waiting for a maintenance window before triggering remediation")
+		return result, err
+	}
+
 	microclusterPort := controlPlane.KCP.Spec.CK8sConfigSpec.ControlPlaneConfig.GetMicroclusterPort()
 	clusterObjectKey := util.ObjectKey(controlPlane.Cluster)
 	workloadCluster, err := r.managementCluster.GetWorkloadCluster(ctx, clusterObjectKey, microclusterPort)
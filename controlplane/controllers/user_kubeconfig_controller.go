@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/cluster-api/util/predicates"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/kubeconfig"
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+	"github.com/canonical/cluster-api-k8s/pkg/secret"
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+	"github.com/canonical/cluster-api-k8s/pkg/trace"
+)
+
+// userKubeconfigRequeueAfter is how long to wait before checking again a CK8sUserKubeconfig whose Cluster is not ready.
+const userKubeconfigRequeueAfter = 30 * time.Second
+
+// UserKubeconfigReconciler reconciles a CK8sUserKubeconfig object.
+type UserKubeconfigReconciler struct {
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+
+	client.Client
+	Log logr.Logger
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *UserKubeconfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.scheme = mgr.GetScheme()
+	r.recorder = mgr.GetEventRecorderFor("ck8s-user-kubeconfig-controller")
+
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&controlplanev1.CK8sUserKubeconfig{}).
+		Owns(&corev1.Secret{}).
+		Watches(
+			&clusterv1.Cluster{},
+			handler.EnqueueRequestsFromMapFunc(r.clusterToUserKubeconfigs),
+			builder.WithPredicates(predicates.ClusterPausedTransitions(mgr.GetScheme(), r.Log)),
+		).
+		Complete(r); err != nil {
+		return fmt.Errorf("failed to get new controller builder: %w", err)
+	}
+
+	return nil
+}
+
+// clusterToUserKubeconfigs is a handler.ToRequestsFunc to be used to enqueue requests for reconciliation
+// for the CK8sUserKubeconfigs of a Cluster, so that their Paused condition follows the Cluster.
+func (r *UserKubeconfigReconciler) clusterToUserKubeconfigs(ctx context.Context, o client.Object) []ctrl.Request {
+	uks := &controlplanev1.CK8sUserKubeconfigList{}
+	if err := r.List(ctx, uks, client.InNamespace(o.GetNamespace())); err != nil {
+		r.Log.Error(err, "Failed to list CK8sUserKubeconfigs", "namespace", o.GetNamespace())
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for _, uk := range uks.Items {
+		if uk.Spec.ClusterName == o.GetName() {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&uk)})
+		}
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters;clusters/status,verbs=get;list;watch
+// +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=ck8suserkubeconfigs;ck8suserkubeconfigs/status,verbs=get;list;watch;update;patch
+
+// Reconcile issues the kubeconfig requested by a CK8sUserKubeconfig, and renews it before its client certificate expires.
+// The Secret holding the kubeconfig is owned by the CK8sUserKubeconfig, so it is garbage collected when the request is removed.
+func (r *UserKubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, rerr error) {
+	traceID := trace.NewID()
+	log := r.Log.WithValues("user_kubeconfig", req.NamespacedName, "trace_id", traceID)
+	log.V(1).Info("Reconciliation started...")
+
+	uk := &controlplanev1.CK8sUserKubeconfig{}
+	if err := r.Get(ctx, req.NamespacedName, uk); err != nil {
+		if apierrors.IsNotFound(err) {
+			log.V(1).Info("CK8sUserKubeconfig resource not found. Ignoring since the object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get CK8sUserKubeconfig: %w", err)
+	}
+
+	if isDeleted(uk) {
+		log.V(1).Info("CK8sUserKubeconfig is being deleted, skipping reconciliation")
+		return ctrl.Result{}, nil
+	}
+
+	patchHelper, err := patch.NewHelper(uk, r.Client)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	defer func() {
+		conditions.SetSummary(uk, conditions.WithConditions(controlplanev1.UserKubeconfigAvailableCondition))
+		if err := patchHelper.Patch(ctx, uk, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			controlplanev1.UserKubeconfigAvailableCondition,
+			paused.PausedCondition,
+		}}); err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("failed to patch CK8sUserKubeconfig: %w", err))
+		}
+	}()
+
+	// NOTE: the spec is validated here as well, since the webhooks can be disabled.
+	if errs := uk.Spec.Validate(field.NewPath("spec")); len(errs) > 0 {
+		uk.Status.Ready = false
+		conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.InvalidUserKubeconfigReason, clusterv1.ConditionSeverityError, "%s", errs.ToAggregate().Error())
+		return ctrl.Result{}, nil
+	}
+	seconds, _ := utiltime.TTLToSeconds(uk.Spec.GetTTL())
+	ttl := time.Duration(seconds) * time.Second
+
+	cluster := &clusterv1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: uk.Namespace, Name: uk.Spec.ClusterName}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.WaitingForClusterReason, clusterv1.ConditionSeverityInfo, "Cluster %q not found", uk.Spec.ClusterName)
+			return ctrl.Result{RequeueAfter: userKubeconfigRequeueAfter}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	if isPaused, _ := paused.SetPausedCondition(cluster, uk); isPaused {
+		log.V(1).Info("Reconciliation is paused for this object")
+		return ctrl.Result{}, nil
+	}
+
+	// NOTE: the CK8sUserKubeconfig is owned by its Cluster, so that clusterctl move carries it along with the Cluster.
+	uk.OwnerReferences = util.EnsureOwnerRef(uk.OwnerReferences, metav1.OwnerReference{
+		APIVersion: clusterv1.GroupVersion.String(),
+		Kind:       "Cluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	})
+
+	if !cluster.Spec.ControlPlaneEndpoint.IsValid() {
+		conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.WaitingForClusterReason, clusterv1.ConditionSeverityInfo, "Cluster does not yet have a ControlPlaneEndpoint defined")
+		return ctrl.Result{RequeueAfter: userKubeconfigRequeueAfter}, nil
+	}
+
+	secretName := fmt.Sprintf("%s-%s", uk.Name, controlplanev1.UserKubeconfigSecretSuffix)
+	configSecret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: uk.Namespace, Name: secretName}, configSecret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to get kubeconfig secret: %w", err)
+		}
+		configSecret = nil
+	} else if !util.IsControlledBy(configSecret, uk) {
+		conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.UserKubeconfigGenerationFailedReason, clusterv1.ConditionSeverityWarning, "Secret %q exists and is not owned by this CK8sUserKubeconfig", secretName)
+		return ctrl.Result{}, nil
+	}
+
+	// Renew the kubeconfig once less than a third of its validity remains.
+	renewBefore := ttl / 3
+	if configSecret != nil && uk.Status.ObservedGeneration == uk.Generation {
+		expiry, err := kubeconfig.ClientCertificateExpiry(configSecret.Data[secret.KubeconfigDataName])
+		if err == nil && time.Until(expiry) > renewBefore {
+			r.setReady(uk, secretName, expiry)
+			return ctrl.Result{RequeueAfter: time.Until(expiry) - renewBefore}, nil
+		}
+	}
+
+	user := kubeconfig.User{
+		Name:   uk.Spec.User,
+		Groups: uk.Spec.Groups,
+		TTL:    ttl,
+	}
+	data, err := kubeconfig.GenerateUserKubeconfig(ctx, r.Client, util.ObjectKey(cluster), cluster.Spec.ControlPlaneEndpoint.String(), user)
+	if err != nil {
+		if errors.Is(err, kubeconfig.ErrDependentCertificateNotFound) {
+			conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.WaitingForClusterReason, clusterv1.ConditionSeverityInfo, "Waiting for the cluster certificate authorities")
+			return ctrl.Result{RequeueAfter: dependentCertRequeueAfter}, nil
+		}
+		r.markFailed(uk, err)
+		return ctrl.Result{}, fmt.Errorf("failed to generate kubeconfig: %w", err)
+	}
+
+	if err := r.writeSecret(ctx, uk, cluster, secretName, configSecret, data); err != nil {
+		r.markFailed(uk, err)
+		return ctrl.Result{}, err
+	}
+
+	// NOTE: the kubeconfig was just generated, so the client certificate is always found.
+	expiry, _ := kubeconfig.ClientCertificateExpiry(data)
+	log.Info("Issued user kubeconfig", "user", user.Name, "secret", secretName, "expiry", expiry)
+	r.recorder.Eventf(uk, corev1.EventTypeNormal, "KubeconfigIssued", "Issued kubeconfig for user %q in secret %s, valid until %s", user.Name, secretName, expiry.UTC().Format(time.RFC3339))
+
+	uk.Status.ObservedGeneration = uk.Generation
+	r.setReady(uk, secretName, expiry)
+	return ctrl.Result{RequeueAfter: time.Until(expiry) - renewBefore}, nil
+}
+
+// writeSecret creates or updates the Secret holding the kubeconfig of a CK8sUserKubeconfig.
+func (r *UserKubeconfigReconciler) writeSecret(ctx context.Context, uk *controlplanev1.CK8sUserKubeconfig, cluster *clusterv1.Cluster, secretName string, configSecret *corev1.Secret, data []byte) error {
+	if configSecret != nil {
+		configSecret.Data = map[string][]byte{
+			secret.KubeconfigDataName: data,
+		}
+		if err := r.Update(ctx, configSecret); err != nil {
+			return fmt.Errorf("failed to update kubeconfig secret: %w", err)
+		}
+		return nil
+	}
+
+	configSecret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: uk.Namespace,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(uk, controlplanev1.GroupVersion.WithKind("CK8sUserKubeconfig")),
+			},
+		},
+		Data: map[string][]byte{
+			secret.KubeconfigDataName: data,
+		},
+	}
+	if err := r.Create(ctx, configSecret); err != nil {
+		return fmt.Errorf("failed to create kubeconfig secret: %w", err)
+	}
+	return nil
+}
+
+// setReady reports the kubeconfig of a CK8sUserKubeconfig as available.
+func (r *UserKubeconfigReconciler) setReady(uk *controlplanev1.CK8sUserKubeconfig, secretName string, expiry time.Time) {
+	uk.Status.Ready = true
+	uk.Status.SecretName = secretName
+	uk.Status.ExpiryDate = &metav1.Time{Time: expiry}
+	conditions.MarkTrue(uk, controlplanev1.UserKubeconfigAvailableCondition)
+}
+
+// markFailed reports a failure to issue the kubeconfig of a CK8sUserKubeconfig.
+// A kubeconfig issued before keeps being reported as ready until it expires.
+func (r *UserKubeconfigReconciler) markFailed(uk *controlplanev1.CK8sUserKubeconfig, err error) {
+	if uk.Status.ExpiryDate == nil || time.Now().After(uk.Status.ExpiryDate.Time) {
+		uk.Status.Ready = false
+	}
+	conditions.MarkFalse(uk, controlplanev1.UserKubeconfigAvailableCondition, controlplanev1.UserKubeconfigGenerationFailedReason, clusterv1.ConditionSeverityWarning, "%s", err.Error())
+	r.recorder.Eventf(uk, corev1.EventTypeWarning, "KubeconfigIssueFailed", "Failed to issue kubeconfig for user %q: %v", uk.Spec.User, err)
+}
@@ -21,6 +21,7 @@ import (
 	"os"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -29,6 +30,7 @@ import (
 	expv1beta1 "sigs.k8s.io/cluster-api/exp/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -90,6 +92,12 @@ func main() {
 		Cache: cache.Options{
 			SyncPeriod: &syncPeriod,
 		},
+		Client: client.Options{
+			Cache: &client.CacheOptions{
+				// Referenced ConfigMaps, such as audit policies, are read from the API server, so that not every ConfigMap is cached.
+				DisableFor: []client.Object{&corev1.ConfigMap{}},
+			},
+		},
 		Controller: config.Controller{
 			// TODO: avoid duplicate controller names.
 			SkipNameValidation: ptr.To(true),
@@ -124,17 +132,49 @@ func main() {
 
 	inplaceUpgradeLogger := ctrl.Log.WithName("controllers").WithName("OrchestratedInPlaceUpgrade")
 	if err = (&controllers.OrchestratedInPlaceUpgradeController{
-		Client: mgr.GetClient(),
-		Log:    inplaceUpgradeLogger,
+		Client:          mgr.GetClient(),
+		Log:             inplaceUpgradeLogger,
+		K8sdDialTimeout: k8sdDialTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "failed to create controller", "controller", "OrchestratedInPlaceUpgrade")
 	}
 
+	certificatesLogger := ctrl.Log.WithName("controllers").WithName("ControlPlaneCertificates")
+	if err = (&controllers.ControlPlaneCertificatesReconciler{
+		Client: mgr.GetClient(),
+		Log:    certificatesLogger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ControlPlaneCertificates")
+		os.Exit(1)
+	}
+
+	certificatesRenewalLogger := ctrl.Log.WithName("controllers").WithName("CertificatesRenewal")
+	if err = (&controllers.CertificatesRenewalReconciler{
+		Client: mgr.GetClient(),
+		Log:    certificatesRenewalLogger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertificatesRenewal")
+		os.Exit(1)
+	}
+
+	userKubeconfigLogger := ctrl.Log.WithName("controllers").WithName("UserKubeconfig")
+	if err = (&controllers.UserKubeconfigReconciler{
+		Client: mgr.GetClient(),
+		Log:    userKubeconfigLogger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UserKubeconfig")
+		os.Exit(1)
+	}
+
 	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
 		if err = (&controlplanev1.CK8sControlPlane{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create webhook", "webhook", "CK8sControlPlane")
 			os.Exit(1)
 		}
+		if err = (&controlplanev1.CK8sUserKubeconfig{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "CK8sUserKubeconfig")
+			os.Exit(1)
+		}
 	}
 	// +kubebuilder:scaffold:builder
 
@@ -0,0 +1,151 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// k8sd-proxy runs on the nodes of the workload clusters, and forwards the connections that the controllers
+// port-forward through the API server to k8sd on the node.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/canonical/cluster-api-k8s/pkg/proxy"
+)
+
+var setupLog = ctrl.Log.WithName("setup")
+
+func main() {
+	var listenAddr string
+	var metricsAddr string
+	var probeAddr string
+	var target proxy.Target
+	var dialTimeout time.Duration
+	var idleTimeout time.Duration
+
+	flag.StringVar(&listenAddr, "listen-address", ":2380",
+		"The address the proxy listens on. The default listens on both IPv4 and IPv6.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+
+	flag.StringVar(&target.Address, "k8sd-address", "",
+		"The address of k8sd (e.g. 10.0.0.1:6400). If empty, it is read from the k8sd daemon config.")
+	flag.StringVar(&target.DaemonConfig, "k8sd-daemon-config", proxy.DefaultK8sdDaemonConfig,
+		"The microcluster daemon config file of k8sd, which the k8sd address is read from.")
+	flag.StringVar(&target.HostIP, "host-ip", os.Getenv("HOSTIP"),
+		"The IP of the node, used if the k8sd address cannot be read from the k8sd daemon config.")
+	flag.IntVar(&target.Port, "k8sd-port", envInt("K8SD_PORT"),
+		"The port of k8sd, used if the k8sd address cannot be read from the k8sd daemon config.")
+
+	flag.DurationVar(&dialTimeout, "dial-timeout", 10*time.Second,
+		"Duration that the proxy waits at most to establish a connection with k8sd")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 5*time.Minute,
+		"Duration that a connection may go without traffic in either direction before it is closed")
+
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New())
+
+	ctx := log.IntoContext(ctrl.SetupSignalHandler(), ctrl.Log.WithName("k8sd-proxy"))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	forwarder, err := proxy.NewForwarder(target.Resolve, proxy.NewMetrics(registry),
+		proxy.ForwardDialTimeout(dialTimeout), proxy.ForwardIdleTimeout(idleTimeout))
+	if err != nil {
+		setupLog.Error(err, "unable to create forwarder")
+		os.Exit(1)
+	}
+
+	if address, err := target.Resolve(); err != nil {
+		setupLog.Error(err, "unable to resolve k8sd address, will retry for each connection")
+	} else {
+		setupLog.Info("forwarding to k8sd", "address", address)
+	}
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	probeMux := http.NewServeMux()
+	probeMux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	probeMux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := forwarder.Ready(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	l, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		setupLog.Error(err, "unable to listen", "address", listenAddr)
+		os.Exit(1)
+	}
+
+	errs := make(chan error, 3)
+	go func() { errs <- serveHTTP(ctx, metricsAddr, metricsMux) }()
+	go func() { errs <- serveHTTP(ctx, probeAddr, probeMux) }()
+	go func() { errs <- forwarder.Serve(ctx, l) }()
+
+	setupLog.Info("starting k8sd-proxy", "address", l.Addr().String())
+	for range 3 {
+		if err := <-errs; err != nil {
+			setupLog.Error(err, "problem running k8sd-proxy")
+			os.Exit(1)
+		}
+	}
+}
+
+// serveHTTP serves the handler on the address until the context is cancelled.
+func serveHTTP(ctx context.Context, address string, handler http.Handler) error {
+	server := &http.Server{Addr: address, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// envInt returns the integer value of the environment variable, or 0 if it is not set or invalid.
+func envInt(key string) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return 0
+	}
+	return v
+}
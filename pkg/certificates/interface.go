@@ -0,0 +1,13 @@
+package certificates
+
+import (
+	"context"
+
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Patcher is an interface that knows how to patch an object.
+type Patcher interface {
+	Patch(ctx context.Context, obj client.Object, opts ...patch.Option) error
+}
@@ -0,0 +1,154 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// RefreshProgress groups the machines of an orchestrated certificates refresh by their state.
+type RefreshProgress struct {
+	Pending    []*clusterv1.Machine
+	Refreshing []*clusterv1.Machine
+	Done       []*clusterv1.Machine
+	Failed     []*clusterv1.Machine
+}
+
+// GetRefreshProgress groups the machines by their certificates refresh state, sorted by name.
+// It expects the machine status annotations to be reset with ResetRefreshStatus
+// when the orchestrated refresh started.
+func GetRefreshProgress(machines []*clusterv1.Machine) RefreshProgress {
+	sorted := make([]*clusterv1.Machine, len(machines))
+	copy(sorted, machines)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	var progress RefreshProgress
+	for _, m := range sorted {
+		switch {
+		case IsMachineRefreshFailed(m):
+			progress.Failed = append(progress.Failed, m)
+		case IsMachineRefreshing(m):
+			progress.Refreshing = append(progress.Refreshing, m)
+		case m.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshDoneStatus:
+			progress.Done = append(progress.Done, m)
+		default:
+			progress.Pending = append(progress.Pending, m)
+		}
+	}
+	return progress
+}
+
+// GetRefreshInstructions returns the TTL of the certificates refresh requested on the object.
+func GetRefreshInstructions(obj client.Object) string {
+	return obj.GetAnnotations()[bootstrapv1.CertificatesRefreshAnnotation]
+}
+
+// IsRefreshInProgress checks if an orchestrated certificates refresh was started on the object.
+func IsRefreshInProgress(obj client.Object) bool {
+	return obj.GetAnnotations()[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshInProgressStatus
+}
+
+// GetMaxConcurrency returns how many machines can have their certificates refreshed at the same time.
+func GetMaxConcurrency(obj client.Object) (int, error) {
+	v, ok := obj.GetAnnotations()[bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation]
+	if !ok {
+		return 1, nil
+	}
+	concurrency, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max concurrency %q: %w", v, err)
+	}
+	if concurrency < 1 {
+		return 0, fmt.Errorf("invalid max concurrency %q: must be at least 1", v)
+	}
+	return concurrency, nil
+}
+
+// ResetRefreshStatus removes the status of a previous certificates refresh from the object.
+// Objects with a refresh in progress are left untouched.
+func ResetRefreshStatus(ctx context.Context, obj client.Object, c client.Client) error {
+	annotations := obj.GetAnnotations()
+	status, ok := annotations[bootstrapv1.CertificatesRefreshStatusAnnotation]
+	if !ok || status == bootstrapv1.CertificatesRefreshInProgressStatus {
+		return nil
+	}
+
+	patchHelper, err := patch.NewHelper(obj, c)
+	if err != nil {
+		return fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	delete(annotations, bootstrapv1.CertificatesRefreshStatusAnnotation)
+	obj.SetAnnotations(annotations)
+
+	if err := patchHelper.Patch(ctx, obj); err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+
+	return nil
+}
+
+// MarkObjectToRefresh annotates the object to refresh the certificates of its machines with the given TTL.
+func MarkObjectToRefresh(ctx context.Context, obj client.Object, ttl string, patcher Patcher) error {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	// clean up
+	delete(annotations, bootstrapv1.CertificatesRefreshStatusAnnotation)
+
+	annotations[bootstrapv1.CertificatesRefreshAnnotation] = ttl
+	obj.SetAnnotations(annotations)
+
+	if err := patcher.Patch(ctx, obj); err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+
+	return nil
+}
+
+// MarkRefreshInProgress annotates the object with certificates refresh in-progress.
+func MarkRefreshInProgress(ctx context.Context, obj client.Object, patcher Patcher) error {
+	return markRefreshStatus(ctx, obj, bootstrapv1.CertificatesRefreshInProgressStatus, patcher)
+}
+
+// MarkRefreshDone annotates the object with certificates refresh done and removes the refresh request.
+func MarkRefreshDone(ctx context.Context, obj client.Object, patcher Patcher) error {
+	return markRefreshStatus(ctx, obj, bootstrapv1.CertificatesRefreshDoneStatus, patcher)
+}
+
+// MarkRefreshFailed annotates the object with certificates refresh failed and removes the refresh request.
+func MarkRefreshFailed(ctx context.Context, obj client.Object, patcher Patcher) error {
+	return markRefreshStatus(ctx, obj, bootstrapv1.CertificatesRefreshFailedStatus, patcher)
+}
+
+func markRefreshStatus(ctx context.Context, obj client.Object, status string, patcher Patcher) error {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	if status != bootstrapv1.CertificatesRefreshInProgressStatus {
+		// clean up
+		delete(annotations, bootstrapv1.CertificatesRefreshAnnotation)
+	}
+
+	annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = status
+	obj.SetAnnotations(annotations)
+
+	if err := patcher.Patch(ctx, obj); err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package certificates_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+)
+
+func TestGetRefreshProgress(t *testing.T) {
+	g := NewWithT(t)
+
+	pending := newMachine("a-pending", nil)
+	refreshing := newMachine("b-refreshing", nil)
+	refreshing.Annotations[bootstrapv1.CertificatesRefreshAnnotation] = "1y"
+	done := newMachine("c-done", nil)
+	done.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshDoneStatus
+	failed := newMachine("d-failed", nil)
+	failed.Annotations[bootstrapv1.CertificatesRefreshAnnotation] = "1y"
+	failed.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshFailedStatus
+
+	progress := certificates.GetRefreshProgress([]*clusterv1.Machine{failed, done, refreshing, pending})
+	g.Expect(progress.Pending).To(ConsistOf(pending))
+	g.Expect(progress.Refreshing).To(ConsistOf(refreshing))
+	g.Expect(progress.Done).To(ConsistOf(done))
+	g.Expect(progress.Failed).To(ConsistOf(failed))
+}
+
+func TestGetMaxConcurrency(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		expected    int
+		expectErr   bool
+	}{
+		{
+			name:     "Default",
+			expected: 1,
+		},
+		{
+			name:        "Valid",
+			annotations: map[string]string{bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation: "3"},
+			expected:    3,
+		},
+		{
+			name:        "NotANumber",
+			annotations: map[string]string{bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation: "many"},
+			expectErr:   true,
+		},
+		{
+			name:        "Zero",
+			annotations: map[string]string{bootstrapv1.CertificatesRefreshMaxConcurrencyAnnotation: "0"},
+			expectErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			md := &clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			concurrency, err := certificates.GetMaxConcurrency(md)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(concurrency).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestMarkRefresh(t *testing.T) {
+	g := NewWithT(t)
+
+	md := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "md",
+			Namespace: "default",
+			Annotations: map[string]string{
+				bootstrapv1.CertificatesRefreshStatusAnnotation: bootstrapv1.CertificatesRefreshFailedStatus,
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(md.DeepCopy()).Build()
+
+	getAnnotations := func() map[string]string {
+		updated := &clusterv1.MachineDeployment{}
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(md), updated)).To(Succeed())
+		return updated.Annotations
+	}
+	newPatcher := func() certificates.Patcher {
+		patchHelper, err := patch.NewHelper(md, c)
+		g.Expect(err).ToNot(HaveOccurred())
+		return patchHelper
+	}
+
+	g.Expect(certificates.MarkObjectToRefresh(context.Background(), md, "1y", newPatcher())).To(Succeed())
+	g.Expect(getAnnotations()).To(HaveKeyWithValue(bootstrapv1.CertificatesRefreshAnnotation, "1y"))
+	g.Expect(getAnnotations()).ToNot(HaveKey(bootstrapv1.CertificatesRefreshStatusAnnotation))
+
+	g.Expect(certificates.MarkRefreshInProgress(context.Background(), md, newPatcher())).To(Succeed())
+	g.Expect(certificates.IsRefreshInProgress(md)).To(BeTrue())
+	g.Expect(getAnnotations()).To(HaveKeyWithValue(bootstrapv1.CertificatesRefreshAnnotation, "1y"))
+
+	g.Expect(certificates.MarkRefreshDone(context.Background(), md, newPatcher())).To(Succeed())
+	g.Expect(getAnnotations()).To(HaveKeyWithValue(bootstrapv1.CertificatesRefreshStatusAnnotation, bootstrapv1.CertificatesRefreshDoneStatus))
+	g.Expect(getAnnotations()).ToNot(HaveKey(bootstrapv1.CertificatesRefreshAnnotation))
+
+	g.Expect(certificates.ResetRefreshStatus(context.Background(), md, c)).To(Succeed())
+	g.Expect(getAnnotations()).ToNot(HaveKey(bootstrapv1.CertificatesRefreshStatusAnnotation))
+}
@@ -0,0 +1,94 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// GetExpiryDate returns the certificates expiry date of the machine.
+// It returns false if the machine has no valid expiry date annotation.
+func GetExpiryDate(m *clusterv1.Machine) (time.Time, bool) {
+	v, ok := m.Annotations[bootstrapv1.MachineCertificatesExpiryDateAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiry, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiry, true
+}
+
+// IsMachineRefreshing checks if the certificates of the machine are being refreshed.
+func IsMachineRefreshing(m *clusterv1.Machine) bool {
+	_, ok := m.Annotations[bootstrapv1.CertificatesRefreshAnnotation]
+	return ok && !IsMachineRefreshFailed(m)
+}
+
+// IsMachineRefreshFailed checks if the last certificates refresh of the machine failed.
+func IsMachineRefreshFailed(m *clusterv1.Machine) bool {
+	return m.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] == bootstrapv1.CertificatesRefreshFailedStatus
+}
+
+// RefreshRetryTime returns when the failed certificates refresh of the machine can be retried: after the given
+// delay since it failed. A refresh without a valid failure time can be retried right away.
+func RefreshRetryTime(m *clusterv1.Machine, delay time.Duration) time.Time {
+	failedAt, err := time.Parse(time.RFC3339, m.Annotations[bootstrapv1.CertificatesRefreshFailedAtAnnotation])
+	if err != nil {
+		return time.Time{}
+	}
+	return failedAt.Add(delay)
+}
+
+// MachineToRenew returns the machine whose certificates expire first, if they expire within the threshold.
+// Machines without a node or being deleted are ignored. It returns nil if no machine needs a renewal.
+func MachineToRenew(machines []*clusterv1.Machine, threshold time.Duration, now time.Time) *clusterv1.Machine {
+	var (
+		next       *clusterv1.Machine
+		nextExpiry time.Time
+	)
+	for _, m := range machines {
+		if m.Status.NodeRef == nil || !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+		expiry, ok := GetExpiryDate(m)
+		if !ok || expiry.Sub(now) > threshold {
+			continue
+		}
+		if next == nil || expiry.Before(nextExpiry) {
+			next, nextExpiry = m, expiry
+		}
+	}
+	return next
+}
+
+// MarkMachineToRefresh annotates the machine to refresh its certificates with the given TTL.
+func MarkMachineToRefresh(ctx context.Context, m *clusterv1.Machine, ttl string, c client.Client) error {
+	patchHelper, err := patch.NewHelper(m, c)
+	if err != nil {
+		return fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+
+	// clean up
+	delete(m.Annotations, bootstrapv1.CertificatesRefreshStatusAnnotation)
+	delete(m.Annotations, bootstrapv1.CertificatesRefreshFailedAtAnnotation)
+
+	m.Annotations[bootstrapv1.CertificatesRefreshAnnotation] = ttl
+
+	if err := patchHelper.Patch(ctx, m); err != nil {
+		return fmt.Errorf("failed to patch: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package certificates_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+)
+
+func newMachine(name string, expiry *time.Time) *clusterv1.Machine {
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{},
+		},
+		Status: clusterv1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: name},
+		},
+	}
+	if expiry != nil {
+		m.Annotations[bootstrapv1.MachineCertificatesExpiryDateAnnotation] = expiry.Format(time.RFC3339)
+	}
+	return m
+}
+
+func TestMachineToRenew(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	soon := now.Add(24 * time.Hour)
+	sooner := now.Add(12 * time.Hour)
+	later := now.Add(90 * 24 * time.Hour)
+	threshold := 30 * 24 * time.Hour
+
+	noNode := newMachine("no-node", &sooner)
+	noNode.Status.NodeRef = nil
+
+	for _, tc := range []struct {
+		name     string
+		machines []*clusterv1.Machine
+		expected string
+	}{
+		{
+			name:     "NoMachines",
+			machines: nil,
+		},
+		{
+			name:     "NotWithinThreshold",
+			machines: []*clusterv1.Machine{newMachine("m1", &later), newMachine("m2", nil)},
+		},
+		{
+			name:     "EarliestWithinThreshold",
+			machines: []*clusterv1.Machine{newMachine("m1", &later), newMachine("m2", &soon), newMachine("m3", &sooner)},
+			expected: "m3",
+		},
+		{
+			name:     "SkipsMachinesWithoutNode",
+			machines: []*clusterv1.Machine{noNode, newMachine("m2", &soon)},
+			expected: "m2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := certificates.MachineToRenew(tc.machines, threshold, now)
+			if tc.expected == "" {
+				g.Expect(m).To(BeNil())
+				return
+			}
+			g.Expect(m).ToNot(BeNil())
+			g.Expect(m.Name).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestIsMachineRefreshing(t *testing.T) {
+	g := NewWithT(t)
+
+	m := newMachine("m1", nil)
+	g.Expect(certificates.IsMachineRefreshing(m)).To(BeFalse())
+	g.Expect(certificates.IsMachineRefreshFailed(m)).To(BeFalse())
+
+	m.Annotations[bootstrapv1.CertificatesRefreshAnnotation] = "1y"
+	g.Expect(certificates.IsMachineRefreshing(m)).To(BeTrue())
+
+	m.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshFailedStatus
+	g.Expect(certificates.IsMachineRefreshing(m)).To(BeFalse())
+	g.Expect(certificates.IsMachineRefreshFailed(m)).To(BeTrue())
+}
+
+func TestMarkMachineToRefresh(t *testing.T) {
+	g := NewWithT(t)
+
+	m := newMachine("m1", nil)
+	m.Annotations[bootstrapv1.CertificatesRefreshStatusAnnotation] = bootstrapv1.CertificatesRefreshDoneStatus
+
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(m.DeepCopy()).Build()
+
+	g.Expect(certificates.MarkMachineToRefresh(context.Background(), m, "1y", c)).To(Succeed())
+
+	updated := &clusterv1.Machine{}
+	g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(m), updated)).To(Succeed())
+	g.Expect(updated.Annotations).To(HaveKeyWithValue(bootstrapv1.CertificatesRefreshAnnotation, "1y"))
+	g.Expect(updated.Annotations).ToNot(HaveKey(bootstrapv1.CertificatesRefreshStatusAnnotation))
+}
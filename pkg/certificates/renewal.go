@@ -0,0 +1,125 @@
+package certificates
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+	utiltime "github.com/canonical/cluster-api-k8s/pkg/time"
+)
+
+const (
+	// RenewalRequeueAfter is how long to wait before checking again if the certificates of the machines
+	// are about to expire.
+	RenewalRequeueAfter = 1 * time.Hour
+
+	// RenewalRetryAfter is how long to wait after a failed certificates refresh before the renewal of the
+	// machine is retried.
+	RenewalRetryAfter = 1 * time.Hour
+
+	// renewalInProgressRequeueAfter is how long to wait before checking again the machine whose
+	// certificates are being renewed.
+	renewalInProgressRequeueAfter = 5 * time.Second
+)
+
+// Renewal renews the certificates of the machines of an object, such as a CK8sControlPlane or a
+// MachineDeployment, before they expire.
+type Renewal struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+}
+
+// Reconcile renews the certificates of at most one of the machines at a time, within the maintenance windows,
+// according to the certificates renewal policy, and updates the CertificatesRenewal condition of the object.
+// A machine whose certificates refresh failed is renewed again RenewalRetryAfter after the failure, before any other.
+// The condition and its reasons are the same for the bootstrap and the control plane APIs.
+// It returns how long to wait before reconciling the renewal again.
+func (r *Renewal) Reconcile(ctx context.Context, log logr.Logger, obj conditions.Setter, machines []*clusterv1.Machine, policy *bootstrapv1.CertificatesRenewalPolicy, windows *maintenance.Windows) (time.Duration, error) {
+	// NOTE: the policy is validated here as well, since the webhooks can be disabled.
+	if errs := policy.Validate(field.NewPath("certificatesRenewal")); len(errs) > 0 {
+		return 0, fmt.Errorf("invalid certificates renewal policy: %w", errs.ToAggregate())
+	}
+	threshold, _ := utiltime.TTLToSeconds(policy.GetExpiryThreshold())
+	ttl := policy.GetTTL()
+
+	now := time.Now()
+	var failed *clusterv1.Machine
+	for _, m := range machines {
+		if IsMachineRefreshing(m) {
+			log.V(1).Info("Machine certificates are being refreshed, requeuing...", "machine", m.Name)
+			conditions.MarkFalse(obj, bootstrapv1.CertificatesRenewalCondition, bootstrapv1.CertificatesRenewalInProgressReason, clusterv1.ConditionSeverityInfo, "Renewing certificates of machine %s", m.Name)
+			return renewalInProgressRequeueAfter, nil
+		}
+		if failed == nil && IsMachineRefreshFailed(m) {
+			failed = m
+		}
+	}
+
+	m := failed
+	if failed != nil {
+		retryAt := RefreshRetryTime(failed, RenewalRetryAfter)
+		if now.Before(retryAt) {
+			log.Info("Certificates refresh failed for machine, halting renewal", "machine", failed.Name, "retryAt", retryAt.Format(time.RFC3339))
+			if !conditions.IsFalse(obj, bootstrapv1.CertificatesRenewalCondition) ||
+				conditions.GetReason(obj, bootstrapv1.CertificatesRenewalCondition) != bootstrapv1.CertificatesRenewalFailedReason {
+				r.Recorder.Eventf(
+					obj,
+					corev1.EventTypeWarning,
+					bootstrapv1.CertificatesRefreshFailedEvent,
+					"Certificates renewal failed for machine %q",
+					failed.Name,
+				)
+			}
+			conditions.MarkFalse(obj, bootstrapv1.CertificatesRenewalCondition, bootstrapv1.CertificatesRenewalFailedReason, clusterv1.ConditionSeverityWarning,
+				"Failed to renew certificates of machine %s, retrying at %s; remove the %s annotation of the machine to retry now",
+				failed.Name, retryAt.Format(time.RFC3339), bootstrapv1.CertificatesRefreshFailedAtAnnotation)
+			return retryAt.Sub(now), nil
+		}
+		log.Info("Retrying the failed certificates refresh of machine", "machine", failed.Name)
+		// Retry with the TTL of the failed refresh, which may have been requested manually.
+		if failedTTL := failed.Annotations[bootstrapv1.CertificatesRefreshAnnotation]; failedTTL != "" {
+			ttl = failedTTL
+		}
+	} else {
+		m = MachineToRenew(machines, time.Duration(threshold)*time.Second, now)
+		if m == nil {
+			conditions.MarkTrue(obj, bootstrapv1.CertificatesRenewalCondition)
+			return RenewalRequeueAfter, nil
+		}
+	}
+
+	// Renewing the certificates restarts the services of the machine, so it only starts within a maintenance window.
+	if allowed, requeueAfter := maintenance.Allow(obj, windows, now, fmt.Sprintf("the certificates renewal of machine %s", m.Name)); !allowed {
+		log.V(1).Info("Waiting for a maintenance window to renew machine certificates", "machine", m.Name)
+		return requeueAfter, nil
+	}
+
+	expiry, _ := GetExpiryDate(m)
+	if err := MarkMachineToRefresh(ctx, m, ttl, r.Client); err != nil {
+		return 0, fmt.Errorf("failed to mark machine to refresh certificates: %w", err)
+	}
+
+	log.Info("Machine certificates marked for renewal", "machine", m.Name, "expiry", expiry.Format(time.RFC3339))
+	r.Recorder.Eventf(
+		obj,
+		corev1.EventTypeNormal,
+		bootstrapv1.CertificatesRefreshInProgressEvent,
+		"Renewing certificates of machine %q expiring at %s. TTL: %s",
+		m.Name,
+		expiry.Format(time.RFC3339),
+		ttl,
+	)
+	conditions.MarkFalse(obj, bootstrapv1.CertificatesRenewalCondition, bootstrapv1.CertificatesRenewalInProgressReason, clusterv1.ConditionSeverityInfo, "Renewing certificates of machine %s", m.Name)
+
+	return renewalInProgressRequeueAfter, nil
+}
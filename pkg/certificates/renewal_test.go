@@ -0,0 +1,133 @@
+package certificates_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
+)
+
+func TestRenewalReconcile(t *testing.T) {
+	soon := time.Now().Add(24 * time.Hour)
+	later := time.Now().Add(90 * 24 * time.Hour)
+
+	for _, tc := range []struct {
+		name         string
+		machines     []*clusterv1.Machine
+		annotations  map[string]string
+		policy       *bootstrapv1.CertificatesRenewalPolicy
+		requeueAfter time.Duration
+		reason       string
+		renewed      string
+		expectErr    bool
+	}{
+		{
+			name:         "NotWithinThreshold",
+			machines:     []*clusterv1.Machine{newMachine("m1", &later)},
+			requeueAfter: certificates.RenewalRequeueAfter,
+		},
+		{
+			name:         "WithinThreshold",
+			machines:     []*clusterv1.Machine{newMachine("m1", &later), newMachine("m2", &soon)},
+			requeueAfter: 5 * time.Second,
+			reason:       bootstrapv1.CertificatesRenewalInProgressReason,
+			renewed:      "m2",
+		},
+		{
+			name:         "Refreshing",
+			machines:     []*clusterv1.Machine{newMachine("m1", &soon)},
+			annotations:  map[string]string{bootstrapv1.CertificatesRefreshAnnotation: "1y"},
+			requeueAfter: 5 * time.Second,
+			reason:       bootstrapv1.CertificatesRenewalInProgressReason,
+		},
+		{
+			name:     "RefreshFailed",
+			machines: []*clusterv1.Machine{newMachine("m1", &soon), newMachine("m2", &soon)},
+			annotations: map[string]string{
+				bootstrapv1.CertificatesRefreshAnnotation:         "1y",
+				bootstrapv1.CertificatesRefreshStatusAnnotation:   bootstrapv1.CertificatesRefreshFailedStatus,
+				bootstrapv1.CertificatesRefreshFailedAtAnnotation: time.Now().Format(time.RFC3339),
+			},
+			requeueAfter: certificates.RenewalRetryAfter,
+			reason:       bootstrapv1.CertificatesRenewalFailedReason,
+		},
+		{
+			name:     "RefreshFailedRetry",
+			machines: []*clusterv1.Machine{newMachine("m1", &later), newMachine("m2", &soon)},
+			annotations: map[string]string{
+				bootstrapv1.CertificatesRefreshAnnotation:         bootstrapv1.DefaultCertificatesRenewalTTL,
+				bootstrapv1.CertificatesRefreshStatusAnnotation:   bootstrapv1.CertificatesRefreshFailedStatus,
+				bootstrapv1.CertificatesRefreshFailedAtAnnotation: time.Now().Add(-certificates.RenewalRetryAfter).Format(time.RFC3339),
+			},
+			requeueAfter: 5 * time.Second,
+			reason:       bootstrapv1.CertificatesRenewalInProgressReason,
+			renewed:      "m1",
+		},
+		{
+			name:      "ThresholdNotBelowTTL",
+			machines:  []*clusterv1.Machine{newMachine("m1", &soon)},
+			policy:    &bootstrapv1.CertificatesRenewalPolicy{ExpiryThreshold: "1y", TTL: "6mo"},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			for k, v := range tc.annotations {
+				tc.machines[0].Annotations[k] = v
+			}
+			objs := make([]client.Object, 0, len(tc.machines))
+			for _, m := range tc.machines {
+				objs = append(objs, m.DeepCopy())
+			}
+
+			scheme := runtime.NewScheme()
+			g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			policy := tc.policy
+			if policy == nil {
+				policy = &bootstrapv1.CertificatesRenewalPolicy{}
+			}
+			md := &clusterv1.MachineDeployment{}
+			r := &certificates.Renewal{Client: c, Recorder: record.NewFakeRecorder(10)}
+
+			requeueAfter, err := r.Reconcile(context.Background(), logr.Discard(), md, tc.machines, policy, nil)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(requeueAfter).To(BeNumerically("~", tc.requeueAfter, time.Minute))
+
+			if tc.reason == "" {
+				g.Expect(conditions.IsTrue(md, bootstrapv1.CertificatesRenewalCondition)).To(BeTrue())
+			} else {
+				g.Expect(conditions.GetReason(md, bootstrapv1.CertificatesRenewalCondition)).To(Equal(tc.reason))
+			}
+
+			for _, m := range tc.machines {
+				updated := &clusterv1.Machine{}
+				g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(m), updated)).To(Succeed())
+				if m.Name == tc.renewed {
+					g.Expect(updated.Annotations).To(HaveKeyWithValue(bootstrapv1.CertificatesRefreshAnnotation, bootstrapv1.DefaultCertificatesRenewalTTL))
+					g.Expect(updated.Annotations).ToNot(HaveKey(bootstrapv1.CertificatesRefreshStatusAnnotation))
+					g.Expect(updated.Annotations).ToNot(HaveKey(bootstrapv1.CertificatesRefreshFailedAtAnnotation))
+				} else {
+					g.Expect(updated.Annotations).To(Equal(m.Annotations))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ck8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// ErrAuditPolicyNotFound is returned when the audit policy ConfigMap or its key does not exist.
+var ErrAuditPolicyNotFound = errors.New("audit policy not found")
+
+// AuditKubeAPIServerArgs returns the kube-apiserver arguments configuring the audit logging.
+func AuditKubeAPIServerArgs(audit *bootstrapv1.AuditConfig) map[string]*string {
+	args := map[string]*string{
+		"--audit-policy-file": ptr.To(bootstrapv1.AuditPolicyFilePath),
+		"--audit-log-path":    ptr.To(audit.GetLogPath()),
+	}
+	if v := audit.MaxAge; v != nil {
+		args["--audit-log-maxage"] = ptr.To(strconv.Itoa(*v))
+	}
+	if v := audit.MaxBackup; v != nil {
+		args["--audit-log-maxbackup"] = ptr.To(strconv.Itoa(*v))
+	}
+	if v := audit.MaxSize; v != nil {
+		args["--audit-log-maxsize"] = ptr.To(strconv.Itoa(*v))
+	}
+	if audit.Webhook != nil {
+		args["--audit-webhook-config-file"] = ptr.To(bootstrapv1.AuditWebhookConfigFilePath)
+		if v := audit.Webhook.Mode; v != "" {
+			args["--audit-webhook-mode"] = ptr.To(v)
+		}
+	}
+	return args
+}
+
+// AuditPolicyHash returns the hash of an audit policy, used to detect control plane nodes running an outdated policy.
+func AuditPolicyHash(policy string) string {
+	sum := sha256.Sum256([]byte(policy))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GetAuditPolicy returns the audit policy held by the ConfigMap referenced by the audit configuration.
+func GetAuditPolicy(ctx context.Context, c client.Client, namespace string, audit *bootstrapv1.AuditConfig) (string, error) {
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: namespace, Name: audit.PolicyConfigMapRef.Name}
+	if err := c.Get(ctx, key, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("ConfigMap %q: %w", key, ErrAuditPolicyNotFound)
+		}
+		return "", fmt.Errorf("failed to get audit policy ConfigMap %q: %w", key, err)
+	}
+	policy, ok := cm.Data[audit.GetPolicyKey()]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap %q has no key %q: %w", key, audit.GetPolicyKey(), ErrAuditPolicyNotFound)
+	}
+	return policy, nil
+}
@@ -0,0 +1,60 @@
+package ck8s
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+func TestAuditKubeAPIServerArgs(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		g := NewWithT(t)
+
+		audit := &bootstrapv1.AuditConfig{PolicyConfigMapRef: bootstrapv1.ConfigMapRef{Name: "audit-policy"}}
+		g.Expect(AuditKubeAPIServerArgs(audit)).To(Equal(map[string]*string{
+			"--audit-policy-file": ptr.To(bootstrapv1.AuditPolicyFilePath),
+			"--audit-log-path":    ptr.To(bootstrapv1.DefaultAuditLogPath),
+		}))
+	})
+
+	t.Run("WithRotationAndWebhook", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cfg := bootstrapv1.CK8sControlPlaneConfig{
+			ExtraKubeAPIServerArgs: map[string]*string{"--anonymous-auth": ptr.To("true")},
+			Audit: &bootstrapv1.AuditConfig{
+				PolicyConfigMapRef: bootstrapv1.ConfigMapRef{Name: "audit-policy"},
+				LogPath:            "-",
+				MaxAge:             ptr.To(30),
+				MaxBackup:          ptr.To(0),
+				MaxSize:            ptr.To(100),
+				Webhook: &bootstrapv1.AuditWebhookConfig{
+					ConfigSecretRef: bootstrapv1.SecretRef{Name: "audit-webhook"},
+					Mode:            "blocking",
+				},
+			},
+		}
+
+		g.Expect(kubeAPIServerArgs(cfg)).To(Equal(map[string]*string{
+			"--anonymous-auth":            ptr.To("true"),
+			"--audit-policy-file":         ptr.To(bootstrapv1.AuditPolicyFilePath),
+			"--audit-log-path":            ptr.To("-"),
+			"--audit-log-maxage":          ptr.To("30"),
+			"--audit-log-maxbackup":       ptr.To("0"),
+			"--audit-log-maxsize":         ptr.To("100"),
+			"--audit-webhook-config-file": ptr.To(bootstrapv1.AuditWebhookConfigFilePath),
+			"--audit-webhook-mode":        ptr.To("blocking"),
+		}))
+		g.Expect(cfg.ExtraKubeAPIServerArgs).To(HaveLen(1))
+	})
+}
+
+func TestAuditPolicyHash(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(AuditPolicyHash("rules:\n- level: Metadata\n")).To(Equal(AuditPolicyHash("rules:\n- level: Metadata\n")))
+	g.Expect(AuditPolicyHash("rules:\n- level: Metadata\n")).ToNot(Equal(AuditPolicyHash("rules:\n- level: None\n")))
+}
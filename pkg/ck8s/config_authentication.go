@@ -0,0 +1,63 @@
+package ck8s
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"k8s.io/utils/ptr"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// kubeAPIServerArgs returns the extra kube-apiserver arguments of a control plane node,
+// including the ones mapped from the typed authentication, audit and encryption configuration.
+func kubeAPIServerArgs(cfg bootstrapv1.CK8sControlPlaneConfig) map[string]*string {
+	oidc := cfg.GetOIDC()
+	if oidc == nil && cfg.Audit == nil && cfg.EncryptionAtRest == nil {
+		return cfg.ExtraKubeAPIServerArgs
+	}
+
+	args := make(map[string]*string, len(cfg.ExtraKubeAPIServerArgs))
+	maps.Copy(args, cfg.ExtraKubeAPIServerArgs)
+	if oidc != nil {
+		maps.Copy(args, OIDCKubeAPIServerArgs(oidc))
+	}
+	if cfg.Audit != nil {
+		maps.Copy(args, AuditKubeAPIServerArgs(cfg.Audit))
+	}
+	if cfg.EncryptionAtRest != nil {
+		maps.Copy(args, EncryptionKubeAPIServerArgs())
+	}
+	return args
+}
+
+// OIDCKubeAPIServerArgs returns the kube-apiserver arguments configuring the authentication with an OIDC provider.
+func OIDCKubeAPIServerArgs(oidc *bootstrapv1.OIDCConfig) map[string]*string {
+	args := map[string]*string{
+		"--oidc-issuer-url": ptr.To(oidc.IssuerURL),
+		"--oidc-client-id":  ptr.To(oidc.ClientID),
+	}
+	if v := oidc.UsernameClaim; v != "" {
+		args["--oidc-username-claim"] = ptr.To(v)
+	}
+	if v := oidc.UsernamePrefix; v != "" {
+		args["--oidc-username-prefix"] = ptr.To(v)
+	}
+	if v := oidc.GroupsClaim; v != "" {
+		args["--oidc-groups-claim"] = ptr.To(v)
+	}
+	if v := oidc.GroupsPrefix; v != "" {
+		args["--oidc-groups-prefix"] = ptr.To(v)
+	}
+	if c := oidc.RequiredClaim; c != nil {
+		args["--oidc-required-claim"] = ptr.To(fmt.Sprintf("%s=%s", c.Claim, c.Value))
+	}
+	if len(oidc.SigningAlgorithms) > 0 {
+		args["--oidc-signing-algs"] = ptr.To(strings.Join(oidc.SigningAlgorithms, ","))
+	}
+	if oidc.CASecretRef != nil {
+		args["--oidc-ca-file"] = ptr.To(bootstrapv1.OIDCCAFilePath)
+	}
+	return args
+}
@@ -0,0 +1,56 @@
+package ck8s
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/utils/ptr"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+func TestKubeAPIServerArgs(t *testing.T) {
+	t.Run("WithoutOIDC", func(t *testing.T) {
+		g := NewWithT(t)
+
+		extra := map[string]*string{"--anonymous-auth": ptr.To("true")}
+		g.Expect(kubeAPIServerArgs(bootstrapv1.CK8sControlPlaneConfig{ExtraKubeAPIServerArgs: extra})).To(Equal(extra))
+	})
+
+	t.Run("WithOIDC", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cfg := bootstrapv1.CK8sControlPlaneConfig{
+			ExtraKubeAPIServerArgs: map[string]*string{"--anonymous-auth": ptr.To("true")},
+			Authentication: &bootstrapv1.AuthenticationConfig{
+				OIDC: &bootstrapv1.OIDCConfig{
+					IssuerURL:         "https://issuer.example.com",
+					ClientID:          "kubernetes",
+					UsernameClaim:     "email",
+					GroupsClaim:       "groups",
+					GroupsPrefix:      "oidc:",
+					RequiredClaim:     &bootstrapv1.OIDCRequiredClaim{Claim: "hd", Value: "example.com"},
+					SigningAlgorithms: []string{"RS256", "ES256"},
+					CASecretRef:       &bootstrapv1.SecretRef{Name: "oidc-ca"},
+				},
+			},
+		}
+
+		g.Expect(kubeAPIServerArgs(cfg)).To(Equal(map[string]*string{
+			"--anonymous-auth":      ptr.To("true"),
+			"--oidc-issuer-url":     ptr.To("https://issuer.example.com"),
+			"--oidc-client-id":      ptr.To("kubernetes"),
+			"--oidc-username-claim": ptr.To("email"),
+			"--oidc-groups-claim":   ptr.To("groups"),
+			"--oidc-groups-prefix":  ptr.To("oidc:"),
+			"--oidc-required-claim": ptr.To("hd=example.com"),
+			"--oidc-signing-algs":   ptr.To("RS256,ES256"),
+			"--oidc-ca-file":        ptr.To(bootstrapv1.OIDCCAFilePath),
+		}))
+		// the extra arguments of the spec are left untouched.
+		g.Expect(cfg.ExtraKubeAPIServerArgs).To(HaveLen(1))
+
+		join := GenerateJoinControlPlaneConfig(JoinControlPlaneConfig{ControlPlaneConfig: cfg})
+		g.Expect(join.ExtraNodeKubeAPIServerArgs).To(HaveKeyWithValue("--oidc-issuer-url", ptr.To("https://issuer.example.com")))
+	})
+}
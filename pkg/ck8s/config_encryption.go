@@ -0,0 +1,54 @@
+package ck8s
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/secret"
+)
+
+// EncryptionKubeAPIServerArgs returns the kube-apiserver arguments configuring the encryption of secrets at rest.
+func EncryptionKubeAPIServerArgs() map[string]*string {
+	return map[string]*string{
+		"--encryption-provider-config": ptr.To(bootstrapv1.EncryptionConfigFilePath),
+	}
+}
+
+// GenerateEncryptionConfig renders the EncryptionConfiguration of kube-apiserver.
+// Secrets are written with the configured provider. The key is accepted for reading with both AES providers,
+// so that secrets remain readable when the provider is changed.
+// Secrets stored before the encryption was enabled are read with the identity provider.
+func GenerateEncryptionConfig(cfg *bootstrapv1.EncryptionAtRestConfig, key []byte) ([]byte, error) {
+	aes := &apiserverv1.AESConfiguration{
+		Keys: []apiserverv1.Key{{
+			Name:   secret.EncryptionKeyName(key),
+			Secret: base64.StdEncoding.EncodeToString(key),
+		}},
+	}
+
+	providers := []apiserverv1.ProviderConfiguration{{AESCBC: aes}, {AESGCM: aes}}
+	if cfg.GetProvider() == bootstrapv1.EncryptionProviderAESGCM {
+		providers[0], providers[1] = providers[1], providers[0]
+	}
+	providers = append(providers, apiserverv1.ProviderConfiguration{Identity: &apiserverv1.IdentityConfiguration{}})
+
+	config := apiserverv1.EncryptionConfiguration{
+		Resources: []apiserverv1.ResourceConfiguration{{
+			Resources: []string{"secrets"},
+			Providers: providers,
+		}},
+	}
+	config.APIVersion = apiserverv1.SchemeGroupVersion.String()
+	config.Kind = "EncryptionConfiguration"
+
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal encryption configuration: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,46 @@
+package ck8s
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	"sigs.k8s.io/yaml"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/secret"
+)
+
+func TestGenerateEncryptionConfig(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	for _, tc := range []struct {
+		provider      string
+		expectPrimary func(apiserverv1.ProviderConfiguration) *apiserverv1.AESConfiguration
+	}{
+		{provider: "", expectPrimary: func(p apiserverv1.ProviderConfiguration) *apiserverv1.AESConfiguration { return p.AESCBC }},
+		{provider: bootstrapv1.EncryptionProviderAESGCM, expectPrimary: func(p apiserverv1.ProviderConfiguration) *apiserverv1.AESConfiguration { return p.AESGCM }},
+	} {
+		t.Run(tc.provider, func(t *testing.T) {
+			g := NewWithT(t)
+
+			b, err := GenerateEncryptionConfig(&bootstrapv1.EncryptionAtRestConfig{Provider: tc.provider}, key)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			config := &apiserverv1.EncryptionConfiguration{}
+			g.Expect(yaml.Unmarshal(b, config)).To(Succeed())
+			g.Expect(config.Kind).To(Equal("EncryptionConfiguration"))
+			g.Expect(config.Resources).To(HaveLen(1))
+			g.Expect(config.Resources[0].Resources).To(Equal([]string{"secrets"}))
+
+			providers := config.Resources[0].Providers
+			g.Expect(providers).To(HaveLen(3))
+			primary := tc.expectPrimary(providers[0])
+			g.Expect(primary).NotTo(BeNil())
+			g.Expect(primary.Keys).To(HaveLen(1))
+			g.Expect(primary.Keys[0].Name).To(Equal(secret.EncryptionKeyName(key)))
+			g.Expect(primary.Keys[0].Secret).To(Equal("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="))
+			g.Expect(providers[2].Identity).NotTo(BeNil())
+		})
+	}
+}
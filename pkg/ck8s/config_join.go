@@ -19,7 +19,7 @@ func GenerateJoinControlPlaneConfig(cfg JoinControlPlaneConfig) apiv1.ControlPla
 	return apiv1.ControlPlaneJoinConfig{
 		ExtraSANS: append(cfg.ControlPlaneConfig.ExtraSANs, cfg.ControlPlaneEndpoint),
 
-		ExtraNodeKubeAPIServerArgs:         cfg.ControlPlaneConfig.ExtraKubeAPIServerArgs,
+		ExtraNodeKubeAPIServerArgs:         kubeAPIServerArgs(cfg.ControlPlaneConfig),
 		ExtraNodeKubeControllerManagerArgs: cfg.ControlPlaneConfig.ExtraKubeControllerManagerArgs,
 		ExtraNodeKubeSchedulerArgs:         cfg.ControlPlaneConfig.ExtraKubeSchedulerArgs,
 
@@ -20,13 +20,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/apiserver/pkg/storage/names"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/controllers/external"
 	"sigs.k8s.io/cluster-api/util/collections"
@@ -36,7 +39,9 @@ import (
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/certificates"
 	"github.com/canonical/cluster-api-k8s/pkg/machinefilters"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
 )
 
 var (
@@ -60,6 +65,9 @@ type ControlPlane struct {
 	// See discussion on https://github.com/kubernetes-sigs/cluster-api/pull/3405
 	ck8sConfigs    map[string]*bootstrapv1.CK8sConfig
 	infraResources map[string]*unstructured.Unstructured
+
+	// auditPolicyHash is the hash of the current audit policy, or empty if audit logging is not configured.
+	auditPolicyHash string
 }
 
 // NewControlPlane returns an instantiated ControlPlane.
@@ -72,6 +80,10 @@ func NewControlPlane(ctx context.Context, client client.Client, cluster *cluster
 	if err != nil {
 		return nil, err
 	}
+	auditPolicyHash, err := getAuditPolicyHash(ctx, client, kcp)
+	if err != nil {
+		return nil, err
+	}
 	patchHelpers := map[string]*patch.Helper{}
 	for _, machine := range ownedMachines {
 		patchHelper, err := patch.NewHelper(machine, client)
@@ -88,6 +100,7 @@ func NewControlPlane(ctx context.Context, client client.Client, cluster *cluster
 		machinesPatchHelpers: patchHelpers,
 		ck8sConfigs:          ck8sConfigs,
 		infraResources:       infraObjects,
+		auditPolicyHash:      auditPolicyHash,
 		reconciliationTime:   metav1.Now(),
 	}, nil
 }
@@ -268,6 +281,8 @@ func (c *ControlPlane) MachinesNeedingRollout() collections.Machines {
 		collections.ShouldRolloutAfter(&c.reconciliationTime, c.KCP.Spec.RolloutAfter),
 		// Machines that do not match with KCP config.
 		collections.Not(machinefilters.MatchesKCPConfiguration(c.infraResources, c.ck8sConfigs, c.KCP)),
+		// Machines that were bootstrapped with an outdated audit policy.
+		collections.Not(machinefilters.MatchesAuditPolicyHash(c.ck8sConfigs, c.auditPolicyHash)),
 	)
 }
 
@@ -313,6 +328,23 @@ func getCK8sConfigs(ctx context.Context, cl client.Client, machines collections.
 	return result, nil
 }
 
+// getAuditPolicyHash returns the hash of the audit policy of the control plane, or empty if audit logging is not configured.
+func getAuditPolicyHash(ctx context.Context, cl client.Client, kcp *controlplanev1.CK8sControlPlane) (string, error) {
+	audit := kcp.Spec.CK8sConfigSpec.ControlPlaneConfig.Audit
+	if audit == nil {
+		return "", nil
+	}
+	policy, err := GetAuditPolicy(ctx, cl, kcp.Namespace, audit)
+	if err != nil {
+		if errors.Is(err, ErrAuditPolicyNotFound) {
+			// New machines cannot bootstrap without a policy, so do not roll out the existing ones.
+			return "", nil
+		}
+		return "", err
+	}
+	return AuditPolicyHash(policy), nil
+}
+
 // IsEtcdManaged returns true if the control plane relies on a managed etcd.
 func (c *ControlPlane) IsEtcdManaged() bool {
 	return c.KCP.Spec.CK8sConfigSpec.IsEtcdManaged()
@@ -350,3 +382,135 @@ func (c *ControlPlane) PatchMachines(ctx context.Context) error {
 
 	return kerrors.NewAggregate(errList)
 }
+
+// CertificatesExpiryDate returns the earliest certificates expiry date across the control plane machines.
+// Machines without a valid expiry date annotation are ignored.
+func (c *ControlPlane) CertificatesExpiryDate() *metav1.Time {
+	var earliest *metav1.Time
+	for _, m := range c.Machines {
+		expiry := machineCertificatesExpiryDate(m)
+		if expiry == nil {
+			continue
+		}
+		if earliest == nil || expiry.Before(earliest) {
+			earliest = expiry
+		}
+	}
+	return earliest
+}
+
+// VersionReplicas returns the number of control plane machines running each Kubernetes version, sorted by version.
+func (c *ControlPlane) VersionReplicas() []controlplanev1.VersionReplicas {
+	counts := map[string]int32{}
+	for _, m := range c.Machines {
+		if m.Spec.Version == nil {
+			continue
+		}
+		counts[*m.Spec.Version]++
+	}
+
+	result := make([]controlplanev1.VersionReplicas, 0, len(counts))
+	for v, replicas := range counts {
+		result = append(result, controlplanev1.VersionReplicas{Version: v, Replicas: replicas})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return versionLess(result[i].Version, result[j].Version)
+	})
+	return result
+}
+
+// versionLess orders Kubernetes versions semantically, so that v1.30.9 comes before v1.30.10.
+// Versions that cannot be parsed come last, in lexical order.
+func versionLess(a, b string) bool {
+	va, errA := version.ParseGeneric(a)
+	vb, errB := version.ParseGeneric(b)
+	switch {
+	case errA == nil && errB == nil:
+		if va.EqualTo(vb) {
+			return a < b
+		}
+		return va.LessThan(vb)
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	default:
+		return a < b
+	}
+}
+
+// InPlaceUpgradeStatus summarizes the in-place upgrade of the control plane machines.
+// It returns nil if no in-place upgrade was requested on the CK8sControlPlane.
+func (c *ControlPlane) InPlaceUpgradeStatus() *controlplanev1.InPlaceUpgradeStatus {
+	release := inplace.GetUpgradeInstructions(c.KCP)
+	if release == "" {
+		return nil
+	}
+
+	status := &controlplanev1.InPlaceUpgradeStatus{
+		Release: release,
+		State:   c.KCP.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation],
+	}
+	for _, m := range c.Machines {
+		switch {
+		case m.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeFailedStatus:
+			status.Failed++
+		case inplace.IsMachineUpgrading(m):
+			status.InProgress++
+		case inplace.IsUpgraded(m, release):
+			status.Done++
+		default:
+			status.Pending++
+		}
+	}
+	return status
+}
+
+// MachineStatuses returns per-machine details of the control plane machines, sorted by name.
+func (c *ControlPlane) MachineStatuses() []controlplanev1.MachineStatus {
+	result := make([]controlplanev1.MachineStatus, 0, len(c.Machines))
+	for _, m := range c.Machines {
+		result = append(result, controlplanev1.MachineStatus{
+			Name:                   m.Name,
+			Version:                ptr.Deref(m.Spec.Version, ""),
+			SnapSource:             c.snapSource(m),
+			CertificatesExpiryDate: machineCertificatesExpiryDate(m),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// snapSource returns the snap source of the machine: the release its last successful in-place upgrade refreshed
+// to, or else the release it was bootstrapped with.
+func (c *ControlPlane) snapSource(m *clusterv1.Machine) string {
+	if release, ok := m.Annotations[bootstrapv1.InPlaceUpgradeRefreshedToAnnotation]; ok {
+		return release
+	}
+
+	config, ok := c.ck8sConfigs[m.Name]
+	if !ok {
+		return ""
+	}
+	switch {
+	case config.Spec.Channel != "":
+		return "channel=" + config.Spec.Channel
+	case config.Spec.Revision != "":
+		return "revision=" + config.Spec.Revision
+	case config.Spec.LocalPath != "":
+		return "localPath=" + config.Spec.LocalPath
+	default:
+		return ""
+	}
+}
+
+// machineCertificatesExpiryDate returns the certificates expiry date of the machine, if known.
+func machineCertificatesExpiryDate(m *clusterv1.Machine) *metav1.Time {
+	expiry, ok := certificates.GetExpiryDate(m)
+	if !ok {
+		return nil
+	}
+	return &metav1.Time{Time: expiry}
+}
@@ -0,0 +1,159 @@
+package ck8s
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+)
+
+func newTestMachine(name, version string, annotations map[string]string) *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: clusterv1.MachineSpec{
+			Version: ptr.To(version),
+		},
+	}
+}
+
+func TestControlPlaneCertificatesExpiryDate(t *testing.T) {
+	g := NewWithT(t)
+
+	earliest := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &ControlPlane{
+		KCP: &controlplanev1.CK8sControlPlane{},
+		Machines: collections.FromMachines(
+			newTestMachine("m1", "v1.30.0", map[string]string{
+				bootstrapv1.MachineCertificatesExpiryDateAnnotation: earliest.Add(time.Hour).Format(time.RFC3339),
+			}),
+			newTestMachine("m2", "v1.30.0", map[string]string{
+				bootstrapv1.MachineCertificatesExpiryDateAnnotation: earliest.Format(time.RFC3339),
+			}),
+			newTestMachine("m3", "v1.30.0", map[string]string{
+				bootstrapv1.MachineCertificatesExpiryDateAnnotation: "invalid",
+			}),
+			newTestMachine("m4", "v1.30.0", nil),
+		),
+	}
+
+	expiry := c.CertificatesExpiryDate()
+	g.Expect(expiry).ToNot(BeNil())
+	g.Expect(expiry.Time.Equal(earliest)).To(BeTrue())
+
+	c.Machines = collections.FromMachines(newTestMachine("m4", "v1.30.0", nil))
+	g.Expect(c.CertificatesExpiryDate()).To(BeNil())
+}
+
+func TestControlPlaneVersionReplicas(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &ControlPlane{
+		KCP: &controlplanev1.CK8sControlPlane{},
+		Machines: collections.FromMachines(
+			newTestMachine("m1", "v1.31.0", nil),
+			newTestMachine("m2", "v1.30.10", nil),
+			newTestMachine("m3", "v1.31.0", nil),
+			newTestMachine("m4", "v1.30.9", nil),
+		),
+	}
+
+	g.Expect(c.VersionReplicas()).To(Equal([]controlplanev1.VersionReplicas{
+		{Version: "v1.30.9", Replicas: 1},
+		{Version: "v1.30.10", Replicas: 1},
+		{Version: "v1.31.0", Replicas: 2},
+	}))
+}
+
+func TestControlPlaneInPlaceUpgradeStatus(t *testing.T) {
+	t.Run("NoUpgrade", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := &ControlPlane{
+			KCP:      &controlplanev1.CK8sControlPlane{},
+			Machines: collections.FromMachines(newTestMachine("m1", "v1.30.0", nil)),
+		}
+		g.Expect(c.InPlaceUpgradeStatus()).To(BeNil())
+	})
+
+	t.Run("UpgradeInProgress", func(t *testing.T) {
+		g := NewWithT(t)
+
+		release := "channel=1.31-classic/stable"
+		c := &ControlPlane{
+			KCP: &controlplanev1.CK8sControlPlane{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						bootstrapv1.InPlaceUpgradeToAnnotation:     release,
+						bootstrapv1.InPlaceUpgradeStatusAnnotation: bootstrapv1.InPlaceUpgradeInProgressStatus,
+					},
+				},
+			},
+			Machines: collections.FromMachines(
+				newTestMachine("done", "v1.30.0", map[string]string{
+					bootstrapv1.InPlaceUpgradeReleaseAnnotation: release,
+					bootstrapv1.InPlaceUpgradeStatusAnnotation:  bootstrapv1.InPlaceUpgradeDoneStatus,
+				}),
+				newTestMachine("in-progress", "v1.30.0", map[string]string{
+					bootstrapv1.InPlaceUpgradeToAnnotation: release,
+				}),
+				newTestMachine("failed", "v1.30.0", map[string]string{
+					bootstrapv1.InPlaceUpgradeToAnnotation:     release,
+					bootstrapv1.InPlaceUpgradeStatusAnnotation: bootstrapv1.InPlaceUpgradeFailedStatus,
+				}),
+				newTestMachine("pending", "v1.30.0", nil),
+			),
+		}
+
+		g.Expect(c.InPlaceUpgradeStatus()).To(Equal(&controlplanev1.InPlaceUpgradeStatus{
+			Release:    release,
+			State:      bootstrapv1.InPlaceUpgradeInProgressStatus,
+			Pending:    1,
+			InProgress: 1,
+			Done:       1,
+			Failed:     1,
+		}))
+	})
+}
+
+func TestControlPlaneMachineStatuses(t *testing.T) {
+	g := NewWithT(t)
+
+	expiry := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := &ControlPlane{
+		KCP: &controlplanev1.CK8sControlPlane{},
+		Machines: collections.FromMachines(
+			newTestMachine("m2", "v1.31.0", map[string]string{
+				bootstrapv1.InPlaceUpgradeReleaseAnnotation:         "version=v1.31.0",
+				bootstrapv1.InPlaceUpgradeRefreshedToAnnotation:     "revision=1234",
+				bootstrapv1.MachineCertificatesExpiryDateAnnotation: expiry.Format(time.RFC3339),
+			}),
+			newTestMachine("m1", "v1.30.0", nil),
+			newTestMachine("m3", "v1.30.0", map[string]string{
+				bootstrapv1.InPlaceUpgradeToAnnotation: "channel=1.31-classic/stable",
+			}),
+		),
+		ck8sConfigs: map[string]*bootstrapv1.CK8sConfig{
+			"m3": {Spec: bootstrapv1.CK8sConfigSpec{Channel: "1.30-classic/stable"}},
+		},
+	}
+
+	statuses := c.MachineStatuses()
+	g.Expect(statuses).To(HaveLen(3))
+	g.Expect(statuses[0]).To(Equal(controlplanev1.MachineStatus{Name: "m1", Version: "v1.30.0"}))
+	g.Expect(statuses[1].Name).To(Equal("m2"))
+	g.Expect(statuses[1].Version).To(Equal("v1.31.0"))
+	g.Expect(statuses[1].SnapSource).To(Equal("revision=1234"))
+	g.Expect(statuses[1].CertificatesExpiryDate.Time.Equal(expiry)).To(BeTrue())
+	// The requested release is not refreshed to yet, the machine still reports the release it was bootstrapped with.
+	g.Expect(statuses[2]).To(Equal(controlplanev1.MachineStatus{Name: "m3", Version: "v1.30.0", SnapSource: "channel=1.30-classic/stable"}))
+}
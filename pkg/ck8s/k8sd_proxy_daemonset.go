@@ -2,27 +2,162 @@ package ck8s
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 )
 
 var (
 	//go:embed manifests/k8sd-proxy-template.yaml
 	k8sdProxyDaemonSetYaml string
 
-	k8sdProxyDaemonSetTemplate *template.Template = template.Must(template.New("K8sdProxyDaemonset").Parse(k8sdProxyDaemonSetYaml))
+	k8sdProxyDaemonSetTemplate *template.Template = template.Must(template.New("K8sdProxyDaemonset").Funcs(template.FuncMap{
+		"toJson": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(k8sdProxyDaemonSetYaml))
 )
 
+// DefaultK8sdProxyImage is the image of the k8sd-proxy daemonset if none is configured. It is set at build time
+// to the k8sd-proxy image released along with the providers.
+var DefaultK8sdProxyImage = ""
+
+// defaultK8sdProxyTolerations are the tolerations of the k8sd-proxy pods if none are configured.
+var defaultK8sdProxyTolerations = []corev1.Toleration{
+	{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: "node-role.kubernetes.io/master", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// defaultK8sdProxySecurityContext is the security context of the k8sd-proxy container if none is configured.
+var defaultK8sdProxySecurityContext = corev1.SecurityContext{
+	RunAsNonRoot:             ptr.To(true),
+	RunAsUser:                ptr.To(int64(65532)),
+	SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	ReadOnlyRootFilesystem:   ptr.To(true),
+	AllowPrivilegeEscalation: ptr.To(false),
+	Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+}
+
 type K8sdProxyDaemonSetInput struct {
 	K8sdPort int
+	// Config configures the k8sd-proxy daemonset. Defaults are used for the unset fields.
+	Config *bootstrapv1.K8sdProxyConfig
+}
+
+// k8sdProxyTemplateData is the data the k8sd-proxy template is rendered with.
+type k8sdProxyTemplateData struct {
+	K8sdPort          int
+	Image             string
+	ImagePullSecrets  []corev1.LocalObjectReference
+	Tolerations       []corev1.Toleration
+	Resources         *corev1.ResourceRequirements
+	PriorityClassName string
+	SecurityContext   *corev1.SecurityContext
+	MountDaemonConfig bool
 }
 
 // RenderK8sdProxyDaemonSet renders the manifest for the k8sd-proxy daemonset based on supplied configuration.
 func RenderK8sdProxyDaemonSetManifest(input K8sdProxyDaemonSetInput) ([]byte, error) {
+	data := k8sdProxyTemplateData{
+		K8sdPort:          input.K8sdPort,
+		Image:             DefaultK8sdProxyImage,
+		Tolerations:       defaultK8sdProxyTolerations,
+		SecurityContext:   &defaultK8sdProxySecurityContext,
+		MountDaemonConfig: true,
+	}
+	if c := input.Config; c != nil {
+		if c.Image != "" {
+			data.Image = c.Image
+		}
+		if len(c.Tolerations) > 0 {
+			data.Tolerations = c.Tolerations
+		}
+		if c.SecurityContext != nil {
+			data.SecurityContext = c.SecurityContext
+		}
+		data.ImagePullSecrets = c.ImagePullSecrets
+		data.Resources = c.Resources
+		data.PriorityClassName = c.PriorityClassName
+		data.MountDaemonConfig = !c.DisableDaemonConfigMount
+	}
+	if data.Image == "" {
+		return nil, fmt.Errorf("no k8sd-proxy image is configured and the providers were built without a default one")
+	}
+
 	var b bytes.Buffer
-	if err := k8sdProxyDaemonSetTemplate.Execute(&b, input); err != nil {
+	if err := k8sdProxyDaemonSetTemplate.Execute(&b, data); err != nil {
 		return nil, err
 	}
 
 	return b.Bytes(), nil
 }
+
+// ReconcileK8sdProxy creates or updates the k8sd-proxy configmap and daemonset in the workload cluster.
+func (w *Workload) ReconcileK8sdProxy(ctx context.Context, input K8sdProxyDaemonSetInput) error {
+	manifest, err := RenderK8sdProxyDaemonSetManifest(input)
+	if err != nil {
+		return fmt.Errorf("failed to render k8sd-proxy manifest: %w", err)
+	}
+
+	objs, err := utilyaml.ToUnstructured(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to parse k8sd-proxy manifest: %w", err)
+	}
+
+	for i := range objs {
+		desired := &objs[i]
+
+		b, err := desired.MarshalJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s %s: %w", desired.GetKind(), desired.GetName(), err)
+		}
+		sum := sha256.Sum256(b)
+		hash := hex.EncodeToString(sum[:])[:16]
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(desired.GroupVersionKind())
+		if err := w.Client.Get(ctx, ctrlclient.ObjectKeyFromObject(desired), existing); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), desired.GetName(), err)
+			}
+			desired.SetAnnotations(map[string]string{bootstrapv1.K8sdProxyHashAnnotation: hash})
+			if err := w.Client.Create(ctx, desired); err != nil {
+				return fmt.Errorf("failed to create %s %s: %w", desired.GetKind(), desired.GetName(), err)
+			}
+			continue
+		}
+
+		annotations := existing.GetAnnotations()
+		if annotations[bootstrapv1.K8sdProxyHashAnnotation] == hash {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[bootstrapv1.K8sdProxyHashAnnotation] = hash
+
+		// NOTE: The object is replaced rather than patched, so that the fields removed from the manifest are removed
+		// from the object as well, including those of the manifest deployed with kubectl when the cluster was initialized.
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		desired.SetAnnotations(annotations)
+		if err := w.Client.Update(ctx, desired); err != nil {
+			return fmt.Errorf("failed to update %s %s: %w", desired.GetKind(), desired.GetName(), err)
+		}
+	}
+
+	return nil
+}
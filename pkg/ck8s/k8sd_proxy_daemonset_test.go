@@ -0,0 +1,159 @@
+package ck8s
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	utilyaml "sigs.k8s.io/cluster-api/util/yaml"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// setDefaultK8sdProxyImage sets the default k8sd-proxy image for the duration of the test, as it is set at build time.
+func setDefaultK8sdProxyImage(t *testing.T, image string) {
+	t.Helper()
+
+	defaultImage := DefaultK8sdProxyImage
+	DefaultK8sdProxyImage = image
+	t.Cleanup(func() { DefaultK8sdProxyImage = defaultImage })
+}
+
+func TestRenderK8sdProxyDaemonSetManifest(t *testing.T) {
+	setDefaultK8sdProxyImage(t, "ghcr.io/canonical/cluster-api-k8s/k8sd-proxy:v0.3.0")
+
+	renderDaemonSet := func(g *WithT, input K8sdProxyDaemonSetInput) *appsv1.DaemonSet {
+		b, err := RenderK8sdProxyDaemonSetManifest(input)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objs, err := utilyaml.ToUnstructured(b)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objs).To(HaveLen(2))
+		g.Expect(objs[0].GetKind()).To(Equal("ConfigMap"))
+
+		ds := &appsv1.DaemonSet{}
+		g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(objs[1].Object, ds)).To(Succeed())
+		return ds
+	}
+
+	t.Run("default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ds := renderDaemonSet(g, K8sdProxyDaemonSetInput{K8sdPort: 2380})
+		spec := ds.Spec.Template.Spec
+		g.Expect(spec.Containers).To(HaveLen(1))
+		g.Expect(spec.Containers[0].Image).To(Equal(DefaultK8sdProxyImage))
+		g.Expect(spec.Containers[0].SecurityContext).To(Equal(&defaultK8sdProxySecurityContext))
+		g.Expect(spec.Containers[0].Resources).To(BeZero())
+		g.Expect(spec.Containers[0].VolumeMounts).To(ConsistOf(corev1.VolumeMount{
+			Name:      "k8sd-daemon-config",
+			MountPath: "/etc/k8sd-proxy/daemon.yaml",
+			ReadOnly:  true,
+		}))
+		g.Expect(spec.Tolerations).To(Equal(defaultK8sdProxyTolerations))
+		g.Expect(spec.Volumes).To(HaveLen(1))
+		g.Expect(spec.Volumes[0].HostPath).To(Equal(&corev1.HostPathVolumeSource{
+			Path: "/var/snap/k8s/common/var/lib/k8sd/state/daemon.yaml",
+			Type: ptr.To(corev1.HostPathFile),
+		}))
+		g.Expect(spec.PriorityClassName).To(BeEmpty())
+		g.Expect(spec.ImagePullSecrets).To(BeEmpty())
+	})
+
+	t.Run("config", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config := &bootstrapv1.K8sdProxyConfig{
+			Image:            "registry.local:5000/k8sd-proxy:v0.3.0",
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			Tolerations:      []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			PriorityClassName: "system-node-critical",
+			SecurityContext: &corev1.SecurityContext{
+				RunAsNonRoot:   ptr.To(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			DisableDaemonConfigMount: true,
+		}
+
+		ds := renderDaemonSet(g, K8sdProxyDaemonSetInput{K8sdPort: 2380, Config: config})
+		spec := ds.Spec.Template.Spec
+		g.Expect(spec.Containers[0].Image).To(Equal(config.Image))
+		g.Expect(spec.Containers[0].SecurityContext).To(Equal(config.SecurityContext))
+		g.Expect(spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("64Mi"))
+		g.Expect(spec.Containers[0].VolumeMounts).To(BeEmpty())
+		g.Expect(spec.Tolerations).To(Equal(config.Tolerations))
+		g.Expect(spec.Volumes).To(BeEmpty())
+		g.Expect(spec.PriorityClassName).To(Equal(config.PriorityClassName))
+		g.Expect(spec.ImagePullSecrets).To(Equal(config.ImagePullSecrets))
+	})
+
+	t.Run("no image", func(t *testing.T) {
+		g := NewWithT(t)
+
+		setDefaultK8sdProxyImage(t, "")
+
+		_, err := RenderK8sdProxyDaemonSetManifest(K8sdProxyDaemonSetInput{K8sdPort: 2380})
+		g.Expect(err).To(HaveOccurred())
+
+		// The configured image is used regardless.
+		config := &bootstrapv1.K8sdProxyConfig{Image: "registry.local:5000/k8sd-proxy:v0.3.0"}
+		_, err = RenderK8sdProxyDaemonSetManifest(K8sdProxyDaemonSetInput{K8sdPort: 2380, Config: config})
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+}
+
+func TestReconcileK8sdProxy(t *testing.T) {
+	g := NewWithT(t)
+
+	setDefaultK8sdProxyImage(t, "ghcr.io/canonical/cluster-api-k8s/k8sd-proxy:v0.3.0")
+
+	ctx := context.Background()
+	w := &Workload{Client: fake.NewClientBuilder().Build()}
+
+	// The daemonset is created.
+	g.Expect(w.ReconcileK8sdProxy(ctx, K8sdProxyDaemonSetInput{K8sdPort: 2380})).To(Succeed())
+
+	key := ctrlclient.ObjectKey{Namespace: "kube-system", Name: "k8sd-proxy"}
+	ds := &appsv1.DaemonSet{}
+	g.Expect(w.Client.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(1))
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(w.Client.Get(ctx, ctrlclient.ObjectKey{Namespace: "kube-system", Name: "k8sd-proxy-config"}, cm)).To(Succeed())
+	g.Expect(cm.Data).To(HaveKeyWithValue("k8sd-port", "2380"))
+
+	g.Expect(ds.Annotations).To(HaveKey(bootstrapv1.K8sdProxyHashAnnotation))
+
+	// The daemonset is not updated if the manifest did not change.
+	resourceVersion := ds.ResourceVersion
+	g.Expect(w.ReconcileK8sdProxy(ctx, K8sdProxyDaemonSetInput{K8sdPort: 2380})).To(Succeed())
+	g.Expect(w.Client.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.ResourceVersion).To(Equal(resourceVersion))
+
+	// The annotations set in the cluster are kept.
+	ds.Annotations["deprecated.daemonset.template.generation"] = "1"
+	g.Expect(w.Client.Update(ctx, ds)).To(Succeed())
+
+	// The daemonset is updated, and the removed fields are removed.
+	config := &bootstrapv1.K8sdProxyConfig{Image: "registry.local:5000/k8sd-proxy:v0.3.0", DisableDaemonConfigMount: true}
+	g.Expect(w.ReconcileK8sdProxy(ctx, K8sdProxyDaemonSetInput{K8sdPort: 6400, Config: config})).To(Succeed())
+
+	g.Expect(w.Client.Get(ctx, key, ds)).To(Succeed())
+	g.Expect(ds.Spec.Template.Spec.Containers[0].Image).To(Equal(config.Image))
+	g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(BeEmpty())
+	g.Expect(ds.Spec.Template.Spec.Volumes).To(BeEmpty())
+	g.Expect(ds.Annotations).To(HaveKeyWithValue("deprecated.daemonset.template.generation", "1"))
+
+	g.Expect(w.Client.Get(ctx, ctrlclient.ObjectKey{Namespace: "kube-system", Name: "k8sd-proxy-config"}, cm)).To(Succeed())
+	g.Expect(cm.Data).To(HaveKeyWithValue("k8sd-port", "6400"))
+}
@@ -10,7 +10,9 @@ import (
 	"sigs.k8s.io/cluster-api/controllers/remote"
 	"sigs.k8s.io/cluster-api/util/collections"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/token"
 )
 
@@ -29,6 +31,9 @@ type Management struct {
 	Client client.Client
 
 	K8sdDialTimeout time.Duration
+
+	// k8sdUnreachable are the k8sd addresses the direct transport failed to dial, which outlive the workload clusters.
+	k8sdUnreachable k8sdUnreachableCache
 }
 
 // RemoteClusterConnectionError represents a failure to connect to a remote cluster.
@@ -84,11 +89,25 @@ func (m *Management) GetWorkloadCluster(ctx context.Context, clusterKey client.O
 		return nil, &RemoteClusterConnectionError{Name: clusterKey.String(), Err: err}
 	}
 
-	g, err := NewK8sdClientGenerator(restConfig, m.K8sdDialTimeout)
+	cluster := &clusterv1.Cluster{}
+	if err := m.Client.Get(ctx, clusterKey, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	transport := getK8sdTransport(ctx, cluster)
+
+	g, err := NewK8sdClientGenerator(restConfig, m.K8sdDialTimeout, WithK8sdTransport(transport), withK8sdUnreachableCache(clusterKey, &m.k8sdUnreachable))
 	if err != nil {
 		return nil, &RemoteClusterConnectionError{Name: clusterKey.String(), Err: err}
 	}
 
+	var controlPlaneMachines collections.Machines
+	if transport == bootstrapv1.K8sdTransportDirect {
+		controlPlaneMachines, err = m.GetMachinesForCluster(ctx, clusterKey, collections.ControlPlaneMachines(clusterKey.Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get control plane machines: %w", err)
+		}
+	}
+
 	authToken, err := token.Lookup(ctx, m.Client, clusterKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to lookup auth token: %w", err)
@@ -104,9 +123,25 @@ func (m *Management) GetWorkloadCluster(ctx context.Context, clusterKey client.O
 		ClientRestConfig:    restConfig,
 		K8sdClientGenerator: g,
 		microclusterPort:    microclusterPort,
+
+		controlPlaneMachines: controlPlaneMachines,
 	}
 
 	return workload, nil
 }
 
 var _ ManagementCluster = &Management{}
+
+// getK8sdTransport returns the k8sd transport selected by the annotation of the cluster.
+// NOTE: An unknown transport must not break the reconciliation of the cluster, so the k8sd-proxy is used instead.
+func getK8sdTransport(ctx context.Context, cluster *clusterv1.Cluster) bootstrapv1.K8sdTransport {
+	transport := bootstrapv1.K8sdTransport(cluster.Annotations[bootstrapv1.K8sdTransportAnnotation])
+	switch transport {
+	case bootstrapv1.K8sdTransportProxy, bootstrapv1.K8sdTransportDirect:
+		return transport
+	case "":
+	default:
+		log.FromContext(ctx).Info("Unknown k8sd transport, falling back to the k8sd-proxy", "annotation", bootstrapv1.K8sdTransportAnnotation, "transport", transport)
+	}
+	return bootstrapv1.K8sdTransportProxy
+}
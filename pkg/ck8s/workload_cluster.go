@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 
 	apiv1 "github.com/canonical/k8s-snap-api/api/v1"
@@ -24,6 +26,7 @@ import (
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
 )
 
@@ -55,6 +58,10 @@ type Workload struct {
 	ClientRestConfig    *rest.Config
 	K8sdClientGenerator *k8sdClientGenerator
 	microclusterPort    int
+
+	// controlPlaneMachines are the control plane machines of the cluster, which the direct transport reaches
+	// k8sd on without the API server of the workload cluster.
+	controlPlaneMachines collections.Machines
 }
 
 // ClusterStatus holds stats information about the cluster.
@@ -146,6 +153,17 @@ func getNodeInternalIP(node *corev1.Node) (string, error) {
 	return "", fmt.Errorf("unable to find internal IP for node %s", node.Name)
 }
 
+// getMachineInternalIP returns the internal IP of the machine reported by the infrastructure provider, if any.
+func getMachineInternalIP(machine *clusterv1.Machine) string {
+	for _, addr := range machine.Status.Addresses {
+		if addr.Type == clusterv1.MachineInternalIP {
+			return addr.Address
+		}
+	}
+
+	return ""
+}
+
 type k8sdProxyOptions struct {
 	// IgnoreNodes is a set of node names that should be ignored when selecting a control plane node to proxy to.
 	// This is useful when a control plane node is being removed and we want to avoid using it
@@ -154,6 +172,11 @@ type k8sdProxyOptions struct {
 
 // GetK8sdProxyForControlPlane returns a k8sd proxy client for the control plane.
 func (w *Workload) GetK8sdProxyForControlPlane(ctx context.Context, options k8sdProxyOptions) (*K8sdClient, error) {
+	// NOTE: The direct transport does not need the API server of the workload cluster, e.g. to remediate it.
+	if w.K8sdClientGenerator.transport == bootstrapv1.K8sdTransportDirect {
+		return w.getK8sdClientForControlPlaneMachines(ctx, options)
+	}
+
 	cplaneNodes, err := w.getControlPlaneNodes(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get control plane nodes: %w", err)
@@ -202,6 +225,43 @@ func (w *Workload) GetK8sdProxyForControlPlane(ctx context.Context, options k8sd
 	return nil, fmt.Errorf("failed to get k8sd proxy for control plane, previous errors: %w", errors.Join(allErrors...))
 }
 
+// getK8sdClientForControlPlaneMachines returns a k8sd client for the control plane with the direct transport.
+// k8sd is reached on the internal IP of the control plane machines reported by the infrastructure provider.
+func (w *Workload) getK8sdClientForControlPlaneMachines(ctx context.Context, options k8sdProxyOptions) (*K8sdClient, error) {
+	var allErrors []error
+	for _, machine := range w.controlPlaneMachines.SortedByCreationTimestamp() {
+		if machine.Status.NodeRef == nil {
+			continue
+		}
+		if _, ok := options.IgnoreNodes[machine.Status.NodeRef.Name]; ok {
+			continue
+		}
+
+		address := getMachineInternalIP(machine)
+		if address == "" {
+			allErrors = append(allErrors, fmt.Errorf("machine %s has no internal IP", machine.Name))
+			continue
+		}
+
+		client, err := w.K8sdClientGenerator.forAddress(address, machine.Status.NodeRef.Name)
+		if err != nil {
+			allErrors = append(allErrors, fmt.Errorf("could not create k8sd client for machine %s: %w", machine.Name, err))
+			continue
+		}
+
+		// Check if there is any response from k8sd.
+		header := w.newHeaderWithCAPIAuthToken()
+		if err := w.doK8sdRequest(ctx, client, http.MethodGet, "", header, "", nil); err != nil {
+			allErrors = append(allErrors, fmt.Errorf("error while contacting k8sd on machine %s: %w", machine.Name, err))
+			continue
+		}
+
+		return client, nil
+	}
+
+	return nil, fmt.Errorf("failed to get k8sd client for control plane, previous errors: %w", errors.Join(allErrors...))
+}
+
 // GetK8sdProxyForMachine returns a k8sd proxy client for the machine.
 func (w *Workload) GetK8sdProxyForMachine(ctx context.Context, machine *clusterv1.Machine) (*K8sdClient, error) {
 	if machine == nil {
@@ -212,6 +272,14 @@ func (w *Workload) GetK8sdProxyForMachine(ctx context.Context, machine *clusterv
 		return nil, fmt.Errorf("machine %s has no node reference", machine.Name)
 	}
 
+	// NOTE: The direct transport does not need the API server of the workload cluster, e.g. to remediate it.
+	// The node is only fetched if the infrastructure provider does not report the internal IP of the machine.
+	if w.K8sdClientGenerator.transport == bootstrapv1.K8sdTransportDirect {
+		if address := getMachineInternalIP(machine); address != "" {
+			return w.K8sdClientGenerator.forAddress(address, machine.Status.NodeRef.Name)
+		}
+	}
+
 	node := &corev1.Node{}
 	if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
 		return nil, fmt.Errorf("failed to get node: %w", err)
@@ -358,13 +426,13 @@ func (w *Workload) RefreshControlPlaneCertificates(ctx context.Context, machine
 	return seconds, nil
 }
 
-func (w *Workload) RefreshMachine(ctx context.Context, machine *clusterv1.Machine, nodeToken string, upgradeOption string) (string, error) {
+// newSnapRefreshRequest returns the request to refresh the k8s snap to the upgrade option, e.g. "channel=1.31-classic/stable".
+func newSnapRefreshRequest(upgradeOption string) (apiv1.SnapRefreshRequest, error) {
 	request := apiv1.SnapRefreshRequest{}
-	response := &apiv1.SnapRefreshResponse{}
 	optionKv := strings.Split(upgradeOption, "=")
 
 	if len(optionKv) != 2 {
-		return "", fmt.Errorf("invalid in-place upgrade release annotation: %s", upgradeOption)
+		return request, fmt.Errorf("invalid in-place upgrade release annotation: %s", upgradeOption)
 	}
 
 	switch optionKv[0] {
@@ -374,8 +442,21 @@ func (w *Workload) RefreshMachine(ctx context.Context, machine *clusterv1.Machin
 		request.Revision = optionKv[1]
 	case "localPath":
 		request.LocalPath = optionKv[1]
+	case "version":
+		return request, fmt.Errorf("upgrade option %s must be resolved to a channel or revision first", upgradeOption)
 	default:
-		return "", fmt.Errorf("invalid upgrade option: %s", optionKv[0])
+		return request, fmt.Errorf("invalid upgrade option: %s", optionKv[0])
+	}
+
+	return request, nil
+}
+
+func (w *Workload) RefreshMachine(ctx context.Context, machine *clusterv1.Machine, nodeToken string, upgradeOption string) (string, error) {
+	response := &apiv1.SnapRefreshResponse{}
+
+	request, err := newSnapRefreshRequest(upgradeOption)
+	if err != nil {
+		return "", err
 	}
 
 	k8sdProxy, err := w.GetK8sdProxyForMachine(ctx, machine)
@@ -476,7 +557,7 @@ func (w *Workload) doK8sdRequest(ctx context.Context, k8sdProxy *K8sdClient, met
 		Metadata json.RawMessage `json:"metadata"`
 	}
 
-	url := fmt.Sprintf("https://%s:%v/%s", k8sdProxy.NodeIP, w.microclusterPort, endpoint)
+	url := fmt.Sprintf("https://%s/%s", net.JoinHostPort(k8sdProxy.NodeIP, strconv.Itoa(w.microclusterPort)), endpoint)
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
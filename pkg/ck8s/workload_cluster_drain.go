@@ -0,0 +1,127 @@
+package ck8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DrainNodeResult is the result of a drain attempt of a node.
+type DrainNodeResult struct {
+	// PodsRemaining is the number of pods that still need to be evicted from the node, or are terminating.
+	PodsRemaining int
+	// PodsBlocked are the "namespace/name" of the pods whose eviction is blocked by a PodDisruptionBudget.
+	PodsBlocked []string
+}
+
+// CordonNode marks the node as unschedulable.
+func (w *Workload) CordonNode(ctx context.Context, nodeName string) error {
+	return w.setNodeUnschedulable(ctx, nodeName, true)
+}
+
+// UncordonNode marks the node as schedulable.
+func (w *Workload) UncordonNode(ctx context.Context, nodeName string) error {
+	return w.setNodeUnschedulable(ctx, nodeName, false)
+}
+
+func (w *Workload) setNodeUnschedulable(ctx context.Context, nodeName string, unschedulable bool) error {
+	node := &corev1.Node{}
+	if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Name: nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+	if node.Spec.Unschedulable == unschedulable {
+		return nil
+	}
+
+	patch := ctrlclient.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = unschedulable
+	if err := w.Client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to patch node %q: %w", nodeName, err)
+	}
+	return nil
+}
+
+// IsNodeReady checks if the node reports the Ready condition.
+func (w *Workload) IsNodeReady(ctx context.Context, nodeName string) (bool, error) {
+	node := &corev1.Node{}
+	if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Name: nodeName}, node); err != nil {
+		return false, fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
+// GetNodeVersion returns the Kubernetes version reported by the kubelet of the node.
+func (w *Workload) GetNodeVersion(ctx context.Context, nodeName string) (string, error) {
+	node := &corev1.Node{}
+	if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Name: nodeName}, node); err != nil {
+		return "", fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+	return node.Status.NodeInfo.KubeletVersion, nil
+}
+
+// DrainNode evicts the pods running on the node, without waiting for them to terminate. DaemonSet pods, mirror pods
+// and pods that already completed are not evicted. Evictions that would violate a PodDisruptionBudget are rejected
+// by the API server, and retried on the next attempt.
+func (w *Workload) DrainNode(ctx context.Context, nodeName string) (*DrainNodeResult, error) {
+	pods := &corev1.PodList{}
+	if err := w.Client.List(ctx, pods, ctrlclient.MatchingFields{"spec.nodeName": nodeName}); err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %q: %w", nodeName, err)
+	}
+
+	result := &DrainNodeResult{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !podNeedsEviction(pod) {
+			continue
+		}
+		result.PodsRemaining++
+
+		if !pod.DeletionTimestamp.IsZero() {
+			// The pod was already evicted and is terminating.
+			continue
+		}
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		}
+		if err := w.Client.SubResource("eviction").Create(ctx, pod, eviction); err != nil {
+			switch {
+			case apierrors.IsNotFound(err):
+				result.PodsRemaining--
+			case apierrors.IsTooManyRequests(err):
+				result.PodsBlocked = append(result.PodsBlocked, ctrlclient.ObjectKeyFromObject(pod).String())
+			default:
+				return nil, fmt.Errorf("failed to evict pod %s: %w", ctrlclient.ObjectKeyFromObject(pod), err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// podNeedsEviction checks if the pod must be evicted to drain its node.
+func podNeedsEviction(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	if controllerRef := metav1.GetControllerOf(pod); controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package ck8s
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newDrainTestPod(name string, mutate func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node1",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	return pod
+}
+
+func newDrainTestClient(funcs interceptor.Funcs, objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().
+		WithObjects(objs...).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+			return []string{o.(*corev1.Pod).Spec.NodeName}
+		}).
+		WithInterceptorFuncs(funcs).
+		Build()
+}
+
+func TestCordonNode(t *testing.T) {
+	g := NewWithT(t)
+
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	w := &Workload{Client: newDrainTestClient(interceptor.Funcs{}, node)}
+
+	g.Expect(w.CordonNode(context.Background(), "node1")).To(Succeed())
+	g.Expect(w.Client.Get(context.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+	g.Expect(node.Spec.Unschedulable).To(BeTrue())
+
+	g.Expect(w.UncordonNode(context.Background(), "node1")).To(Succeed())
+	g.Expect(w.Client.Get(context.Background(), client.ObjectKeyFromObject(node), node)).To(Succeed())
+	g.Expect(node.Spec.Unschedulable).To(BeFalse())
+
+	g.Expect(w.CordonNode(context.Background(), "node2")).ToNot(Succeed())
+}
+
+func TestIsNodeReady(t *testing.T) {
+	g := NewWithT(t)
+
+	ready := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.31.2"},
+		},
+	}
+	notReady := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "not-ready"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}},
+		},
+	}
+	w := &Workload{Client: newDrainTestClient(interceptor.Funcs{}, ready, notReady)}
+
+	isReady, err := w.IsNodeReady(context.Background(), "ready")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(isReady).To(BeTrue())
+
+	isReady, err = w.IsNodeReady(context.Background(), "not-ready")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(isReady).To(BeFalse())
+
+	version, err := w.GetNodeVersion(context.Background(), "ready")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(version).To(Equal("v1.31.2"))
+}
+
+func TestDrainNode(t *testing.T) {
+	evictable := newDrainTestPod("evictable", nil)
+	otherNode := newDrainTestPod("other-node", func(p *corev1.Pod) { p.Spec.NodeName = "node2" })
+	daemonSet := newDrainTestPod("daemonset", func(p *corev1.Pod) {
+		p.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "ds", UID: "uid", Controller: ptr.To(true)}}
+	})
+	mirror := newDrainTestPod("mirror", func(p *corev1.Pod) {
+		p.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+	})
+	completed := newDrainTestPod("completed", func(p *corev1.Pod) { p.Status.Phase = corev1.PodSucceeded })
+
+	t.Run("EvictsPods", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: newDrainTestClient(interceptor.Funcs{},
+			evictable.DeepCopy(), otherNode.DeepCopy(), daemonSet.DeepCopy(), mirror.DeepCopy(), completed.DeepCopy())}
+
+		result, err := w.DrainNode(context.Background(), "node1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.PodsRemaining).To(Equal(1))
+		g.Expect(result.PodsBlocked).To(BeEmpty())
+
+		pods := &corev1.PodList{}
+		g.Expect(w.Client.List(context.Background(), pods)).To(Succeed())
+		g.Expect(pods.Items).To(HaveLen(4))
+		for _, pod := range pods.Items {
+			g.Expect(pod.Name).ToNot(Equal(evictable.Name))
+		}
+
+		result, err = w.DrainNode(context.Background(), "node1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.PodsRemaining).To(Equal(0))
+	})
+
+	t.Run("BlockedByPodDisruptionBudget", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: newDrainTestClient(interceptor.Funcs{
+			SubResourceCreate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+				return apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+			},
+		}, evictable.DeepCopy())}
+
+		result, err := w.DrainNode(context.Background(), "node1")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.PodsRemaining).To(Equal(1))
+		g.Expect(result.PodsBlocked).To(ConsistOf("default/evictable"))
+	})
+
+	t.Run("EvictionError", func(t *testing.T) {
+		g := NewWithT(t)
+		w := &Workload{Client: newDrainTestClient(interceptor.Funcs{
+			SubResourceCreate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+				return apierrors.NewInternalError(context.DeadlineExceeded)
+			},
+		}, evictable.DeepCopy())}
+
+		_, err := w.DrainNode(context.Background(), "node1")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
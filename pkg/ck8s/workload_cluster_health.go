@@ -0,0 +1,211 @@
+package ck8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apiv1 "github.com/canonical/k8s-snap-api/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+// healthCheckServiceTimeout is how long the health check Service may take to respond.
+const healthCheckServiceTimeout = 10 * time.Second
+
+// CheckHealthGates returns the reasons why the machine does not pass the health gates after its in-place upgrade
+// to the release, if any. Failures to reach the workload cluster are reported as reasons, so that they count
+// towards the health check timeout.
+func (w *Workload) CheckHealthGates(ctx context.Context, machine *clusterv1.Machine, nodeToken string, release string, gates *inplace.HealthGates) ([]string, error) {
+	if machine.Status.NodeRef == nil {
+		return []string{"machine has no node reference"}, nil
+	}
+	nodeName := machine.Status.NodeRef.Name
+
+	var reasons []string
+	if gates.Node {
+		if err := w.checkNodeHealth(ctx, nodeName, inplace.GetReleaseVersion(release)); err != nil {
+			reasons = append(reasons, fmt.Sprintf("node: %v", err))
+		}
+	}
+
+	if gates.ControlPlane {
+		if err := w.checkAPIServerReady(ctx); err != nil {
+			reasons = append(reasons, fmt.Sprintf("control plane: %v", err))
+		}
+	}
+
+	if gates.Datastore && util.IsControlPlaneMachine(machine) {
+		role, err := w.GetDatastoreRole(ctx, machine, nodeToken)
+		switch {
+		case err != nil:
+			reasons = append(reasons, fmt.Sprintf("datastore: %v", err))
+		case !isDatastoreMember(role):
+			reasons = append(reasons, fmt.Sprintf("datastore: node has role %q", role))
+		}
+	}
+
+	if gates.Service != nil {
+		if err := w.checkHealthCheckService(ctx, gates.Service); err != nil {
+			reasons = append(reasons, fmt.Sprintf("health check service: %v", err))
+		}
+	}
+
+	if gates.Job != nil {
+		reason, err := w.runHealthCheckJob(ctx, *gates.Job, machine, release)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			reasons = append(reasons, fmt.Sprintf("health check job: %s", reason))
+		}
+	}
+
+	return reasons, nil
+}
+
+// checkNodeHealth checks that the node is Ready and, if set, that the kubelet reports the Kubernetes minor version.
+func (w *Workload) checkNodeHealth(ctx context.Context, nodeName string, version string) error {
+	node := &corev1.Node{}
+	if err := w.Client.Get(ctx, ctrlclient.ObjectKey{Name: nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %q: %w", nodeName, err)
+	}
+
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	if !ready {
+		return fmt.Errorf("node %q is not ready", nodeName)
+	}
+
+	if kubeletVersion := node.Status.NodeInfo.KubeletVersion; version != "" && !strings.HasPrefix(kubeletVersion, version+".") {
+		return fmt.Errorf("node %q reports version %q, expected %s", nodeName, kubeletVersion, version)
+	}
+	return nil
+}
+
+// checkAPIServerReady checks that the API server of the workload cluster reports ready.
+func (w *Workload) checkAPIServerReady(ctx context.Context) error {
+	clientset, err := kubernetes.NewForConfig(w.ClientRestConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	if _, err := clientset.RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+		return fmt.Errorf("API server is not ready: %w", err)
+	}
+	return nil
+}
+
+// checkHealthCheckService checks that the Service responds with a 2xx status code, through the service proxy of the
+// API server of the workload cluster.
+func (w *Workload) checkHealthCheckService(ctx context.Context, service *inplace.HealthCheckService) error {
+	clientset, err := kubernetes.NewForConfig(w.ClientRestConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckServiceTimeout)
+	defer cancel()
+
+	if _, err := clientset.CoreV1().Services(service.Namespace).ProxyGet("http", service.Name, service.Port, service.Path, nil).DoRaw(ctx); err != nil {
+		return fmt.Errorf("service %s/%s is not healthy: %w", service.Namespace, service.Name, err)
+	}
+	return nil
+}
+
+// GetDatastoreRole returns the role of the node of the machine within the datastore cluster.
+func (w *Workload) GetDatastoreRole(ctx context.Context, machine *clusterv1.Machine, nodeToken string) (apiv1.DatastoreRole, error) {
+	response := &apiv1.NodeStatusResponse{}
+
+	k8sdProxy, err := w.GetK8sdProxyForMachine(ctx, machine)
+	if err != nil {
+		return "", fmt.Errorf("failed to create k8sd proxy: %w", err)
+	}
+
+	header := w.newHeaderWithNodeToken(nodeToken)
+
+	if err := w.doK8sdRequest(ctx, k8sdProxy, http.MethodGet, fmt.Sprintf("%s/%s", apiv1.K8sdAPIVersion, apiv1.NodeStatusRPC), header, apiv1.NodeStatusRequest{}, response); err != nil {
+		return "", fmt.Errorf("failed to get status of machine %s: %w", machine.Name, err)
+	}
+
+	return response.NodeStatus.DatastoreRole, nil
+}
+
+// isDatastoreMember checks if the datastore role is held by a member of the datastore cluster.
+func isDatastoreMember(role apiv1.DatastoreRole) bool {
+	switch role {
+	case apiv1.DatastoreRoleVoter, apiv1.DatastoreRoleStandBy, apiv1.DatastoreRoleSpare:
+		return true
+	default:
+		return false
+	}
+}
+
+// runHealthCheckJob creates a Job from the template of the CronJob for the machine and the release, and returns
+// why the Job did not complete yet, if it did not.
+func (w *Workload) runHealthCheckJob(ctx context.Context, cronJobKey ctrlclient.ObjectKey, machine *clusterv1.Machine, release string) (string, error) {
+	cronJob := &batchv1.CronJob{}
+	if err := w.Client.Get(ctx, cronJobKey, cronJob); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("CronJob %s not found", cronJobKey), nil
+		}
+		return "", fmt.Errorf("failed to get CronJob %s: %w", cronJobKey, err)
+	}
+
+	job := &batchv1.Job{}
+	jobKey := ctrlclient.ObjectKey{Namespace: cronJob.Namespace, Name: healthCheckJobName(cronJob.Name, machine, release)}
+	if err := w.Client.Get(ctx, jobKey, job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to get Job %s: %w", jobKey, err)
+		}
+
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        jobKey.Name,
+				Namespace:   jobKey.Namespace,
+				Labels:      cronJob.Spec.JobTemplate.Labels,
+				Annotations: cronJob.Spec.JobTemplate.Annotations,
+			},
+			Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+		}
+		if err := w.Client.Create(ctx, job); err != nil {
+			return "", fmt.Errorf("failed to create Job %s: %w", jobKey, err)
+		}
+		return fmt.Sprintf("Job %s was created", jobKey), nil
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return "", nil
+		case batchv1.JobFailed:
+			return fmt.Sprintf("Job %s failed: %s", jobKey, condition.Message), nil
+		}
+	}
+	return fmt.Sprintf("Job %s did not complete yet", jobKey), nil
+}
+
+// healthCheckJobName returns the name of the health check Job of the machine for the release.
+func healthCheckJobName(cronJobName string, machine *clusterv1.Machine, release string) string {
+	hash := sha256.Sum256([]byte(string(machine.UID) + "/" + release))
+	return fmt.Sprintf("%s-%s", cronJobName, hex.EncodeToString(hash[:])[:10])
+}
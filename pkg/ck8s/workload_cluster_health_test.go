@@ -0,0 +1,132 @@
+package ck8s
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+func TestCheckHealthGatesNode(t *testing.T) {
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", UID: "uid"},
+		Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node1"}},
+	}
+	newNode := func(ready corev1.ConditionStatus, version string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+				NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: version},
+			},
+		}
+	}
+	gates := &inplace.HealthGates{Node: true}
+
+	for _, tc := range []struct {
+		name    string
+		node    *corev1.Node
+		release string
+		healthy bool
+	}{
+		{
+			name:    "Healthy",
+			node:    newNode(corev1.ConditionTrue, "v1.31.2"),
+			release: "channel=1.31-classic/stable",
+			healthy: true,
+		},
+		{
+			name:    "NotReady",
+			node:    newNode(corev1.ConditionFalse, "v1.31.2"),
+			release: "channel=1.31-classic/stable",
+		},
+		{
+			name:    "UnexpectedVersion",
+			node:    newNode(corev1.ConditionTrue, "v1.30.6"),
+			release: "channel=1.31-classic/stable",
+		},
+		{
+			name:    "UnknownVersion",
+			node:    newNode(corev1.ConditionTrue, "v1.30.6"),
+			release: "revision=123",
+			healthy: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			w := &Workload{Client: fake.NewClientBuilder().WithObjects(tc.node).Build()}
+			reasons, err := w.CheckHealthGates(context.Background(), machine, "", tc.release, gates)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tc.healthy {
+				g.Expect(reasons).To(BeEmpty())
+			} else {
+				g.Expect(reasons).To(HaveLen(1))
+			}
+		})
+	}
+}
+
+func TestCheckHealthGatesJob(t *testing.T) {
+	g := NewWithT(t)
+
+	const release = "channel=1.31-classic/stable"
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", UID: "uid"},
+		Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node1"}},
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "smoke-test", Namespace: metav1.NamespaceDefault},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "@yearly",
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "smoke-test"}},
+			},
+		},
+	}
+	gates := &inplace.HealthGates{Job: &types.NamespacedName{Namespace: metav1.NamespaceDefault, Name: "missing"}}
+
+	w := &Workload{Client: fake.NewClientBuilder().WithObjects(cronJob).Build()}
+
+	reasons, err := w.CheckHealthGates(context.Background(), machine, "", release, gates)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reasons).To(ConsistOf(ContainSubstring("not found")))
+
+	gates.Job.Name = cronJob.Name
+	reasons, err = w.CheckHealthGates(context.Background(), machine, "", release, gates)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reasons).To(ConsistOf(ContainSubstring("was created")))
+
+	job := &batchv1.Job{}
+	jobKey := client.ObjectKey{Namespace: metav1.NamespaceDefault, Name: healthCheckJobName(cronJob.Name, machine, release)}
+	g.Expect(w.Client.Get(context.Background(), jobKey, job)).To(Succeed())
+	g.Expect(job.Labels).To(HaveKeyWithValue("app", "smoke-test"))
+
+	reasons, err = w.CheckHealthGates(context.Background(), machine, "", release, gates)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reasons).To(ConsistOf(ContainSubstring("did not complete yet")))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	g.Expect(w.Client.Status().Update(context.Background(), job)).To(Succeed())
+	reasons, err = w.CheckHealthGates(context.Background(), machine, "", release, gates)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reasons).To(ConsistOf(ContainSubstring("BackoffLimitExceeded")))
+
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(w.Client.Status().Update(context.Background(), job)).To(Succeed())
+	reasons, err = w.CheckHealthGates(context.Background(), machine, "", release, gates)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(reasons).To(BeEmpty())
+
+	// A new Job is created for another release.
+	g.Expect(healthCheckJobName(cronJob.Name, machine, "channel=1.32-classic/stable")).ToNot(Equal(jobKey.Name))
+}
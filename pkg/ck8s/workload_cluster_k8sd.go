@@ -6,42 +6,131 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	"github.com/canonical/cluster-api-k8s/pkg/proxy"
 )
 
+// k8sdDirectDialTimeout is how long the direct transport waits for a node before falling back to the k8sd-proxy.
+const k8sdDirectDialTimeout = 5 * time.Second
+
+// k8sdUnreachableTTL is how long the direct transport reaches a node it failed to dial through the k8sd-proxy.
+const k8sdUnreachableTTL = 5 * time.Minute
+
 type K8sdClient struct {
 	NodeIP string
 	Client *http.Client
 }
 
+// k8sdUnreachableKey identifies a k8sd address of a workload cluster.
+type k8sdUnreachableKey struct {
+	cluster ctrlclient.ObjectKey
+	address string
+}
+
+// k8sdUnreachableCache records the k8sd addresses the direct transport failed to dial. It is kept on the management
+// cluster, as the workload clusters and their k8sd client generators are built on every reconcile.
+type k8sdUnreachableCache struct {
+	// addresses maps the unreachable addresses to the time they failed to be dialed.
+	addresses sync.Map
+}
+
+// isUnreachable returns true if the address failed to be dialed within k8sdUnreachableTTL.
+func (c *k8sdUnreachableCache) isUnreachable(key k8sdUnreachableKey, now time.Time) bool {
+	v, ok := c.addresses.Load(key)
+	if !ok {
+		return false
+	}
+	if now.Sub(v.(time.Time)) >= k8sdUnreachableTTL {
+		c.addresses.Delete(key)
+		return false
+	}
+	return true
+}
+
+func (c *k8sdUnreachableCache) markUnreachable(key k8sdUnreachableKey, now time.Time) {
+	c.addresses.Store(key, now)
+}
+
 type k8sdClientGenerator struct {
 	restConfig         *rest.Config
 	clientset          *kubernetes.Clientset
 	proxyClientTimeout time.Duration
+	transport          bootstrapv1.K8sdTransport
+
+	// cluster is the workload cluster, which the addresses of the unreachable cache are scoped to.
+	cluster     ctrlclient.ObjectKey
+	unreachable *k8sdUnreachableCache
+}
+
+// K8sdClientGeneratorOption configures a k8sdClientGenerator.
+type K8sdClientGeneratorOption func(*k8sdClientGenerator) error
+
+// WithK8sdTransport sets the transport of the k8sd clients. The default is bootstrapv1.K8sdTransportProxy.
+func WithK8sdTransport(transport bootstrapv1.K8sdTransport) K8sdClientGeneratorOption {
+	return func(g *k8sdClientGenerator) error {
+		switch transport {
+		case "":
+		case bootstrapv1.K8sdTransportProxy, bootstrapv1.K8sdTransportDirect:
+			g.transport = transport
+		default:
+			return fmt.Errorf("unknown k8sd transport %q", transport)
+		}
+		return nil
+	}
+}
+
+// withK8sdUnreachableCache sets the cache of the addresses of the cluster the direct transport failed to dial.
+func withK8sdUnreachableCache(cluster ctrlclient.ObjectKey, cache *k8sdUnreachableCache) K8sdClientGeneratorOption {
+	return func(g *k8sdClientGenerator) error {
+		g.cluster = cluster
+		g.unreachable = cache
+		return nil
+	}
 }
 
-func NewK8sdClientGenerator(restConfig *rest.Config, proxyClientTimeout time.Duration) (*k8sdClientGenerator, error) {
+func NewK8sdClientGenerator(restConfig *rest.Config, proxyClientTimeout time.Duration, options ...K8sdClientGeneratorOption) (*k8sdClientGenerator, error) {
 	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	return &k8sdClientGenerator{
+	g := &k8sdClientGenerator{
 		restConfig:         restConfig,
 		clientset:          clientset,
 		proxyClientTimeout: proxyClientTimeout,
-	}, nil
+		transport:          bootstrapv1.K8sdTransportProxy,
+		unreachable:        &k8sdUnreachableCache{},
+	}
+	for _, option := range options {
+		if err := option(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
 }
 
 func (g *k8sdClientGenerator) forNode(ctx context.Context, node *corev1.Node) (*K8sdClient, error) {
+	if g.transport == bootstrapv1.K8sdTransportDirect {
+		address, err := getNodeInternalIP(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get k8sd address for node %s: %w", node.Name, err)
+		}
+		return g.forAddress(address, node.Name)
+	}
+
 	podmap, err := g.getProxyPods(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get proxy pods: %w", err)
@@ -55,10 +144,11 @@ func (g *k8sdClientGenerator) forNode(ctx context.Context, node *corev1.Node) (*
 	return g.forNodePod(ctx, node, pod.Name)
 }
 
+// forNodePod returns a client for k8sd on the node through its k8sd-proxy pod.
 func (g *k8sdClientGenerator) forNodePod(ctx context.Context, node *corev1.Node, podname string) (*K8sdClient, error) {
-	nodeInternalIP, err := getNodeInternalIP(node)
+	address, err := getNodeInternalIP(node)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get internal IP for node %s: %w", node.Name, err)
+		return nil, fmt.Errorf("failed to get k8sd address for node %s: %w", node.Name, err)
 	}
 
 	client, err := g.NewHTTPClient(ctx, podname)
@@ -67,11 +157,40 @@ func (g *k8sdClientGenerator) forNodePod(ctx context.Context, node *corev1.Node,
 	}
 
 	return &K8sdClient{
-		NodeIP: nodeInternalIP,
+		NodeIP: address,
 		Client: client,
 	}, nil
 }
 
+// forAddress returns a client for k8sd on the node address with the direct transport, which does not need the
+// API server of the workload cluster. If the address cannot be dialed, the client falls back to the k8sd-proxy pod
+// of the node, unless the node name is empty.
+func (g *k8sdClientGenerator) forAddress(address string, nodeName string) (*K8sdClient, error) {
+	dialer := &fallbackDialer{
+		direct:      &net.Dialer{Timeout: k8sdDirectDialTimeout},
+		cluster:     g.cluster,
+		unreachable: g.unreachable,
+	}
+	if nodeName != "" {
+		dialer.fallback = func(ctx context.Context) (k8sdDialer, error) {
+			podmap, err := g.getProxyPods(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get proxy pods: %w", err)
+			}
+			pod, ok := podmap[nodeName]
+			if !ok {
+				return nil, fmt.Errorf("missing k8sd proxy pod for node %s", nodeName)
+			}
+			return g.newProxyDialer(pod.Name)
+		}
+	}
+
+	return &K8sdClient{
+		NodeIP: address,
+		Client: g.newHTTPClient(dialer),
+	}, nil
+}
+
 func (g *k8sdClientGenerator) getProxyPods(ctx context.Context) (map[string]corev1.Pod, error) {
 	pods, err := g.clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "app=k8sd-proxy"})
 	if err != nil {
@@ -90,7 +209,13 @@ func (g *k8sdClientGenerator) getProxyPods(ctx context.Context) (map[string]core
 	return podmap, nil
 }
 
-func (g *k8sdClientGenerator) NewHTTPClient(ctx context.Context, podName string) (*http.Client, error) {
+// k8sdDialer dials k8sd on a node.
+type k8sdDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// newProxyDialer returns a dialer reaching k8sd through the k8sd-proxy pod.
+func (g *k8sdClientGenerator) newProxyDialer(podName string) (k8sdDialer, error) {
 	p := proxy.Proxy{
 		Kind:         "pods",
 		Namespace:    metav1.NamespaceSystem,
@@ -103,9 +228,58 @@ func (g *k8sdClientGenerator) NewHTTPClient(ctx context.Context, podName string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy dialer: %w", err)
 	}
+	return dialer, nil
+}
 
-	// We return a http client with the same parameters as http.DefaultClient
-	// and an overridden DialContext to proxy the requests through api server.
+// fallbackDialer dials the node address directly, and falls back to the k8sd-proxy if the node cannot be reached.
+type fallbackDialer struct {
+	direct k8sdDialer
+	// fallback returns the dialer of the k8sd-proxy pod of the node. It is only called once the node cannot be
+	// reached directly, as it needs the API server of the workload cluster. It is nil if there is no fallback.
+	fallback func(ctx context.Context) (k8sdDialer, error)
+
+	cluster     ctrlclient.ObjectKey
+	unreachable *k8sdUnreachableCache
+}
+
+func (d *fallbackDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.fallback == nil {
+		return d.direct.DialContext(ctx, network, address)
+	}
+
+	key := k8sdUnreachableKey{cluster: d.cluster, address: address}
+	if !d.unreachable.isUnreachable(key, time.Now()) {
+		conn, err := d.direct.DialContext(ctx, network, address)
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		log.FromContext(ctx).V(1).Info("Failed to dial k8sd directly, falling back to the k8sd-proxy", "address", address, "error", err)
+		d.unreachable.markUnreachable(key, time.Now())
+	}
+
+	fallback, err := d.fallback(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fall back to the k8sd-proxy: %w", err)
+	}
+	return fallback.DialContext(ctx, network, address)
+}
+
+func (g *k8sdClientGenerator) NewHTTPClient(ctx context.Context, podName string) (*http.Client, error) {
+	dialer, err := g.newProxyDialer(podName)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.newHTTPClient(dialer), nil
+}
+
+// newHTTPClient returns a http client with the same parameters as http.DefaultClient
+// and an overridden DialContext to reach k8sd through the dialer.
+func (g *k8sdClientGenerator) newHTTPClient(dialer k8sdDialer) *http.Client {
 	return &http.Client{
 		Transport: &http.Transport{
 			Proxy:                 http.DefaultTransport.(*http.Transport).Proxy,
@@ -120,5 +294,5 @@ func (g *k8sdClientGenerator) NewHTTPClient(ctx context.Context, podName string)
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
 		},
 		Timeout: g.proxyClientTimeout,
-	}, nil
+	}
 }
@@ -0,0 +1,278 @@
+package ck8s
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// fakeDialer returns a connection, or err if set, and counts the dials.
+type fakeDialer struct {
+	err   error
+	dials int
+}
+
+func (d *fakeDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.dials++
+	if d.err != nil {
+		return nil, d.err
+	}
+	conn, _ := net.Pipe()
+	return conn, nil
+}
+
+func TestFallbackDialer(t *testing.T) {
+	ctx := context.Background()
+	cluster := client.ObjectKey{Namespace: "default", Name: "cluster"}
+
+	// newFallback returns a fallback to the dialer that counts its lookups of the k8sd-proxy pod.
+	newFallback := func(dialer *fakeDialer, lookups *int) func(context.Context) (k8sdDialer, error) {
+		return func(context.Context) (k8sdDialer, error) {
+			*lookups++
+			return dialer, nil
+		}
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lookups int
+		direct, fallback := &fakeDialer{}, &fakeDialer{}
+		d := &fallbackDialer{direct: direct, fallback: newFallback(fallback, &lookups), cluster: cluster, unreachable: &k8sdUnreachableCache{}}
+
+		_, err := d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(direct.dials).To(Equal(1))
+		g.Expect(fallback.dials).To(Equal(0))
+
+		// The k8sd-proxy pod is not looked up while the node is reachable.
+		g.Expect(lookups).To(Equal(0))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lookups int
+		unreachable := &k8sdUnreachableCache{}
+		direct, fallback := &fakeDialer{err: errors.New("no route to host")}, &fakeDialer{}
+		d := &fallbackDialer{direct: direct, fallback: newFallback(fallback, &lookups), cluster: cluster, unreachable: unreachable}
+
+		_, err := d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(direct.dials).To(Equal(1))
+		g.Expect(fallback.dials).To(Equal(1))
+		g.Expect(lookups).To(Equal(1))
+
+		// The unreachable address is not dialed directly again, even by the dialers of another workload cluster object.
+		d = &fallbackDialer{direct: direct, fallback: newFallback(fallback, &lookups), cluster: cluster, unreachable: unreachable}
+		_, err = d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(direct.dials).To(Equal(1))
+		g.Expect(fallback.dials).To(Equal(2))
+
+		_, err = d.DialContext(ctx, "tcp", "10.0.0.2:6400")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(direct.dials).To(Equal(2))
+
+		// The same address of another cluster is dialed directly.
+		d = &fallbackDialer{direct: direct, fallback: newFallback(fallback, &lookups), cluster: client.ObjectKey{Namespace: "default", Name: "other"}, unreachable: unreachable}
+		_, err = d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(direct.dials).To(Equal(3))
+	})
+
+	t.Run("fallback-error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		direct := &fakeDialer{err: errors.New("no route to host")}
+		d := &fallbackDialer{
+			direct: direct,
+			fallback: func(context.Context) (k8sdDialer, error) {
+				return nil, errors.New("missing k8sd proxy pod for node node")
+			},
+			cluster:     cluster,
+			unreachable: &k8sdUnreachableCache{},
+		}
+
+		_, err := d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).To(MatchError(ContainSubstring("missing k8sd proxy pod")))
+	})
+
+	t.Run("no-fallback", func(t *testing.T) {
+		g := NewWithT(t)
+
+		direct := &fakeDialer{err: errors.New("no route to host")}
+		d := &fallbackDialer{direct: direct, cluster: cluster, unreachable: &k8sdUnreachableCache{}}
+
+		_, err := d.DialContext(ctx, "tcp", "10.0.0.1:6400")
+		g.Expect(err).To(MatchError("no route to host"))
+	})
+}
+
+func TestK8sdUnreachableCache(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &k8sdUnreachableCache{}
+	key := k8sdUnreachableKey{cluster: client.ObjectKey{Namespace: "default", Name: "cluster"}, address: "10.0.0.1:6400"}
+	now := time.Now()
+
+	g.Expect(c.isUnreachable(key, now)).To(BeFalse())
+
+	c.markUnreachable(key, now)
+	g.Expect(c.isUnreachable(key, now.Add(k8sdUnreachableTTL-time.Second))).To(BeTrue())
+
+	// The address is dialed directly again once the entry expires.
+	g.Expect(c.isUnreachable(key, now.Add(k8sdUnreachableTTL))).To(BeFalse())
+	g.Expect(c.isUnreachable(key, now)).To(BeFalse())
+}
+
+func TestNewK8sdClientGenerator(t *testing.T) {
+	g := NewWithT(t)
+
+	generator, err := NewK8sdClientGenerator(&rest.Config{}, 0)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(generator.transport).To(Equal(bootstrapv1.K8sdTransportProxy))
+
+	generator, err = NewK8sdClientGenerator(&rest.Config{}, 0, WithK8sdTransport(bootstrapv1.K8sdTransportDirect))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(generator.transport).To(Equal(bootstrapv1.K8sdTransportDirect))
+
+	_, err = NewK8sdClientGenerator(&rest.Config{}, 0, WithK8sdTransport("ssh"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestGetK8sdTransport(t *testing.T) {
+	for _, tc := range []struct {
+		annotation string
+		expected   bootstrapv1.K8sdTransport
+	}{
+		{annotation: "", expected: bootstrapv1.K8sdTransportProxy},
+		{annotation: "proxy", expected: bootstrapv1.K8sdTransportProxy},
+		{annotation: "direct", expected: bootstrapv1.K8sdTransportDirect},
+		{annotation: "ssh", expected: bootstrapv1.K8sdTransportProxy},
+	} {
+		t.Run(tc.annotation, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{}
+			if tc.annotation != "" {
+				cluster.Annotations = map[string]string{bootstrapv1.K8sdTransportAnnotation: tc.annotation}
+			}
+			g.Expect(getK8sdTransport(context.Background(), cluster)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestGetK8sdProxyForMachine(t *testing.T) {
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine"},
+		Status: clusterv1.MachineStatus{
+			NodeRef:   &corev1.ObjectReference{Name: "node"},
+			Addresses: clusterv1.MachineAddresses{{Type: clusterv1.MachineInternalIP, Address: "10.0.0.1"}},
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}},
+		},
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// The node cannot be fetched from the workload cluster, but the machine address is reached directly.
+		w := &Workload{
+			Client:              fake.NewClientBuilder().Build(),
+			K8sdClientGenerator: &k8sdClientGenerator{transport: bootstrapv1.K8sdTransportDirect, unreachable: &k8sdUnreachableCache{}},
+		}
+
+		client, err := w.GetK8sdProxyForMachine(context.Background(), machine)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(client.NodeIP).To(Equal("10.0.0.1"))
+	})
+
+	t.Run("direct-node", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// The node address is used if the machine has none.
+		w := &Workload{
+			Client:              fake.NewClientBuilder().WithObjects(node).Build(),
+			K8sdClientGenerator: &k8sdClientGenerator{transport: bootstrapv1.K8sdTransportDirect, unreachable: &k8sdUnreachableCache{}},
+		}
+
+		m := machine.DeepCopy()
+		m.Status.Addresses = nil
+		client, err := w.GetK8sdProxyForMachine(context.Background(), m)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(client.NodeIP).To(Equal("10.0.0.2"))
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		w := &Workload{
+			Client:              fake.NewClientBuilder().Build(),
+			K8sdClientGenerator: &k8sdClientGenerator{transport: bootstrapv1.K8sdTransportProxy},
+		}
+
+		_, err := w.GetK8sdProxyForMachine(context.Background(), machine)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestGetK8sdProxyForControlPlaneDirect(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"metadata": {}}`))
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	g.Expect(err).ToNot(HaveOccurred())
+	microclusterPort, err := strconv.Atoi(port)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	newMachine := func(name string, address string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}},
+		}
+		if address != "" {
+			m.Status.Addresses = clusterv1.MachineAddresses{{Type: clusterv1.MachineInternalIP, Address: address}}
+		}
+		return m
+	}
+
+	// The workload cluster has no nodes or k8sd-proxy pods, as its API server is not reached.
+	w := &Workload{
+		Client:               fake.NewClientBuilder().Build(),
+		K8sdClientGenerator:  &k8sdClientGenerator{transport: bootstrapv1.K8sdTransportDirect, unreachable: &k8sdUnreachableCache{}},
+		microclusterPort:     microclusterPort,
+		controlPlaneMachines: collections.FromMachines(newMachine("cp-0", ""), newMachine("cp-1", host)),
+	}
+
+	client, err := w.GetK8sdProxyForControlPlane(context.Background(), k8sdProxyOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(client.NodeIP).To(Equal(host))
+
+	_, err = w.GetK8sdProxyForControlPlane(context.Background(), k8sdProxyOptions{IgnoreNodes: map[string]struct{}{"cp-1": {}}})
+	g.Expect(err).To(MatchError(ContainSubstring("machine cp-0 has no internal IP")))
+}
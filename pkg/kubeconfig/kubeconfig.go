@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -20,13 +21,18 @@ import (
 	"github.com/canonical/cluster-api-k8s/pkg/secret"
 )
 
+// ClientCertificateExpiryAnnotation tracks the expiry date of the client certificate of a Kubeconfig secret,
+// in RFC3339 format.
+const ClientCertificateExpiryAnnotation = "v1beta2.k8sd.io/client-certificate-expiry"
+
 var (
 	ErrDependentCertificateNotFound = errors.New("could not find secret ca")
 	ErrCertNotInKubeconfig          = errors.New("certificate not found in config")
 	ErrCAPrivateKeyNotFound         = errors.New("CA private key not found")
 )
 
-func generateKubeconfig(ctx context.Context, c client.Client, clusterName client.ObjectKey, endpoint string) ([]byte, error) {
+// generateKubeconfig generates a kubeconfig for the given user, or for the cluster admin if user is nil.
+func generateKubeconfig(ctx context.Context, c client.Client, clusterName client.ObjectKey, endpoint string, user *User) ([]byte, error) {
 	clusterCA, err := secret.GetFromNamespacedName(ctx, c, clusterName, secret.ClusterCA)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -64,7 +70,12 @@ func generateKubeconfig(ctx context.Context, c client.Client, clusterName client
 		return nil, ErrCertNotInKubeconfig
 	}
 
-	cfg, err := New(clusterName.Name, endpoint, clientCACert, clientCAKey, serverCACert)
+	var cfg *api.Config
+	if user == nil {
+		cfg, err = New(clusterName.Name, endpoint, clientCACert, clientCAKey, serverCACert)
+	} else {
+		cfg, err = NewForUser(clusterName.Name, endpoint, *user, clientCACert, clientCAKey, serverCACert)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate a kubeconfig: %w", err)
 	}
@@ -134,12 +145,106 @@ func CreateSecret(ctx context.Context, c client.Client, cluster *clusterv1.Clust
 // CreateSecretWithOwner creates the Kubeconfig secret for the given cluster name, namespace, endpoint, and owner reference.
 func CreateSecretWithOwner(ctx context.Context, c client.Client, clusterName client.ObjectKey, endpoint string, owner metav1.OwnerReference) error {
 	server := fmt.Sprintf("https://%s", endpoint)
-	out, err := generateKubeconfig(ctx, c, clusterName, server)
+	out, err := generateKubeconfig(ctx, c, clusterName, server, nil)
+	if err != nil {
+		return err
+	}
+
+	configSecret := GenerateSecretWithOwner(clusterName, out, owner)
+	if err := setClientCertificateExpiry(configSecret); err != nil {
+		return err
+	}
+	return c.Create(ctx, configSecret)
+}
+
+// RegenerateSecret regenerates the data of an existing Kubeconfig secret for the given cluster name and endpoint,
+// issuing a new client certificate signed by the current client CA.
+func RegenerateSecret(ctx context.Context, c client.Client, configSecret *corev1.Secret, endpoint string) error {
+	clusterName, _, err := secret.ParseSecretName(configSecret.Name)
+	if err != nil {
+		return fmt.Errorf("failed to parse secret name: %w", err)
+	}
+
+	server := fmt.Sprintf("https://%s", endpoint)
+	out, err := generateKubeconfig(ctx, c, client.ObjectKey{Name: clusterName, Namespace: configSecret.Namespace}, server, nil)
+	if err != nil {
+		return err
+	}
+
+	configSecret.Data[secret.KubeconfigDataName] = out
+	if err := setClientCertificateExpiry(configSecret); err != nil {
+		return err
+	}
+	return c.Update(ctx, configSecret)
+}
+
+// TrackClientCertificateExpiry returns the expiry date of the client certificate of a Kubeconfig secret.
+// Secrets that do not track the expiry date yet are annotated with it.
+func TrackClientCertificateExpiry(ctx context.Context, c client.Client, configSecret *corev1.Secret) (time.Time, error) {
+	if v, ok := configSecret.Annotations[ClientCertificateExpiryAnnotation]; ok {
+		if expiry, err := time.Parse(time.RFC3339, v); err == nil {
+			return expiry, nil
+		}
+	}
+
+	patch := client.MergeFrom(configSecret.DeepCopy())
+	if err := setClientCertificateExpiry(configSecret); err != nil {
+		return time.Time{}, err
+	}
+	if err := c.Patch(ctx, configSecret, patch); err != nil {
+		return time.Time{}, fmt.Errorf("failed to annotate kubeconfig secret: %w", err)
+	}
+
+	// NOTE: the annotation was just set from the kubeconfig data, so it is always valid.
+	expiry, _ := time.Parse(time.RFC3339, configSecret.Annotations[ClientCertificateExpiryAnnotation])
+	return expiry, nil
+}
+
+// NeedsClientCertRotation returns whether the client certificate of a Kubeconfig secret expires within the given threshold.
+func NeedsClientCertRotation(ctx context.Context, c client.Client, configSecret *corev1.Secret, threshold time.Duration) (bool, error) {
+	expiry, err := TrackClientCertificateExpiry(ctx, c, configSecret)
+	if err != nil {
+		return false, err
+	}
+	return time.Until(expiry) < threshold, nil
+}
+
+// setClientCertificateExpiry annotates a Kubeconfig secret with the expiry date of its client certificate.
+func setClientCertificateExpiry(configSecret *corev1.Secret) error {
+	expiry, err := ClientCertificateExpiry(configSecret.Data[secret.KubeconfigDataName])
 	if err != nil {
 		return err
 	}
 
-	return c.Create(ctx, GenerateSecretWithOwner(clusterName, out, owner))
+	if configSecret.Annotations == nil {
+		configSecret.Annotations = map[string]string{}
+	}
+	configSecret.Annotations[ClientCertificateExpiryAnnotation] = expiry.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// ClientCertificateExpiry returns the expiry date of the client certificate of the current context of a kubeconfig.
+func ClientCertificateExpiry(data []byte) (time.Time, error) {
+	cfg, err := clientcmd.Load(data)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+
+	currentContext, ok := cfg.Contexts[cfg.CurrentContext]
+	if !ok {
+		return time.Time{}, ErrCertNotInKubeconfig
+	}
+	authInfo, ok := cfg.AuthInfos[currentContext.AuthInfo]
+	if !ok {
+		return time.Time{}, ErrCertNotInKubeconfig
+	}
+	clientCert, err := certs.DecodeCertPEM(authInfo.ClientCertificateData)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode client certificate: %w", err)
+	} else if clientCert == nil {
+		return time.Time{}, ErrCertNotInKubeconfig
+	}
+	return clientCert.NotAfter, nil
 }
 
 // GenerateSecret returns a Kubernetes secret for the given Cluster and kubeconfig data.
@@ -0,0 +1,99 @@
+package kubeconfig
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// User is the identity a kubeconfig client certificate is issued for.
+type User struct {
+	// Name is the common name of the client certificate.
+	Name string
+	// Groups are the organizations of the client certificate.
+	Groups []string
+	// TTL is how long the client certificate is valid for.
+	TTL time.Duration
+}
+
+// NewForUser creates a new Kubeconfig for the given user using the cluster name and specified endpoint.
+func NewForUser(clusterName, endpoint string, user User, clientCACert *x509.Certificate, clientCAKey crypto.Signer, serverCACert *x509.Certificate) (*api.Config, error) {
+	clientKey, err := certs.NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create private key: %w", err)
+	}
+
+	clientCert, err := newClientCert(user, clientKey, clientCACert, clientCAKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign certificate: %w", err)
+	}
+
+	contextName := fmt.Sprintf("%s@%s", user.Name, clusterName)
+
+	return &api.Config{
+		Clusters: map[string]*api.Cluster{
+			clusterName: {
+				Server:                   endpoint,
+				CertificateAuthorityData: certs.EncodeCertPEM(serverCACert),
+			},
+		},
+		Contexts: map[string]*api.Context{
+			contextName: {
+				Cluster:  clusterName,
+				AuthInfo: user.Name,
+			},
+		},
+		AuthInfos: map[string]*api.AuthInfo{
+			user.Name: {
+				ClientKeyData:         certs.EncodePrivateKeyPEM(clientKey),
+				ClientCertificateData: certs.EncodeCertPEM(clientCert),
+			},
+		},
+		CurrentContext: contextName,
+	}, nil
+}
+
+// GenerateUserKubeconfig generates a kubeconfig for the given user of a cluster, whose client certificate
+// is signed by the client CA of the cluster.
+func GenerateUserKubeconfig(ctx context.Context, c client.Client, clusterName client.ObjectKey, endpoint string, user User) ([]byte, error) {
+	return generateKubeconfig(ctx, c, clusterName, fmt.Sprintf("https://%s", endpoint), &user)
+}
+
+// newClientCert signs a client certificate for the given user.
+// Unlike certs.Config.NewSignedCert, the validity of the certificate is set from the TTL of the user.
+func newClientCert(user User, key crypto.Signer, caCert *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate random integer for signed certificate: %w", err)
+	}
+
+	now := time.Now().UTC()
+	tmpl := x509.Certificate{
+		SerialNumber: new(big.Int).Add(serial, big.NewInt(1)),
+		Subject: pkix.Name{
+			CommonName:   user.Name,
+			Organization: user.Groups,
+		},
+		NotBefore:   caCert.NotBefore,
+		NotAfter:    now.Add(user.TTL),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	b, err := x509.CreateCertificate(rand.Reader, &tmpl, caCert, key.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signed certificate: %w", err)
+	}
+
+	return x509.ParseCertificate(b)
+}
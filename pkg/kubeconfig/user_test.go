@@ -0,0 +1,75 @@
+package kubeconfig_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/canonical/cluster-api-k8s/pkg/kubeconfig"
+	"github.com/canonical/cluster-api-k8s/pkg/secret"
+)
+
+func TestGenerateUserKubeconfig(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	clusterKey := client.ObjectKey{Name: "test", Namespace: "default"}
+	certificates := secret.Certificates{
+		&secret.Certificate{Purpose: secret.ClusterCA},
+		&secret.Certificate{Purpose: secret.ClientClusterCA},
+	}
+	g.Expect(certificates.Generate()).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		certificates[0].AsSecret(clusterKey, metav1.OwnerReference{}),
+		certificates[1].AsSecret(clusterKey, metav1.OwnerReference{}),
+	).Build()
+
+	user := kubeconfig.User{Name: "alice", Groups: []string{"developers", "viewers"}, TTL: 48 * time.Hour}
+	before := time.Now()
+	data, err := kubeconfig.GenerateUserKubeconfig(ctx, c, clusterKey, "1.2.3.4:6443", user)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cfg, err := clientcmd.Load(data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.CurrentContext).To(Equal("alice@test"))
+	g.Expect(cfg.Clusters["test"].Server).To(Equal("https://1.2.3.4:6443"))
+	g.Expect(cfg.Clusters["test"].CertificateAuthorityData).To(Equal(certificates[0].KeyPair.Cert))
+
+	clientCert, err := certs.DecodeCertPEM(cfg.AuthInfos["alice"].ClientCertificateData)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clientCert.Subject.CommonName).To(Equal("alice"))
+	g.Expect(clientCert.Subject.Organization).To(ConsistOf("developers", "viewers"))
+	g.Expect(clientCert.NotAfter).To(BeTemporally("~", before.Add(48*time.Hour), time.Minute))
+
+	clientCACert, err := certs.DecodeCertPEM(certificates[1].KeyPair.Cert)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clientCert.CheckSignatureFrom(clientCACert)).To(Succeed())
+
+	expiry, err := kubeconfig.ClientCertificateExpiry(data)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expiry).To(Equal(clientCert.NotAfter))
+}
+
+func TestGenerateUserKubeconfigMissingCA(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	_, err := kubeconfig.GenerateUserKubeconfig(context.Background(), c, client.ObjectKey{Name: "test", Namespace: "default"}, "1.2.3.4:6443", kubeconfig.User{Name: "alice", TTL: time.Hour})
+	g.Expect(err).To(MatchError(kubeconfig.ErrDependentCertificateNotFound))
+}
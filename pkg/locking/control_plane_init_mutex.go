@@ -19,171 +19,86 @@ package locking
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"time"
 
-	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-const semaphoreInformationKey = "lock-information"
+// DefaultControlPlaneInitLockDuration is how long the control plane init lock is held after it was last renewed.
+const DefaultControlPlaneInitLockDuration = 10 * time.Minute
 
-// ControlPlaneInitMutex uses a ConfigMap to synchronize cluster initialization.
+// ControlPlaneInitMutex uses a Lease to synchronize cluster initialization.
 type ControlPlaneInitMutex struct {
-	client client.Client
+	lease *LeaseLock
 }
 
 // NewControlPlaneInitMutex returns a lock that can be held by a control plane node before init.
-func NewControlPlaneInitMutex(client client.Client) *ControlPlaneInitMutex {
+func NewControlPlaneInitMutex(client client.Client, recorder record.EventRecorder) *ControlPlaneInitMutex {
 	return &ControlPlaneInitMutex{
-		client: client,
+		lease: NewLeaseLock(client, recorder, DefaultControlPlaneInitLockDuration),
 	}
 }
 
 // Lock allows a control plane node to be the first and only node to run init.
+// A lock whose holder has not renewed it in time, or whose holder Machine no longer exists, is taken over.
 func (c *ControlPlaneInitMutex) Lock(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) bool {
-	sema := newSemaphore()
-	cmName := configMapName(cluster.Name)
-	log := ctrl.LoggerFrom(ctx, "ConfigMap", klog.KRef(cluster.Namespace, cmName))
-	err := c.client.Get(ctx, client.ObjectKey{
-		Namespace: cluster.Namespace,
-		Name:      cmName,
-	}, sema.ConfigMap)
-	switch {
-	case apierrors.IsNotFound(err):
-		break
-	case err != nil:
+	leaseName := leaseName(cluster.Name)
+	log := ctrl.LoggerFrom(ctx, "Lease", klog.KRef(cluster.Namespace, leaseName))
+
+	lease, err := c.lease.Get(ctx, cluster, leaseName)
+	if err != nil {
 		log.Error(err, "Failed to acquire init lock")
 		return false
-	default: // Successfully found an existing config map.
-		info, err := sema.information()
+	}
+	if lease != nil && HolderMachine(lease) != client.ObjectKeyFromObject(machine) {
+		released, err := c.lease.ReleaseStale(ctx, cluster, lease)
 		if err != nil {
-			log.Error(err, "Failed to get information about the existing init lock")
+			log.Error(err, "Failed to release stale init lock")
 			return false
 		}
-		// The machine requesting the lock is the machine that created the lock, therefore the lock is acquired.
-		if info.MachineName == machine.Name {
-			return true
-		}
-
-		// If the machine that created the lock can not be found unlock the mutex.
-		if err := c.client.Get(ctx, client.ObjectKey{
-			Namespace: cluster.Namespace,
-			Name:      info.MachineName,
-		}, &clusterv1.Machine{}); err != nil {
-			log.Error(err, "Failed to get machine holding init lock")
-			if apierrors.IsNotFound(err) {
-				c.Unlock(ctx, cluster)
-			}
+		if !released {
+			log.Info(fmt.Sprintf("Waiting for Machine %s to initialize", HolderMachine(lease).Name))
+			return false
 		}
-		log.Info(fmt.Sprintf("Waiting for Machine %s to initialize", info.MachineName))
-		return false
-	}
-
-	// Adds owner reference, namespace and name
-	sema.setMetadata(cluster)
-	// Adds the additional information
-	if err := sema.setInformation(&information{MachineName: machine.Name}); err != nil {
-		log.Error(err, "Failed to acquire init lock while setting semaphore information")
-		return false
+		log.Info("Released stale init lock", "holder", HolderMachine(lease))
 	}
 
 	log.Info("Attempting to acquire the lock")
-	err = c.client.Create(ctx, sema.ConfigMap)
+	acquired, err := c.lease.Acquire(ctx, cluster, leaseName, machine)
 	switch {
-	case apierrors.IsAlreadyExists(err):
-		log.Info("Cannot acquire the init lock. The init lock has been acquired by someone else")
-		return false
 	case err != nil:
 		log.Error(err, "Error acquiring the init lock")
 		return false
+	case !acquired:
+		log.Info("Cannot acquire the init lock. The init lock has been acquired by someone else")
+		return false
 	default:
 		return true
 	}
 }
 
+// Renew renews the lock if it is held by the machine, so that it is not taken over while the machine initializes.
+func (c *ControlPlaneInitMutex) Renew(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	return c.lease.Renew(ctx, cluster, leaseName(cluster.Name), machine)
+}
+
 // Unlock releases the lock.
 func (c *ControlPlaneInitMutex) Unlock(ctx context.Context, cluster *clusterv1.Cluster) bool {
-	sema := newSemaphore()
-	cmName := configMapName(cluster.Name)
-	log := ctrl.LoggerFrom(ctx, "ConfigMap", klog.KRef(cluster.Namespace, cmName))
-	err := c.client.Get(ctx, client.ObjectKey{
-		Namespace: cluster.Namespace,
-		Name:      cmName,
-	}, sema.ConfigMap)
-	switch {
-	case apierrors.IsNotFound(err):
-		log.Info("Control plane init lock not found, it may have been released already")
-		return true
-	case err != nil:
+	leaseName := leaseName(cluster.Name)
+	log := ctrl.LoggerFrom(ctx, "Lease", klog.KRef(cluster.Namespace, leaseName))
+
+	if err := c.lease.Release(ctx, cluster, leaseName); err != nil {
 		log.Error(err, "Error unlocking the control plane init lock")
 		return false
-	default:
-		// Delete the config map semaphore if there is no error fetching it
-		if err := c.client.Delete(ctx, sema.ConfigMap); err != nil {
-			if apierrors.IsNotFound(err) {
-				return true
-			}
-			log.Error(err, "Error deleting the config map underlying the control plane init lock")
-			return false
-		}
-		return true
 	}
+	return true
 }
 
-type information struct {
-	MachineName string `json:"machineName"`
-}
-
-type semaphore struct {
-	*corev1.ConfigMap
-}
-
-func newSemaphore() *semaphore {
-	return &semaphore{&corev1.ConfigMap{}}
-}
-
-func configMapName(clusterName string) string {
+func leaseName(clusterName string) string {
 	return fmt.Sprintf("%s-lock", clusterName)
 }
-
-func (s semaphore) information() (*information, error) {
-	li := &information{}
-	if err := json.Unmarshal([]byte(s.Data[semaphoreInformationKey]), li); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal semaphore information: %w", err)
-	}
-	return li, nil
-}
-
-func (s semaphore) setInformation(information *information) error {
-	b, err := json.Marshal(information)
-	if err != nil {
-		return fmt.Errorf("failed to marshal semaphore information: %w", err)
-	}
-	s.Data = map[string]string{}
-	s.Data[semaphoreInformationKey] = string(b)
-	return nil
-}
-
-func (s *semaphore) setMetadata(cluster *clusterv1.Cluster) {
-	s.ObjectMeta = metav1.ObjectMeta{
-		Namespace: cluster.Namespace,
-		Name:      configMapName(cluster.Name),
-		Labels: map[string]string{
-			clusterv1.ClusterNameLabel: cluster.Name,
-		},
-		OwnerReferences: []metav1.OwnerReference{
-			{
-				APIVersion: clusterv1.GroupVersion.String(),
-				Kind:       "Cluster",
-				Name:       cluster.Name,
-				UID:        cluster.UID,
-			},
-		},
-	}
-}
@@ -0,0 +1,240 @@
+package locking
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// LockHolderMachineAnnotation records the "namespace/name" of the Machine holding a lock lease.
+	LockHolderMachineAnnotation = "v1beta2.k8sd.io/lock-holder-machine"
+
+	// StaleLockRecoveredEvent is emitted on the Cluster when a stale lock is released.
+	StaleLockRecoveredEvent = "StaleLockRecovered"
+)
+
+// LeaseLock is a lock for a Cluster backed by a coordination.k8s.io Lease. The lease records the identity of the
+// controller and the Machine holding the lock, and expires unless it is renewed on behalf of the Machine.
+type LeaseLock struct {
+	client   client.Client
+	recorder record.EventRecorder
+
+	// Identity is the identity of the controller acquiring and renewing the lease.
+	Identity string
+
+	// Duration is how long the lease is valid for after it was last renewed.
+	Duration time.Duration
+
+	now func() time.Time
+}
+
+// NewLeaseLock returns a LeaseLock with the given duration, and the hostname of the controller as its identity.
+// Stale lock recoveries are reported on the Cluster if recorder is not nil.
+func NewLeaseLock(c client.Client, recorder record.EventRecorder, duration time.Duration) *LeaseLock {
+	identity, err := os.Hostname()
+	if err != nil || identity == "" {
+		identity = "unknown"
+	}
+
+	return &LeaseLock{
+		client:   c,
+		recorder: recorder,
+		Identity: identity,
+		Duration: duration,
+		now:      time.Now,
+	}
+}
+
+// Get returns the lease with the given name in the namespace of the Cluster, or nil if it does not exist.
+func (l *LeaseLock) Get(ctx context.Context, cluster *clusterv1.Cluster, name string) (*coordinationv1.Lease, error) {
+	lease := &coordinationv1.Lease{}
+	if err := l.client.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, lease); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lease %q: %w", name, err)
+	}
+	return lease, nil
+}
+
+// Acquire tries to acquire the lease for the Machine, without blocking. The lease is renewed if the Machine already
+// holds it. Stale leases must be released with ReleaseStale before they can be acquired.
+func (l *LeaseLock) Acquire(ctx context.Context, cluster *clusterv1.Cluster, name string, machine *clusterv1.Machine) (bool, error) {
+	lease, err := l.Get(ctx, cluster, name)
+	if err != nil {
+		return false, err
+	}
+
+	if lease != nil {
+		if HolderMachine(lease) != client.ObjectKeyFromObject(machine) {
+			return false, nil
+		}
+		l.hold(lease, machine, false)
+		if err := l.client.Update(ctx, lease); err != nil {
+			return false, fmt.Errorf("failed to renew lease %q: %w", name, err)
+		}
+		return true, nil
+	}
+
+	lease = &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      name,
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: cluster.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       clusterv1.ClusterKind,
+					Name:       cluster.Name,
+					UID:        cluster.UID,
+				},
+			},
+		},
+	}
+	l.hold(lease, machine, true)
+	if err := l.client.Create(ctx, lease); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create lease %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// Renew renews the lease if it is held by the Machine. It is a no-op otherwise.
+func (l *LeaseLock) Renew(ctx context.Context, cluster *clusterv1.Cluster, name string, machine *clusterv1.Machine) error {
+	lease, err := l.Get(ctx, cluster, name)
+	if err != nil {
+		return err
+	}
+	if lease == nil || HolderMachine(lease) != client.ObjectKeyFromObject(machine) {
+		return nil
+	}
+
+	l.hold(lease, machine, false)
+	if err := l.client.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to renew lease %q: %w", name, err)
+	}
+	return nil
+}
+
+// Release deletes the lease. Releasing a lease that does not exist is not an error.
+func (l *LeaseLock) Release(ctx context.Context, cluster *clusterv1.Cluster, name string) error {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: name},
+	}
+	if err := l.client.Delete(ctx, lease); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete lease %q: %w", name, err)
+	}
+	return nil
+}
+
+// ReleaseStale releases the lease if it is stale, and reports the recovery on the Cluster. A lease is stale if
+// it is owned by a previous Cluster with the same name, if its holder Machine no longer exists, or if its holder has not renewed
+// it in time. It returns whether the lease was released.
+func (l *LeaseLock) ReleaseStale(ctx context.Context, cluster *clusterv1.Cluster, lease *coordinationv1.Lease) (bool, error) {
+	holder := HolderMachine(lease)
+
+	var message string
+	switch {
+	case isOwnedByPreviousCluster(lease, cluster):
+		message = fmt.Sprintf("Released lock %s of a previous Cluster with the same name", lease.Name)
+	case holder.Name == "":
+		message = fmt.Sprintf("Released lock %s without a holder Machine", lease.Name)
+	case l.IsExpired(lease):
+		message = fmt.Sprintf("Released lock %s of Machine %s, held by %s and not renewed since %s",
+			lease.Name, holder, ptr.Deref(lease.Spec.HolderIdentity, ""), renewTime(lease).Format(time.RFC3339))
+	default:
+		if err := l.client.Get(ctx, holder, &clusterv1.Machine{}); err == nil {
+			return false, nil
+		} else if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get Machine %s holding lease %q: %w", holder, lease.Name, err)
+		}
+		message = fmt.Sprintf("Released lock %s of Machine %s, which no longer exists", lease.Name, holder)
+	}
+
+	// NOTE: the preconditions make sure that a lease acquired or renewed in the meantime is not deleted.
+	if err := l.client.Delete(ctx, lease, client.Preconditions{UID: &lease.UID, ResourceVersion: &lease.ResourceVersion}); err != nil {
+		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to delete lease %q: %w", lease.Name, err)
+	}
+
+	if l.recorder != nil {
+		l.recorder.Event(cluster, corev1.EventTypeWarning, StaleLockRecoveredEvent, message)
+	}
+	return true, nil
+}
+
+// IsExpired returns true if the lease was not renewed within its duration.
+func (l *LeaseLock) IsExpired(lease *coordinationv1.Lease) bool {
+	duration := time.Duration(ptr.Deref(lease.Spec.LeaseDurationSeconds, 0)) * time.Second
+	return l.now().After(renewTime(lease).Add(duration))
+}
+
+// HolderMachine returns the key of the Machine holding the lease.
+func HolderMachine(lease *coordinationv1.Lease) client.ObjectKey {
+	namespace, name, found := strings.Cut(lease.GetAnnotations()[LockHolderMachineAnnotation], "/")
+	if !found {
+		return client.ObjectKey{}
+	}
+	return client.ObjectKey{Namespace: namespace, Name: name}
+}
+
+// hold sets the Machine and the controller as the holders of the lease, and renews it.
+func (l *LeaseLock) hold(lease *coordinationv1.Lease, machine *clusterv1.Machine, acquire bool) {
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[LockHolderMachineAnnotation] = client.ObjectKeyFromObject(machine).String()
+
+	now := metav1.NewMicroTime(l.now())
+	lease.Spec.HolderIdentity = ptr.To(l.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(l.Duration.Seconds()))
+	lease.Spec.RenewTime = &now
+	if acquire {
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+	}
+}
+
+// renewTime returns the last time the lease was renewed or acquired.
+func renewTime(lease *coordinationv1.Lease) time.Time {
+	switch {
+	case lease.Spec.RenewTime != nil:
+		return lease.Spec.RenewTime.Time
+	case lease.Spec.AcquireTime != nil:
+		return lease.Spec.AcquireTime.Time
+	default:
+		return lease.CreationTimestamp.Time
+	}
+}
+
+// isOwnedByPreviousCluster returns true if the lease is owned by a Cluster with the same name but a different UID.
+// This is the case if the Cluster was deleted and created again before the lease was garbage collected.
+func isOwnedByPreviousCluster(lease *coordinationv1.Lease, cluster *clusterv1.Cluster) bool {
+	if cluster.UID == "" {
+		return false
+	}
+	for _, ref := range lease.OwnerReferences {
+		if ref.Kind == clusterv1.ClusterKind && ref.Name == cluster.Name && ref.UID != cluster.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -21,20 +21,26 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/version"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/collections"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 	controlplanev1 "github.com/canonical/cluster-api-k8s/controlplane/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
 )
 
 type Func = collections.Func
 
 // MatchesKCPConfiguration returns a filter to find all machines that matches with KCP config and do not require any rollout.
 // Kubernetes version, infrastructure template, and CK8sConfig field need to be equivalent.
+// Machines upgraded in-place to a newer Kubernetes version are not rolled out back to the version of the KCP.
 func MatchesKCPConfiguration(infraConfigs map[string]*unstructured.Unstructured, machineConfigs map[string]*bootstrapv1.CK8sConfig, kcp *controlplanev1.CK8sControlPlane) func(machine *clusterv1.Machine) bool {
 	return collections.And(
-		MatchesKubernetesVersion(kcp.Spec.Version),
+		collections.Or(
+			MatchesKubernetesVersion(kcp.Spec.Version),
+			UpgradedInPlaceToNewerKubernetesVersion(kcp.Spec.Version),
+		),
 		MatchesCK8sBootstrapConfig(machineConfigs, kcp),
 		MatchesTemplateClonedFrom(infraConfigs, kcp),
 	)
@@ -83,6 +89,32 @@ func MatchesKubernetesVersion(kubernetesVersion string) Func {
 	}
 }
 
+// UpgradedInPlaceToNewerKubernetesVersion returns a filter to find all machines that were upgraded in-place to a
+// newer Kubernetes version than the given one.
+func UpgradedInPlaceToNewerKubernetesVersion(kubernetesVersion string) Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil || machine.Spec.Version == nil {
+			return false
+		}
+
+		// NOTE: The version of the machine is set to the version reported by its node once it is upgraded in-place
+		// to a version, which may be another patch version than the requested one.
+		if inplace.GetUpgradeVersion(machine.Annotations[bootstrapv1.InPlaceUpgradeReleaseAnnotation]) == "" {
+			return false
+		}
+
+		machineVersion, err := version.ParseSemantic(*machine.Spec.Version)
+		if err != nil {
+			return false
+		}
+		targetVersion, err := version.ParseSemantic(kubernetesVersion)
+		if err != nil {
+			return false
+		}
+		return machineVersion.GreaterThan(targetVersion)
+	}
+}
+
 // MatchesCK8sBootstrapConfig checks if machine's CK8sConfigSpec is equivalent with KCP's CK8sConfigSpec.
 func MatchesCK8sBootstrapConfig(machineConfigs map[string]*bootstrapv1.CK8sConfig, kcp *controlplanev1.CK8sControlPlane) Func {
 	return func(machine *clusterv1.Machine) bool {
@@ -105,11 +137,41 @@ func MatchesCK8sBootstrapConfig(machineConfigs map[string]*bootstrapv1.CK8sConfi
 		}
 
 		kcpConfig := kcp.Spec.CK8sConfigSpec.DeepCopy()
+		machineSpec := machineConfig.Spec.DeepCopy()
 
 		// KCP version check is handled elsewhere
 		kcpConfig.Version = ""
-		machineConfig.Spec.Version = ""
+		machineSpec.Version = ""
+
+		// The k8sd-proxy configuration is applied to the workload cluster without rolling out the machines.
+		kcpConfig.ControlPlaneConfig.K8sdProxy = nil
+		machineSpec.ControlPlaneConfig.K8sdProxy = nil
 
-		return reflect.DeepEqual(&machineConfig.Spec, kcpConfig)
+		return reflect.DeepEqual(machineSpec, kcpConfig)
+	}
+}
+
+// MatchesAuditPolicyHash returns a filter to find all machines that were bootstrapped with the given audit policy.
+// Machines are always matching if the hash is empty, or if they have not been bootstrapped yet.
+func MatchesAuditPolicyHash(machineConfigs map[string]*bootstrapv1.CK8sConfig, hash string) Func {
+	return func(machine *clusterv1.Machine) bool {
+		if machine == nil {
+			return false
+		}
+		if hash == "" {
+			return true
+		}
+
+		machineConfig, found := machineConfigs[machine.Name]
+		if !found {
+			// Return true here because failing to get CK8sConfig should not be considered as unmatching.
+			return true
+		}
+
+		machineHash, ok := machineConfig.GetAnnotations()[bootstrapv1.AuditPolicyHashAnnotation]
+		if !ok {
+			return true
+		}
+		return machineHash == hash
 	}
 }
@@ -189,6 +189,13 @@ func TestMatchesCK8sBootstrapConfig(t *testing.T) {
 			g.Expect(match).To(BeTrue())
 		})
 
+		t.Run("by returning true if only the k8sd-proxy configs don't match", func(t *testing.T) {
+			g := NewWithT(t)
+			machineConfigs[m.Name].Spec.ControlPlaneConfig.K8sdProxy = &bootstrapv1.K8sdProxyConfig{Image: "registry.local/k8sd-proxy:v0.3.0"}
+			match := MatchesCK8sBootstrapConfig(machineConfigs, kcp)(m)
+			g.Expect(match).To(BeTrue())
+		})
+
 		t.Run("by returning false if post commands don't match", func(t *testing.T) {
 			g := NewWithT(t)
 			machineConfigs[m.Name].Spec.PostRunCommands = []string{"new-test"}
@@ -330,3 +337,117 @@ func TestMatchesKubernetesVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestUpgradedInPlaceToNewerKubernetesVersion(t *testing.T) {
+	upgradedMachine := func(release string, version string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: release}},
+			Spec:       clusterv1.MachineSpec{Version: ptr.To(version)},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		kubernetesVersion string
+		expectedMatch     bool
+		machine           *clusterv1.Machine
+	}{
+		{
+			name:              "returns true if machine was upgraded in-place to a newer version",
+			kubernetesVersion: "v1.30.0",
+			expectedMatch:     true,
+			machine:           upgradedMachine("version=1.31.2", "v1.31.2"),
+		},
+		{
+			name:              "returns false if machine was upgraded in-place to an older version",
+			kubernetesVersion: "v1.32.0",
+			expectedMatch:     false,
+			machine:           upgradedMachine("version=v1.31.2", "v1.31.2"),
+		},
+		{
+			name:              "returns true if machine node reports another patch version than its in-place upgrade",
+			kubernetesVersion: "v1.30.0",
+			expectedMatch:     true,
+			machine:           upgradedMachine("version=v1.31.2", "v1.31.3"),
+		},
+		{
+			name:              "returns false if machine version is not newer after its in-place upgrade",
+			kubernetesVersion: "v1.30.0",
+			expectedMatch:     false,
+			machine:           upgradedMachine("version=v1.31.2", "v1.30.0"),
+		},
+		{
+			name:              "returns false if machine was upgraded in-place to a channel",
+			kubernetesVersion: "v1.30.0",
+			expectedMatch:     false,
+			machine:           upgradedMachine("channel=1.31-classic/stable", "v1.31.2"),
+		},
+		{
+			name:          "returns false if machine is nil",
+			expectedMatch: false,
+			machine:       nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			match := UpgradedInPlaceToNewerKubernetesVersion(test.kubernetesVersion)(test.machine)
+			g.Expect(match).To(Equal(test.expectedMatch))
+		})
+	}
+}
+
+func TestMatchesAuditPolicyHash(t *testing.T) {
+	machine := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	configWithHash := func(hash string) map[string]*bootstrapv1.CK8sConfig {
+		return map[string]*bootstrapv1.CK8sConfig{
+			"test": {ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{bootstrapv1.AuditPolicyHashAnnotation: hash}}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		hash           string
+		machineConfigs map[string]*bootstrapv1.CK8sConfig
+		expectedMatch  bool
+	}{
+		{
+			name:           "returns true if audit logging is not configured",
+			machineConfigs: configWithHash("abc"),
+			expectedMatch:  true,
+		},
+		{
+			name:           "returns true if the config is missing",
+			hash:           "abc",
+			machineConfigs: map[string]*bootstrapv1.CK8sConfig{},
+			expectedMatch:  true,
+		},
+		{
+			name:           "returns true if the machine is not bootstrapped yet",
+			hash:           "abc",
+			machineConfigs: map[string]*bootstrapv1.CK8sConfig{"test": {}},
+			expectedMatch:  true,
+		},
+		{
+			name:           "returns true if the hash matches",
+			hash:           "abc",
+			machineConfigs: configWithHash("abc"),
+			expectedMatch:  true,
+		},
+		{
+			name:           "returns false if the hash does not match",
+			hash:           "abc",
+			machineConfigs: configWithHash("def"),
+			expectedMatch:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewWithT(t)
+			match := MatchesAuditPolicyHash(test.machineConfigs, test.hash)(machine)
+			g.Expect(match).To(Equal(test.expectedMatch))
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// minRequeueAfter is the minimum time to wait before checking again if a maintenance window is open.
+const minRequeueAfter = time.Minute
+
+// closesAtSearchLimit is how far in the future the end of the open maintenance windows is searched for.
+const closesAtSearchLimit = 7 * 24 * time.Hour
+
+// window is a parsed maintenance window.
+type window struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// Windows are the maintenance windows disruptive operations are restricted to.
+// A nil *Windows is always open.
+type Windows struct {
+	windows  []window
+	location *time.Location
+}
+
+// New parses the maintenance window policy. It returns nil if the policy is nil.
+func New(policy *bootstrapv1.MaintenanceWindowPolicy) (*Windows, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	w := &Windows{}
+	for _, spec := range policy.Windows {
+		// NOTE: The time zone of the schedule is set by the policy, so that it is the same for all windows.
+		if strings.HasPrefix(spec.Schedule, "TZ=") || strings.HasPrefix(spec.Schedule, "CRON_TZ=") {
+			return nil, fmt.Errorf("invalid cron schedule %q of maintenance window, the time zone is set by the policy", spec.Schedule)
+		}
+		schedule, err := cron.ParseStandard(spec.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron schedule %q of maintenance window: %w", spec.Schedule, err)
+		}
+		if spec.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %s of maintenance window %q, expected a positive duration", spec.Duration.Duration, spec.Schedule)
+		}
+		w.windows = append(w.windows, window{schedule: schedule, duration: spec.Duration.Duration})
+	}
+	if len(w.windows) == 0 {
+		return nil, fmt.Errorf("expected at least one maintenance window")
+	}
+
+	location, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", policy.Timezone, err)
+	}
+	w.location = location
+
+	return w, nil
+}
+
+// FromAnnotations parses the maintenance windows configured with annotations on the object.
+// It returns nil if there are none.
+func FromAnnotations(obj client.Object) (*Windows, error) {
+	value := obj.GetAnnotations()[bootstrapv1.MaintenanceWindowsAnnotation]
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	policy := &bootstrapv1.MaintenanceWindowPolicy{
+		Timezone: obj.GetAnnotations()[bootstrapv1.MaintenanceWindowTimezoneAnnotation],
+	}
+	for _, spec := range strings.Split(value, ";") {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("invalid maintenance window %q, expected a cron schedule with 5 fields followed by a duration", strings.TrimSpace(spec))
+		}
+
+		duration, err := time.ParseDuration(fields[5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of maintenance window %q: %w", strings.TrimSpace(spec), err)
+		}
+		policy.Windows = append(policy.Windows, bootstrapv1.MaintenanceWindow{
+			Schedule: strings.Join(fields[:5], " "),
+			Duration: metav1.Duration{Duration: duration},
+		})
+	}
+
+	return New(policy)
+}
+
+// IsOpen checks if a maintenance window is open at the given time.
+func (w *Windows) IsOpen(now time.Time) bool {
+	if w == nil {
+		return true
+	}
+
+	now = now.In(w.location)
+	for _, win := range w.windows {
+		// The window is open if it opened during the last duration.
+		if start := win.schedule.Next(now.Add(-win.duration)); !start.IsZero() && !start.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next maintenance window opens after the given time, or the given time if a window
+// is open. It returns the zero time if no window ever opens.
+func (w *Windows) NextOpen(now time.Time) time.Time {
+	if w.IsOpen(now) {
+		return now
+	}
+
+	var next time.Time
+	for _, win := range w.windows {
+		start := win.schedule.Next(now.In(w.location))
+		if !start.IsZero() && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return next
+}
+
+// ClosesAt returns when the open maintenance windows close after the given time, including the windows that
+// open before then. It returns the zero time if no window is open, or if there are no windows. The search stops
+// a week after the given time.
+func (w *Windows) ClosesAt(now time.Time) time.Time {
+	if w == nil || !w.IsOpen(now) {
+		return time.Time{}
+	}
+
+	closesAt, limit := now, now.Add(closesAtSearchLimit)
+	for extended := true; extended && closesAt.Before(limit); {
+		extended = false
+		for _, win := range w.windows {
+			start := win.schedule.Next(now.In(w.location).Add(-win.duration))
+			for ; !start.IsZero() && !start.After(closesAt) && closesAt.Before(limit); start = win.schedule.Next(start) {
+				if end := start.Add(win.duration); end.After(closesAt) {
+					closesAt, extended = end, true
+				}
+			}
+		}
+	}
+	if closesAt.After(limit) {
+		return limit
+	}
+	return closesAt
+}
+
+// Allow checks if a new disruptive operation may start at the given time. Otherwise, the object is marked with
+// the MaintenanceWindow condition set to false, and the time to wait for the next maintenance window is returned.
+// The condition is set back to true once a window is open.
+func Allow(obj conditions.Setter, w *Windows, now time.Time, operation string) (bool, time.Duration) {
+	if w.IsOpen(now) {
+		if conditions.Has(obj, bootstrapv1.MaintenanceWindowCondition) {
+			conditions.MarkTrue(obj, bootstrapv1.MaintenanceWindowCondition)
+		}
+		return true, 0
+	}
+
+	next := w.NextOpen(now)
+	if next.IsZero() {
+		conditions.MarkFalse(obj, bootstrapv1.MaintenanceWindowCondition, bootstrapv1.WaitingForMaintenanceWindowReason, clusterv1.ConditionSeverityInfo, "Waiting for a maintenance window to start %s, but no window opens in the next years", operation)
+		return false, time.Hour
+	}
+
+	conditions.MarkFalse(obj, bootstrapv1.MaintenanceWindowCondition, bootstrapv1.WaitingForMaintenanceWindowReason, clusterv1.ConditionSeverityInfo, "Waiting for the maintenance window at %s to start %s", next.Format(time.RFC3339), operation)
+	return false, max(next.Sub(now), minRequeueAfter)
+}
@@ -0,0 +1,235 @@
+package maintenance_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/maintenance"
+)
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("failed to parse time %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		policy    *bootstrapv1.MaintenanceWindowPolicy
+		expectNil bool
+		expectErr bool
+	}{
+		{
+			name:      "none",
+			expectNil: true,
+		},
+		{
+			name: "valid",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{
+				Windows: []bootstrapv1.MaintenanceWindow{
+					{Schedule: "0 22 * * mon-fri", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+					{Schedule: "*/30 1,3 1-7/2 JAN,jul 0", Duration: metav1.Duration{Duration: 10 * time.Minute}},
+				},
+				Timezone: "Europe/Berlin",
+			},
+		},
+		{
+			name:      "noWindows",
+			policy:    &bootstrapv1.MaintenanceWindowPolicy{},
+			expectErr: true,
+		},
+		{
+			name: "invalidSchedule",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+				{Schedule: "0 24 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "missingField",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+				{Schedule: "0 22 * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "scheduleTimezone",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+				{Schedule: "CRON_TZ=Europe/Berlin 0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalidDuration",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+				{Schedule: "0 22 * * *"},
+			}},
+			expectErr: true,
+		},
+		{
+			name: "invalidTimezone",
+			policy: &bootstrapv1.MaintenanceWindowPolicy{
+				Windows:  []bootstrapv1.MaintenanceWindow{{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+				Timezone: "Mars/Olympus_Mons",
+			},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			w, err := maintenance.New(tc.policy)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			if tc.expectNil {
+				g.Expect(w).To(BeNil())
+			} else {
+				g.Expect(w).ToNot(BeNil())
+			}
+		})
+	}
+}
+
+func TestIsOpen(t *testing.T) {
+	// Weekdays from 22:00 to 00:00 in Berlin, and the first Sunday of the month from 06:00 to 08:00.
+	w, err := maintenance.New(&bootstrapv1.MaintenanceWindowPolicy{
+		Windows: []bootstrapv1.MaintenanceWindow{
+			{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			{Schedule: "0 6 1-7 * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+		},
+		Timezone: "Europe/Berlin",
+	})
+	if err != nil {
+		t.Fatalf("failed to parse maintenance windows: %v", err)
+	}
+
+	for _, tc := range []struct {
+		now      string
+		open     bool
+		nextOpen string
+	}{
+		// Wednesday 2024-07-10, 22:30 in Berlin.
+		{now: "2024-07-10T20:30:00Z", open: true, nextOpen: "2024-07-10T20:30:00Z"},
+		// Wednesday, 21:59 in Berlin.
+		{now: "2024-07-10T19:59:00Z", open: false, nextOpen: "2024-07-10T20:00:00Z"},
+		// Thursday, 00:00 in Berlin, the window just closed.
+		{now: "2024-07-10T22:00:00Z", open: false, nextOpen: "2024-07-11T20:00:00Z"},
+		// Saturday, 12:00 in Berlin.
+		{now: "2024-07-13T10:00:00Z", open: false, nextOpen: "2024-07-15T20:00:00Z"},
+		// Saturday 2024-08-03, 07:00 in Berlin, days 1-7 of the month open the window in the morning.
+		{now: "2024-08-03T05:00:00Z", open: true, nextOpen: "2024-08-03T05:00:00Z"},
+	} {
+		t.Run(tc.now, func(t *testing.T) {
+			g := NewWithT(t)
+
+			now := mustParseTime(t, tc.now)
+			g.Expect(w.IsOpen(now)).To(Equal(tc.open))
+			g.Expect(w.NextOpen(now).Equal(mustParseTime(t, tc.nextOpen))).To(BeTrue(), "next open at %s", w.NextOpen(now))
+		})
+	}
+}
+
+func TestIsOpenDayOfMonthOrDayOfWeek(t *testing.T) {
+	g := NewWithT(t)
+
+	// As in cron, the day matches if either the day of month or the day of week matches.
+	w, err := maintenance.New(&bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+		{Schedule: "0 0 15 * SUN", Duration: metav1.Duration{Duration: time.Hour}},
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(w.IsOpen(mustParseTime(t, "2024-07-14T00:30:00Z"))).To(BeTrue())  // Sunday
+	g.Expect(w.IsOpen(mustParseTime(t, "2024-07-15T00:30:00Z"))).To(BeTrue())  // 15th
+	g.Expect(w.IsOpen(mustParseTime(t, "2024-07-16T00:30:00Z"))).To(BeFalse()) // Tuesday
+}
+
+func TestClosesAt(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect((*maintenance.Windows)(nil).ClosesAt(time.Now()).IsZero()).To(BeTrue())
+
+	w, err := maintenance.New(&bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+		{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+		{Schedule: "0 23 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The windows overlap, so they close once the last one closes.
+	g.Expect(w.ClosesAt(mustParseTime(t, "2024-07-15T22:30:00Z"))).To(BeTemporally("==", mustParseTime(t, "2024-07-16T01:00:00Z")))
+	g.Expect(w.ClosesAt(mustParseTime(t, "2024-07-16T00:30:00Z"))).To(BeTemporally("==", mustParseTime(t, "2024-07-16T01:00:00Z")))
+	g.Expect(w.ClosesAt(mustParseTime(t, "2024-07-16T12:00:00Z")).IsZero()).To(BeTrue())
+
+	// The search stops a week later for windows that never close.
+	w, err = maintenance.New(&bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+		{Schedule: "* * * * *", Duration: metav1.Duration{Duration: time.Hour}},
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(w.ClosesAt(mustParseTime(t, "2024-07-16T12:00:00Z"))).To(BeTemporally("==", mustParseTime(t, "2024-07-23T12:00:00Z")))
+}
+
+func TestFromAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	md := &clusterv1.MachineDeployment{}
+	w, err := maintenance.FromAnnotations(md)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(w).To(BeNil())
+
+	md.Annotations = map[string]string{
+		bootstrapv1.MaintenanceWindowsAnnotation:        "0 22 * * 1-5 2h; 0 0 * * SAT 8h",
+		bootstrapv1.MaintenanceWindowTimezoneAnnotation: "America/New_York",
+	}
+	w, err = maintenance.FromAnnotations(md)
+	g.Expect(err).ToNot(HaveOccurred())
+	// Saturday 2024-07-13, 07:00 in New York.
+	g.Expect(w.IsOpen(mustParseTime(t, "2024-07-13T11:00:00Z"))).To(BeTrue())
+	// Saturday 2024-07-13, 09:00 in New York.
+	g.Expect(w.IsOpen(mustParseTime(t, "2024-07-13T13:00:00Z"))).To(BeFalse())
+
+	md.Annotations[bootstrapv1.MaintenanceWindowsAnnotation] = "0 22 * * 1-5"
+	_, err = maintenance.FromAnnotations(md)
+	g.Expect(err).To(HaveOccurred())
+
+	md.Annotations[bootstrapv1.MaintenanceWindowsAnnotation] = "0 22 * * 1-5 2"
+	_, err = maintenance.FromAnnotations(md)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestAllow(t *testing.T) {
+	g := NewWithT(t)
+
+	w, err := maintenance.New(&bootstrapv1.MaintenanceWindowPolicy{Windows: []bootstrapv1.MaintenanceWindow{
+		{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+	}})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	md := &clusterv1.MachineDeployment{}
+
+	// Without maintenance windows, operations are always allowed and the condition is not set.
+	allowed, _ := maintenance.Allow(md, nil, mustParseTime(t, "2024-07-10T12:00:00Z"), "the rollout")
+	g.Expect(allowed).To(BeTrue())
+	g.Expect(conditions.Has(md, bootstrapv1.MaintenanceWindowCondition)).To(BeFalse())
+
+	allowed, requeueAfter := maintenance.Allow(md, w, mustParseTime(t, "2024-07-10T12:00:00Z"), "the rollout")
+	g.Expect(allowed).To(BeFalse())
+	g.Expect(requeueAfter).To(Equal(10 * time.Hour))
+	g.Expect(conditions.IsFalse(md, bootstrapv1.MaintenanceWindowCondition)).To(BeTrue())
+	g.Expect(conditions.GetReason(md, bootstrapv1.MaintenanceWindowCondition)).To(Equal(bootstrapv1.WaitingForMaintenanceWindowReason))
+	g.Expect(conditions.GetMessage(md, bootstrapv1.MaintenanceWindowCondition)).To(ContainSubstring("the rollout"))
+
+	allowed, _ = maintenance.Allow(md, w, mustParseTime(t, "2024-07-10T23:00:00Z"), "the rollout")
+	g.Expect(allowed).To(BeTrue())
+	g.Expect(conditions.IsTrue(md, bootstrapv1.MaintenanceWindowCondition)).To(BeTrue())
+}
@@ -0,0 +1,80 @@
+// Package paused implements helpers for the controllers to honor the paused state of a Cluster and its objects.
+package paused
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/annotations"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// PausedCondition is set on the objects whose reconciliation is paused, either because
+	// Cluster.Spec.Paused is set or because the object has the paused annotation.
+	// The condition is removed once the reconciliation resumes.
+	PausedCondition clusterv1.ConditionType = "Paused"
+
+	// PausedReason documents the reconciliation of an object being paused.
+	PausedReason = "Paused"
+)
+
+// SetPausedCondition reflects the paused state of the object in its Paused condition, without patching the object.
+// It returns whether the reconciliation of the object is paused, and whether the condition changed.
+func SetPausedCondition(cluster *clusterv1.Cluster, obj conditions.Setter) (isPaused bool, changed bool) {
+	isPaused = annotations.IsPaused(cluster, obj)
+	current := conditions.Get(obj, PausedCondition)
+
+	if !isPaused {
+		if current == nil {
+			return false, false
+		}
+		conditions.Delete(obj, PausedCondition)
+		return false, true
+	}
+
+	var reasons []string
+	if cluster.Spec.Paused {
+		reasons = append(reasons, "Cluster spec.paused is set to true")
+	}
+	if annotations.HasPaused(obj) {
+		reasons = append(reasons, fmt.Sprintf("Object has the %s annotation", clusterv1.PausedAnnotation))
+	}
+	message := strings.Join(reasons, ", ")
+
+	if current != nil && current.Status == corev1.ConditionTrue && current.Message == message {
+		return true, false
+	}
+	conditions.Set(obj, &clusterv1.Condition{
+		Type:     PausedCondition,
+		Status:   corev1.ConditionTrue,
+		Reason:   PausedReason,
+		Severity: clusterv1.ConditionSeverityNone,
+		Message:  message,
+	})
+	return true, true
+}
+
+// EnsurePausedCondition reflects the paused state of the object in its Paused condition, and patches the object
+// if the condition changed. It returns whether the reconciliation of the object is paused.
+func EnsurePausedCondition(ctx context.Context, c client.Client, cluster *clusterv1.Cluster, obj conditions.Setter) (bool, error) {
+	patchHelper, err := patch.NewHelper(obj, c)
+	if err != nil {
+		return false, fmt.Errorf("failed to create patch helper: %w", err)
+	}
+
+	isPaused, changed := SetPausedCondition(cluster, obj)
+	if !changed {
+		return isPaused, nil
+	}
+
+	if err := patchHelper.Patch(ctx, obj, patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{PausedCondition}}); err != nil {
+		return isPaused, fmt.Errorf("failed to patch paused condition: %w", err)
+	}
+	return isPaused, nil
+}
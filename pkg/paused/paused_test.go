@@ -0,0 +1,89 @@
+package paused_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/canonical/cluster-api-k8s/pkg/paused"
+)
+
+func TestSetPausedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	cluster := &clusterv1.Cluster{}
+	machine := &clusterv1.Machine{}
+
+	isPaused, changed := paused.SetPausedCondition(cluster, machine)
+	g.Expect(isPaused).To(BeFalse())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(conditions.Has(machine, paused.PausedCondition)).To(BeFalse())
+
+	cluster.Spec.Paused = true
+	isPaused, changed = paused.SetPausedCondition(cluster, machine)
+	g.Expect(isPaused).To(BeTrue())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(conditions.IsTrue(machine, paused.PausedCondition)).To(BeTrue())
+	g.Expect(conditions.GetMessage(machine, paused.PausedCondition)).To(Equal("Cluster spec.paused is set to true"))
+
+	isPaused, changed = paused.SetPausedCondition(cluster, machine)
+	g.Expect(isPaused).To(BeTrue())
+	g.Expect(changed).To(BeFalse())
+
+	machine.SetAnnotations(map[string]string{clusterv1.PausedAnnotation: ""})
+	isPaused, changed = paused.SetPausedCondition(cluster, machine)
+	g.Expect(isPaused).To(BeTrue())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(conditions.GetMessage(machine, paused.PausedCondition)).To(ContainSubstring(clusterv1.PausedAnnotation))
+
+	cluster.Spec.Paused = false
+	machine.SetAnnotations(nil)
+	isPaused, changed = paused.SetPausedCondition(cluster, machine)
+	g.Expect(isPaused).To(BeFalse())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(conditions.Has(machine, paused.PausedCondition)).To(BeFalse())
+}
+
+func TestEnsurePausedCondition(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       clusterv1.ClusterSpec{Paused: true},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-machine", Namespace: "default"},
+		Spec:       clusterv1.MachineSpec{ClusterName: "test"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(machine).WithStatusSubresource(machine).Build()
+
+	getMachine := func() *clusterv1.Machine {
+		m := &clusterv1.Machine{}
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(machine), m)).To(Succeed())
+		return m
+	}
+
+	isPaused, err := paused.EnsurePausedCondition(ctx, c, cluster, machine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(isPaused).To(BeTrue())
+	g.Expect(conditions.IsTrue(getMachine(), paused.PausedCondition)).To(BeTrue())
+
+	cluster.Spec.Paused = false
+	isPaused, err = paused.EnsurePausedCondition(ctx, c, cluster, machine)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(isPaused).To(BeFalse())
+	g.Expect(conditions.Has(getMachine(), paused.PausedCondition)).To(BeFalse())
+}
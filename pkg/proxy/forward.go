@@ -0,0 +1,240 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultIdleTimeout = 5 * time.Minute
+
+	acceptRetryDelay = 100 * time.Millisecond
+	copyBufferSize   = 32 * 1024
+)
+
+// errIdleTimeout is returned when a forwarded connection is idle for longer than the idle timeout.
+var errIdleTimeout = errors.New("connection idle timeout")
+
+// Forwarder forwards the TCP connections it accepts to k8sd on the node. It runs in the k8sd-proxy pods, which the
+// Dialer port-forwards to through the API server.
+type Forwarder struct {
+	target      func() (string, error)
+	metrics     *Metrics
+	dialTimeout time.Duration
+	idleTimeout time.Duration
+}
+
+// NewForwarder creates a new forwarder to the address returned by target, which is called for each connection.
+func NewForwarder(target func() (string, error), metrics *Metrics, options ...func(*Forwarder) error) (*Forwarder, error) {
+	if target == nil {
+		return nil, errors.New("target required")
+	}
+	if metrics == nil {
+		return nil, errors.New("metrics required")
+	}
+
+	forwarder := &Forwarder{
+		target:  target,
+		metrics: metrics,
+	}
+
+	for _, option := range options {
+		if err := option(forwarder); err != nil {
+			return nil, err
+		}
+	}
+
+	if forwarder.dialTimeout == 0 {
+		forwarder.dialTimeout = defaultTimeout
+	}
+	if forwarder.idleTimeout == 0 {
+		forwarder.idleTimeout = defaultIdleTimeout
+	}
+	return forwarder, nil
+}
+
+// ForwardDialTimeout sets how long the forwarder waits at most to connect to k8sd.
+func ForwardDialTimeout(duration time.Duration) func(*Forwarder) error {
+	return func(f *Forwarder) error {
+		if duration < 0 {
+			return fmt.Errorf("invalid dial timeout %s", duration)
+		}
+		f.dialTimeout = duration
+		return nil
+	}
+}
+
+// ForwardIdleTimeout sets how long a forwarded connection may go without traffic in either direction before it is
+// closed. Unlike socat's -t, a connection is not closed shortly after one side closes it, while the other side is
+// still sending data.
+func ForwardIdleTimeout(duration time.Duration) func(*Forwarder) error {
+	return func(f *Forwarder) error {
+		if duration < 0 {
+			return fmt.Errorf("invalid idle timeout %s", duration)
+		}
+		f.idleTimeout = duration
+		return nil
+	}
+}
+
+// Serve accepts connections on the listener and forwards them to k8sd, until the context is cancelled.
+func (f *Forwarder) Serve(ctx context.Context, l net.Listener) error {
+	log := log.FromContext(ctx)
+
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			log.Error(err, "Failed to accept connection, retrying...")
+			time.Sleep(acceptRetryDelay)
+			continue
+		}
+
+		go f.forward(ctx, conn)
+	}
+}
+
+// Ready checks that k8sd can be reached.
+func (f *Forwarder) Ready(ctx context.Context) error {
+	conn, err := f.dial(ctx)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dial connects to k8sd.
+func (f *Forwarder) dial(ctx context.Context) (net.Conn, error) {
+	address, err := f.target()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve k8sd address: %w", err)
+	}
+
+	start := time.Now()
+	dialer := &net.Dialer{Timeout: f.dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	f.metrics.dialDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial k8sd at %s: %w", address, err)
+	}
+
+	return conn, nil
+}
+
+// forward forwards the client connection to k8sd, until both sides are closed or the connection is idle.
+func (f *Forwarder) forward(ctx context.Context, client net.Conn) {
+	log := log.FromContext(ctx).WithValues("client", client.RemoteAddr().String())
+	defer client.Close()
+
+	upstream, err := f.dial(ctx)
+	if err != nil {
+		f.metrics.connections.WithLabelValues("error").Inc()
+		log.Error(err, "Failed to forward connection")
+		return
+	}
+	defer upstream.Close()
+
+	f.metrics.connections.WithLabelValues("success").Inc()
+	f.metrics.activeConnections.Inc()
+	defer f.metrics.activeConnections.Dec()
+
+	p := &pipe{idleTimeout: f.idleTimeout}
+	p.touch()
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- p.copy(upstream, client, f.metrics.bytes.WithLabelValues("upstream"))
+	}()
+	go func() {
+		errs <- p.copy(client, upstream, f.metrics.bytes.WithLabelValues("downstream"))
+	}()
+
+	for range 2 {
+		if err := <-errs; err != nil {
+			if errors.Is(err, errIdleTimeout) {
+				f.metrics.idleTimeouts.Inc()
+				log.V(1).Info("Closing idle connection")
+			}
+
+			// NOTE: Closing both connections unblocks the other direction.
+			return
+		}
+	}
+}
+
+// pipe tracks the activity of a forwarded connection in both directions.
+type pipe struct {
+	idleTimeout time.Duration
+
+	// lastActivity is when data was last read in either direction, in Unix nanoseconds.
+	lastActivity atomic.Int64
+}
+
+func (p *pipe) touch() {
+	p.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (p *pipe) idle() bool {
+	return time.Since(time.Unix(0, p.lastActivity.Load())) >= p.idleTimeout
+}
+
+// copy copies data from src to dst. It returns nil once src is closed and the end of the stream was propagated to
+// dst, so that the other direction keeps going, or an error if the connection must be closed.
+func (p *pipe) copy(dst net.Conn, src net.Conn, bytes prometheus.Counter) error {
+	buf := make([]byte, copyBufferSize)
+	for {
+		if err := src.SetReadDeadline(time.Now().Add(p.idleTimeout)); err != nil {
+			return err
+		}
+
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.touch()
+			if err := dst.SetWriteDeadline(time.Now().Add(p.idleTimeout)); err != nil {
+				return err
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+			bytes.Add(float64(n))
+		}
+
+		switch {
+		case err == nil:
+		case errors.Is(err, io.EOF):
+			if cw, ok := dst.(interface{ CloseWrite() error }); ok && cw.CloseWrite() == nil {
+				return nil
+			}
+			return err
+		case isTimeout(err):
+			// NOTE: The connection is only idle if there was no traffic in the other direction either.
+			if p.idle() {
+				return errIdleTimeout
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// isTimeout checks if the error is a network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
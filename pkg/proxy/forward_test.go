@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// startEchoServer starts a TCP server that echoes back what it receives, until the client closes the connection.
+func startEchoServer(t *testing.T, network string, address string) string {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Skipf("failed to listen on %s: %v", address, err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// startForwarder starts a forwarder to the target address, and returns the address it listens on.
+func startForwarder(t *testing.T, target string, options ...func(*Forwarder) error) (string, *Metrics) {
+	metrics := NewMetrics(prometheus.NewRegistry())
+	forwarder, err := NewForwarder(func() (string, error) { return target, nil }, metrics, options...)
+	if err != nil {
+		t.Fatalf("failed to create forwarder: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = forwarder.Serve(ctx, l) }()
+
+	return l.Addr().String(), metrics
+}
+
+func TestForwarder(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		network string
+		address string
+	}{
+		{name: "ipv4", network: "tcp4", address: "127.0.0.1:0"},
+		{name: "ipv6", network: "tcp6", address: "[::1]:0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			address, metrics := startForwarder(t, startEchoServer(t, tc.network, tc.address))
+
+			conn, err := net.Dial("tcp", address)
+			g.Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("hello"))
+			g.Expect(err).ToNot(HaveOccurred())
+			// NOTE: The echo server replies after the end of the stream, as a slow k8sd request would.
+			g.Expect(conn.(*net.TCPConn).CloseWrite()).To(Succeed())
+
+			b, err := io.ReadAll(conn)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(b)).To(Equal("hello"))
+
+			g.Eventually(func() float64 { return testutil.ToFloat64(metrics.activeConnections) }).Should(BeZero())
+			g.Expect(testutil.ToFloat64(metrics.connections.WithLabelValues("success"))).To(Equal(1.0))
+			g.Expect(testutil.ToFloat64(metrics.bytes.WithLabelValues("upstream"))).To(Equal(5.0))
+			g.Expect(testutil.ToFloat64(metrics.bytes.WithLabelValues("downstream"))).To(Equal(5.0))
+		})
+	}
+}
+
+func TestForwarderIdleTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	address, metrics := startForwarder(t, startEchoServer(t, "tcp4", "127.0.0.1:0"), ForwardIdleTimeout(200*time.Millisecond))
+
+	conn, err := net.Dial("tcp", address)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+
+	// Traffic keeps the connection open for longer than the idle timeout.
+	buf := make([]byte, 4)
+	for range 4 {
+		_, err = conn.Write([]byte("ping"))
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = io.ReadFull(conn, buf)
+		g.Expect(err).ToNot(HaveOccurred())
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The idle connection is closed.
+	g.Expect(conn.SetReadDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	_, err = conn.Read(buf)
+	g.Expect(err).To(MatchError(io.EOF))
+	g.Expect(testutil.ToFloat64(metrics.idleTimeouts)).To(Equal(1.0))
+}
+
+func TestForwarderDialError(t *testing.T) {
+	g := NewWithT(t)
+
+	// Reserve a port with nothing listening on it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	target := l.Addr().String()
+	l.Close()
+
+	address, metrics := startForwarder(t, target)
+
+	conn, err := net.Dial("tcp", address)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer conn.Close()
+
+	_, err = io.ReadAll(conn)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(testutil.ToFloat64(metrics.connections.WithLabelValues("error"))).To(Equal(1.0))
+
+	forwarder, err := NewForwarder(func() (string, error) { return target, nil }, NewMetrics(prometheus.NewRegistry()))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(forwarder.Ready(context.Background())).ToNot(Succeed())
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the Prometheus metrics of a Forwarder.
+type Metrics struct {
+	connections       *prometheus.CounterVec
+	activeConnections prometheus.Gauge
+	bytes             *prometheus.CounterVec
+	idleTimeouts      prometheus.Counter
+	dialDuration      prometheus.Histogram
+}
+
+// NewMetrics creates the metrics of a Forwarder, and registers them with the registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		connections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "k8sd_proxy_connections_total",
+			Help: "Number of connections accepted by the k8sd-proxy, by the result of dialing k8sd.",
+		}, []string{"result"}),
+		activeConnections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "k8sd_proxy_active_connections",
+			Help: "Number of connections currently forwarded to k8sd.",
+		}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "k8sd_proxy_bytes_total",
+			Help: "Number of bytes forwarded, by direction (upstream towards k8sd, or downstream towards the client).",
+		}, []string{"direction"}),
+		idleTimeouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "k8sd_proxy_idle_timeouts_total",
+			Help: "Number of connections closed after being idle for longer than the idle timeout.",
+		}),
+		dialDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "k8sd_proxy_dial_duration_seconds",
+			Help:    "Time taken to dial k8sd.",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+
+	registerer.MustRegister(m.connections, m.activeConnections, m.bytes, m.idleTimeouts, m.dialDuration)
+	return m
+}
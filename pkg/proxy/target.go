@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultK8sdDaemonConfig is the microcluster daemon config file of k8sd on the nodes.
+const DefaultK8sdDaemonConfig = "/var/snap/k8s/common/var/lib/k8sd/state/daemon.yaml"
+
+// daemonConfig is the subset of the microcluster daemon config file that is used.
+type daemonConfig struct {
+	Address string `json:"address"`
+}
+
+// Target resolves the address of k8sd on the node, which the connections of the k8sd-proxy are forwarded to.
+type Target struct {
+	// Address is the address of k8sd. If set, it takes precedence over the other fields.
+	Address string
+
+	// DaemonConfig is the microcluster daemon config file of k8sd. The address k8sd listens on is read from it,
+	// so that it matches the microcluster address even if it differs from the kubelet IP.
+	DaemonConfig string
+
+	// HostIP and Port are the address of k8sd if it cannot be read from DaemonConfig.
+	HostIP string
+	Port   int
+}
+
+// Resolve returns the address of k8sd on the node, e.g. "10.0.0.1:2380" or "[fd00::1]:2380".
+func (t *Target) Resolve() (string, error) {
+	if t.Address != "" {
+		if _, _, err := net.SplitHostPort(t.Address); err != nil {
+			return "", fmt.Errorf("invalid k8sd address %q: %w", t.Address, err)
+		}
+		return t.Address, nil
+	}
+
+	if t.DaemonConfig != "" {
+		address, err := t.readDaemonConfig()
+		if err == nil {
+			return address, nil
+		}
+		if t.HostIP == "" {
+			return "", err
+		}
+	}
+
+	if t.HostIP == "" || t.Port == 0 {
+		return "", fmt.Errorf("no k8sd address, daemon config or host IP and port configured")
+	}
+	return net.JoinHostPort(t.HostIP, strconv.Itoa(t.Port)), nil
+}
+
+// readDaemonConfig reads the address of k8sd from the microcluster daemon config file.
+// An unspecified host (e.g. "0.0.0.0" or "::") is replaced with HostIP.
+func (t *Target) readDaemonConfig() (string, error) {
+	b, err := os.ReadFile(t.DaemonConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to read microcluster daemon config: %w", err)
+	}
+
+	var config daemonConfig
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return "", fmt.Errorf("failed to parse microcluster daemon config: %w", err)
+	}
+
+	host, port, err := net.SplitHostPort(config.Address)
+	if err != nil {
+		return "", fmt.Errorf("invalid microcluster address %q: %w", config.Address, err)
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if t.HostIP == "" {
+			return "", fmt.Errorf("microcluster listens on all addresses %q, but no host IP is configured", config.Address)
+		}
+		host = t.HostIP
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
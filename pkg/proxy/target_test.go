@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTargetResolve(t *testing.T) {
+	writeDaemonConfig := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "daemon.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write daemon.yaml: %v", err)
+		}
+		return path
+	}
+
+	for _, tc := range []struct {
+		name         string
+		target       Target
+		daemonConfig string
+		expected     string
+		expectErr    bool
+	}{
+		{
+			name:     "address",
+			target:   Target{Address: "10.0.0.2:2380", HostIP: "10.0.0.1", Port: 6400},
+			expected: "10.0.0.2:2380",
+		},
+		{
+			name:         "daemonConfig",
+			target:       Target{HostIP: "10.0.0.1", Port: 2380},
+			daemonConfig: "name: node-1\naddress: 192.168.1.10:6400\n",
+			expected:     "192.168.1.10:6400",
+		},
+		{
+			name:         "daemonConfigIPv6",
+			target:       Target{},
+			daemonConfig: "name: node-1\naddress: '[fd00::10]:2380'\n",
+			expected:     "[fd00::10]:2380",
+		},
+		{
+			name:         "daemonConfigUnspecified",
+			target:       Target{HostIP: "fd00::1"},
+			daemonConfig: "address: '[::]:2380'\n",
+			expected:     "[fd00::1]:2380",
+		},
+		{
+			name:     "hostIPFallback",
+			target:   Target{DaemonConfig: "/nonexistent", HostIP: "fd00::1", Port: 2380},
+			expected: "[fd00::1]:2380",
+		},
+		{
+			name:      "invalidAddress",
+			target:    Target{Address: "10.0.0.2"},
+			expectErr: true,
+		},
+		{
+			name:      "nothingConfigured",
+			target:    Target{DaemonConfig: "/nonexistent"},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if tc.daemonConfig != "" {
+				tc.target.DaemonConfig = writeDaemonConfig(t, tc.daemonConfig)
+			}
+
+			address, err := tc.target.Resolve()
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(address).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// EncryptionKeyDataName is the key used to store the key encrypting secrets in the datastore.
+	EncryptionKeyDataName = "key"
+
+	// encryptionKeySize is the size of the generated keys, valid for both AES-CBC and AES-GCM.
+	encryptionKeySize = 32
+)
+
+// EncryptionKeyName returns the name identifying an encryption key in the encryption configuration.
+func EncryptionKeyName(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "key-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// LookupOrGenerateEncryptionKey generates the encryption key of a cluster, unless it already exists.
+func LookupOrGenerateEncryptionKey(ctx context.Context, c client.Client, clusterName client.ObjectKey, owner metav1.OwnerReference) error {
+	if _, err := GetFromNamespacedName(ctx, c, clusterName, Encryption); err == nil {
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get %s secret: %w", Encryption, err)
+	}
+
+	key, err := generateEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	s := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterName.Namespace,
+			Name:      Name(clusterName.Name, Encryption),
+			Labels: map[string]string{
+				clusterv1.ClusterNameLabel: clusterName.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Data: map[string][]byte{
+			EncryptionKeyDataName: key,
+		},
+		Type: clusterv1.ClusterSecretType,
+	}
+	if err := c.Create(ctx, s); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create %s secret: %w", Encryption, err)
+	}
+	return nil
+}
+
+// GetEncryptionKey returns the key encrypting the secrets of a cluster in the datastore.
+func GetEncryptionKey(ctx context.Context, c client.Reader, clusterName client.ObjectKey) ([]byte, error) {
+	s, err := GetFromNamespacedName(ctx, c, clusterName, Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s secret: %w", Encryption, err)
+	}
+	if len(s.Data[EncryptionKeyDataName]) == 0 {
+		return nil, fmt.Errorf("%s secret has no %q: %w", Encryption, EncryptionKeyDataName, ErrMissingKey)
+	}
+
+	return s.Data[EncryptionKeyDataName], nil
+}
+
+func generateEncryptionKey() ([]byte, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,38 @@
+package secret_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/canonical/cluster-api-k8s/pkg/secret"
+)
+
+func TestLookupOrGenerateEncryptionKey(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+
+	clusterKey := client.ObjectKey{Name: "test", Namespace: "default"}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	_, err := secret.GetEncryptionKey(ctx, c, clusterKey)
+	g.Expect(err).To(HaveOccurred())
+
+	// The key is generated once.
+	g.Expect(secret.LookupOrGenerateEncryptionKey(ctx, c, clusterKey, metav1.OwnerReference{})).To(Succeed())
+	key, err := secret.GetEncryptionKey(ctx, c, clusterKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(key).To(HaveLen(32))
+
+	g.Expect(secret.LookupOrGenerateEncryptionKey(ctx, c, clusterKey, metav1.OwnerReference{})).To(Succeed())
+	g.Expect(secret.GetEncryptionKey(ctx, c, clusterKey)).To(Equal(key))
+}
@@ -0,0 +1,249 @@
+package inplace
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/token"
+)
+
+const (
+	// HealthGateNode checks that the node is Ready and reports the Kubernetes version of the release.
+	HealthGateNode = "node"
+	// HealthGateControlPlane checks that the API server of the workload cluster is ready.
+	HealthGateControlPlane = "control-plane"
+	// HealthGateDatastore checks that the node of a control plane machine is a member of the datastore cluster.
+	HealthGateDatastore = "datastore"
+
+	// DefaultHealthCheckTimeout is how long a machine may take to pass the health gates, unless configured otherwise.
+	DefaultHealthCheckTimeout = 10 * time.Minute
+)
+
+// HealthGates are the checks an upgraded machine must pass before the next machines are upgraded.
+type HealthGates struct {
+	Node         bool
+	ControlPlane bool
+	Datastore    bool
+
+	// Service is the Service of the workload cluster that must respond with a 2xx status code, if set.
+	Service *HealthCheckService
+	// Job is the CronJob of the workload cluster a Job is created from for each upgraded machine, if set.
+	Job *types.NamespacedName
+	// Timeout is how long the machine may take to pass the health gates, before they are reported as failed.
+	Timeout time.Duration
+}
+
+// HealthCheckService is a Service of the workload cluster that is requested through the API server of the workload
+// cluster, so that only workload cluster endpoints can be probed.
+type HealthCheckService struct {
+	Namespace string
+	Name      string
+	Port      string
+	Path      string
+}
+
+// ParseHealthCheckService parses a "namespace/name:port/path" health check Service. The path defaults to "/".
+func ParseHealthCheckService(value string) (*HealthCheckService, error) {
+	namespace, rest, _ := strings.Cut(value, "/")
+	nameAndPort, path, _ := strings.Cut(rest, "/")
+	name, port, _ := strings.Cut(nameAndPort, ":")
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid health check service %q, expected \"namespace/name:port/path\"", value)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return nil, fmt.Errorf("invalid port of health check service %q, expected a port number", value)
+	}
+	return &HealthCheckService{Namespace: namespace, Name: name, Port: port, Path: "/" + path}, nil
+}
+
+// HealthChecker checks the health gates of an upgraded machine that depend on the workload cluster.
+type HealthChecker interface {
+	// CheckHealthGates returns the reasons why the machine does not pass the health gates, if any.
+	CheckHealthGates(ctx context.Context, m *clusterv1.Machine, nodeToken string, release string, gates *HealthGates) ([]string, error)
+}
+
+// HealthCheckResult is the result of checking the health gates of an upgraded machine.
+type HealthCheckResult struct {
+	// Healthy is set once the machine passed the health gates.
+	Healthy bool
+	// Failed is set if the machine did not pass the health gates within the timeout.
+	Failed bool
+	// Message describes why the machine does not pass the health gates.
+	Message string
+}
+
+// GetHealthGates returns the health gates configured on the object, or nil if there are none.
+func GetHealthGates(obj client.Object) (*HealthGates, error) {
+	annotations := obj.GetAnnotations()
+	gates := &HealthGates{
+		Timeout: DefaultHealthCheckTimeout,
+	}
+
+	if value := annotations[bootstrapv1.InPlaceUpgradeHealthGatesAnnotation]; value != "" {
+		for _, gate := range strings.Split(value, ",") {
+			switch strings.TrimSpace(gate) {
+			case HealthGateNode:
+				gates.Node = true
+			case HealthGateControlPlane:
+				gates.ControlPlane = true
+			case HealthGateDatastore:
+				gates.Datastore = true
+			default:
+				return nil, fmt.Errorf("invalid health gate %q", gate)
+			}
+		}
+	}
+
+	if value := annotations[bootstrapv1.InPlaceUpgradeHealthCheckServiceAnnotation]; value != "" {
+		service, err := ParseHealthCheckService(value)
+		if err != nil {
+			return nil, err
+		}
+		gates.Service = service
+	}
+
+	if value := annotations[bootstrapv1.InPlaceUpgradeHealthCheckJobAnnotation]; value != "" {
+		namespace, name, ok := strings.Cut(value, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid health check job %q, expected \"namespace/name\"", value)
+		}
+		gates.Job = &types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	if value := annotations[bootstrapv1.InPlaceUpgradeHealthCheckTimeoutAnnotation]; value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid health check timeout %q: %w", value, err)
+		}
+		gates.Timeout = timeout
+	}
+
+	if !gates.Node && !gates.ControlPlane && !gates.Datastore && gates.Service == nil && gates.Job == nil {
+		return nil, nil
+	}
+	return gates, nil
+}
+
+// NeedsHealthCheck checks if the machine was upgraded to the release, but did not pass the health gates yet.
+func NeedsHealthCheck(m *clusterv1.Machine, release string) bool {
+	return IsUpgraded(m, release) && m.Annotations[bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation] != release
+}
+
+var channelVersionRegexp = regexp.MustCompile(`^(\d+\.\d+)(-[^/]+)?(/.*)?$`)
+
+// GetReleaseVersion returns the Kubernetes minor version (e.g. "v1.31") of a release that refreshes to a channel
+// (e.g. "channel=1.31-classic/stable") or upgrades to a version (e.g. "version=v1.31.2"), or an empty string if it
+// cannot be derived from the release.
+func GetReleaseVersion(release string) string {
+	if version := GetUpgradeVersion(release); version != "" {
+		return version[:strings.LastIndex(version, ".")]
+	}
+
+	option, value, ok := strings.Cut(release, "=")
+	if !ok || option != "channel" {
+		return ""
+	}
+	match := channelVersionRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	return "v" + match[1]
+}
+
+// CheckMachineHealth checks the health gates of the machine upgraded to the release. The machine is annotated once
+// it passes them, so that they are not checked again.
+func CheckMachineHealth(ctx context.Context, m *clusterv1.Machine, release string, gates *HealthGates, checker HealthChecker, c client.Client) (*HealthCheckResult, error) {
+	patchHelper, err := patch.NewHelper(m, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new patch helper: %w", err)
+	}
+
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+
+	now := time.Now()
+	startedAt, err := time.Parse(time.RFC3339, m.Annotations[bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation])
+	if err != nil {
+		startedAt = now
+		m.Annotations[bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation] = now.Format(time.RFC3339)
+	}
+
+	var nodeToken string
+	if gates.Datastore {
+		clusterKey := client.ObjectKey{Namespace: m.Namespace, Name: m.Spec.ClusterName}
+		if nodeToken, err = token.LookupNodeToken(ctx, c, clusterKey, m.Name); err != nil {
+			return nil, fmt.Errorf("failed to lookup node token: %w", err)
+		}
+	}
+
+	reasons, err := checker.CheckHealthGates(ctx, m, nodeToken, release, gates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check health gates: %w", err)
+	}
+
+	result := &HealthCheckResult{}
+	if len(reasons) == 0 {
+		result.Healthy = true
+		m.Annotations[bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation] = release
+		delete(m.Annotations, bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation)
+	} else {
+		result.Message = strings.Join(reasons, "; ")
+		result.Failed = now.Sub(startedAt) > gates.Timeout
+	}
+
+	if err := patchHelper.Patch(ctx, m); err != nil {
+		return nil, fmt.Errorf("failed to patch: %w", err)
+	}
+
+	return result, nil
+}
+
+// HealthGatesFromSpec returns the health gates configured on a CK8sUpgradePlan, or nil if there are none.
+func HealthGatesFromSpec(spec *bootstrapv1.UpgradePlanHealthGates) (*HealthGates, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	gates := &HealthGates{
+		Node:         spec.Node,
+		ControlPlane: spec.ControlPlane,
+		Datastore:    spec.Datastore,
+		Timeout:      DefaultHealthCheckTimeout,
+	}
+
+	if spec.Service != "" {
+		service, err := ParseHealthCheckService(spec.Service)
+		if err != nil {
+			return nil, err
+		}
+		gates.Service = service
+	}
+
+	if spec.Job != "" {
+		namespace, name, ok := strings.Cut(spec.Job, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid health check job %q, expected \"namespace/name\"", spec.Job)
+		}
+		gates.Job = &types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	if spec.Timeout != nil {
+		gates.Timeout = spec.Timeout.Duration
+	}
+
+	if !gates.Node && !gates.ControlPlane && !gates.Datastore && gates.Service == nil && gates.Job == nil {
+		return nil, nil
+	}
+	return gates, nil
+}
@@ -0,0 +1,241 @@
+package inplace_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+type fakeHealthChecker struct {
+	reasons []string
+}
+
+func (f *fakeHealthChecker) CheckHealthGates(ctx context.Context, m *clusterv1.Machine, nodeToken string, release string, gates *inplace.HealthGates) ([]string, error) {
+	return f.reasons, nil
+}
+
+func TestGetHealthGates(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		healthGates *inplace.HealthGates
+		expectErr   bool
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "builtin",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeHealthGatesAnnotation: "node, control-plane,datastore",
+			},
+			healthGates: &inplace.HealthGates{Node: true, ControlPlane: true, Datastore: true, Timeout: inplace.DefaultHealthCheckTimeout},
+		},
+		{
+			name: "probes",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeHealthCheckServiceAnnotation: "monitoring/smoke-test:8080/healthz",
+				bootstrapv1.InPlaceUpgradeHealthCheckJobAnnotation:     "default/smoke-test",
+				bootstrapv1.InPlaceUpgradeHealthCheckTimeoutAnnotation: "5m",
+			},
+			healthGates: &inplace.HealthGates{
+				Service: &inplace.HealthCheckService{Namespace: "monitoring", Name: "smoke-test", Port: "8080", Path: "/healthz"},
+				Job:     &types.NamespacedName{Namespace: "default", Name: "smoke-test"},
+				Timeout: 5 * time.Minute,
+			},
+		},
+		{
+			name:        "invalidGate",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeHealthGatesAnnotation: "node,etcd"},
+			expectErr:   true,
+		},
+		{
+			name:        "invalidService",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeHealthCheckServiceAnnotation: "https://example.com/healthz"},
+			expectErr:   true,
+		},
+		{
+			name:        "invalidJob",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeHealthCheckJobAnnotation: "smoke-test"},
+			expectErr:   true,
+		},
+		{
+			name: "invalidTimeout",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeHealthGatesAnnotation:        "node",
+				bootstrapv1.InPlaceUpgradeHealthCheckTimeoutAnnotation: "5",
+			},
+			expectErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			md := &clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			healthGates, err := inplace.GetHealthGates(md)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(healthGates).To(Equal(tc.healthGates))
+		})
+	}
+}
+
+func TestHealthGatesFromSpec(t *testing.T) {
+	g := NewWithT(t)
+
+	healthGates, err := inplace.HealthGatesFromSpec(nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(healthGates).To(BeNil())
+
+	healthGates, err = inplace.HealthGatesFromSpec(&bootstrapv1.UpgradePlanHealthGates{Timeout: &metav1.Duration{Duration: time.Minute}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(healthGates).To(BeNil())
+
+	healthGates, err = inplace.HealthGatesFromSpec(&bootstrapv1.UpgradePlanHealthGates{Node: true, Job: "default/smoke-test"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(healthGates).To(Equal(&inplace.HealthGates{
+		Node:    true,
+		Job:     &types.NamespacedName{Namespace: "default", Name: "smoke-test"},
+		Timeout: inplace.DefaultHealthCheckTimeout,
+	}))
+
+	_, err = inplace.HealthGatesFromSpec(&bootstrapv1.UpgradePlanHealthGates{Job: "smoke-test"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestParseHealthCheckService(t *testing.T) {
+	for _, tc := range []struct {
+		value     string
+		service   *inplace.HealthCheckService
+		expectErr bool
+	}{
+		{value: "monitoring/smoke-test:8080/healthz", service: &inplace.HealthCheckService{Namespace: "monitoring", Name: "smoke-test", Port: "8080", Path: "/healthz"}},
+		{value: "monitoring/smoke-test:8080/api/v1/healthz", service: &inplace.HealthCheckService{Namespace: "monitoring", Name: "smoke-test", Port: "8080", Path: "/api/v1/healthz"}},
+		{value: "monitoring/smoke-test:8080", service: &inplace.HealthCheckService{Namespace: "monitoring", Name: "smoke-test", Port: "8080", Path: "/"}},
+		{value: "monitoring/smoke-test", expectErr: true},
+		{value: "monitoring/smoke-test:http/healthz", expectErr: true},
+		{value: "smoke-test:8080", expectErr: true},
+		{value: "https://example.com/healthz", expectErr: true},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			g := NewWithT(t)
+
+			service, err := inplace.ParseHealthCheckService(tc.value)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(service).To(Equal(tc.service))
+		})
+	}
+}
+
+func TestGetReleaseVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(inplace.GetReleaseVersion("channel=1.31-classic/stable")).To(Equal("v1.31"))
+	g.Expect(inplace.GetReleaseVersion("channel=1.32/edge")).To(Equal("v1.32"))
+	g.Expect(inplace.GetReleaseVersion("channel=latest/edge")).To(BeEmpty())
+	g.Expect(inplace.GetReleaseVersion("revision=123")).To(BeEmpty())
+	g.Expect(inplace.GetReleaseVersion("localPath=/k8s.snap")).To(BeEmpty())
+	g.Expect(inplace.GetReleaseVersion("version=v1.31.2")).To(Equal("v1.31"))
+	g.Expect(inplace.GetReleaseVersion("version=1.32.0")).To(Equal("v1.32"))
+}
+
+func TestNeedsHealthCheck(t *testing.T) {
+	g := NewWithT(t)
+
+	m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.31/stable",
+	}}}
+	g.Expect(inplace.NeedsHealthCheck(m, "channel=1.32/stable")).To(BeFalse())
+	g.Expect(inplace.NeedsHealthCheck(m, "channel=1.31/stable")).To(BeTrue())
+
+	m.Annotations[bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation] = "channel=1.31/stable"
+	g.Expect(inplace.NeedsHealthCheck(m, "channel=1.31/stable")).To(BeFalse())
+}
+
+func TestCheckMachineHealth(t *testing.T) {
+	const release = "channel=1.31/stable"
+
+	newMachine := func(annotations map[string]string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   metav1.NamespaceDefault,
+				Annotations: annotations,
+			},
+		}
+	}
+	newClient := func(g Gomega, m *clusterv1.Machine) client.Client {
+		scheme := runtime.NewScheme()
+		g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(m.DeepCopy()).Build()
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := newMachine(map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: release})
+		c := newClient(g, m)
+		gates := &inplace.HealthGates{Node: true, Timeout: inplace.DefaultHealthCheckTimeout}
+
+		result, err := inplace.CheckMachineHealth(context.Background(), m, release, gates, &fakeHealthChecker{}, c)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Healthy).To(BeTrue())
+
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(m), m)).To(Succeed())
+		g.Expect(m.Annotations).To(HaveKeyWithValue(bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation, release))
+		g.Expect(m.Annotations).ToNot(HaveKey(bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation))
+		g.Expect(inplace.NeedsHealthCheck(m, release)).To(BeFalse())
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := newMachine(map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: release})
+		c := newClient(g, m)
+		gates := &inplace.HealthGates{Node: true, Timeout: inplace.DefaultHealthCheckTimeout}
+
+		result, err := inplace.CheckMachineHealth(context.Background(), m, release, gates, &fakeHealthChecker{reasons: []string{"node: not ready"}}, c)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Healthy).To(BeFalse())
+		g.Expect(result.Failed).To(BeFalse())
+		g.Expect(result.Message).To(Equal("node: not ready"))
+
+		g.Expect(c.Get(context.Background(), client.ObjectKeyFromObject(m), m)).To(Succeed())
+		g.Expect(m.Annotations).To(HaveKey(bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation))
+		g.Expect(inplace.NeedsHealthCheck(m, release)).To(BeTrue())
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := newMachine(map[string]string{
+			bootstrapv1.InPlaceUpgradeReleaseAnnotation:              release,
+			bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation: time.Now().Add(-time.Hour).Format(time.RFC3339),
+		})
+		c := newClient(g, m)
+		gates := &inplace.HealthGates{Node: true, Timeout: inplace.DefaultHealthCheckTimeout}
+
+		result, err := inplace.CheckMachineHealth(context.Background(), m, release, gates, &fakeHealthChecker{reasons: []string{"node: not ready"}}, c)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(result.Healthy).To(BeFalse())
+		g.Expect(result.Failed).To(BeTrue())
+	})
+}
@@ -42,3 +42,18 @@ func IsMachineUpgrading(m *clusterv1.Machine) bool {
 	return m.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeInProgressStatus ||
 		m.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] != ""
 }
+
+// IsDrainEnabled checks if the nodes are drained before the in-place upgrade of the object.
+func IsDrainEnabled(obj client.Object) bool {
+	return obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeDrainAnnotation] == "true"
+}
+
+// IsRollbackEnabled checks if the k8s snap is rolled back if the in-place upgrade of the object fails.
+func IsRollbackEnabled(obj client.Object) bool {
+	return obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeRollbackAnnotation] == "true"
+}
+
+// IsMachineRolledBack checks if the in-place upgrade of the machine was rolled back.
+func IsMachineRolledBack(m *clusterv1.Machine) bool {
+	return m.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] == bootstrapv1.InPlaceUpgradeRolledBackStatus
+}
@@ -214,3 +214,44 @@ func TestIsMachineUpgrading(t *testing.T) {
 		})
 	}
 }
+
+func TestIsMachineRolledBack(t *testing.T) {
+	g := NewWithT(t)
+	for _, tc := range []struct {
+		name         string
+		annotations  map[string]string
+		isRolledBack bool
+	}{
+		{
+			name: "rolledBack",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeStatusAnnotation: bootstrapv1.InPlaceUpgradeRolledBackStatus,
+				bootstrapv1.InPlaceUpgradeToAnnotation:     "v1.31",
+			},
+			isRolledBack: true,
+		},
+		{
+			name: "rollingBack",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeStatusAnnotation: bootstrapv1.InPlaceUpgradeRollingBackStatus,
+				bootstrapv1.InPlaceUpgradeToAnnotation:     "v1.31",
+			},
+			isRolledBack: false,
+		},
+		{
+			name:         "noAnnotations",
+			annotations:  map[string]string{},
+			isRolledBack: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &clusterv1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: tc.annotations,
+				},
+			}
+
+			g.Expect(inplace.IsMachineRolledBack(machine)).To(Equal(tc.isRolledBack))
+		})
+	}
+}
@@ -2,506 +2,259 @@ package inplace
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
-)
 
-func TestNewUpgradeLock(t *testing.T) {
-	g := NewWithT(t)
-	testClient := fake.NewClientBuilder().Build()
+	"github.com/canonical/cluster-api-k8s/pkg/locking"
+)
 
-	lock := NewUpgradeLock(testClient)
+const testClusterUID = types.UID("50f4a6af-39be-4589-abf0-0a71110fda00")
 
-	g.Expect(lock).ToNot(BeNil())
-	g.Expect(lock).To(BeAssignableToTypeOf(&upgradeLock{}))
+func newTestObjects() (*clusterv1.Cluster, *clusterv1.Machine) {
+	cluster := &clusterv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "test-namespace",
+			UID:       testClusterUID,
+		},
+	}
+	machine := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-machine",
+			Namespace: "test-namespace",
+		},
+	}
+	return cluster, machine
 }
 
-func TestNewSemaphore(t *testing.T) {
-	g := NewWithT(t)
+func newTestLease(cluster *clusterv1.Cluster, machine *clusterv1.Machine, clusterUID types.UID, renewedAt time.Time) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
+			Namespace: cluster.Namespace,
+			Annotations: map[string]string{
+				locking.LockHolderMachineAnnotation: client.ObjectKeyFromObject(machine).String(),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: clusterv1.GroupVersion.String(),
+					Kind:       clusterv1.ClusterKind,
+					Name:       cluster.Name,
+					UID:        clusterUID,
+				},
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       ptr.To("other-controller"),
+			LeaseDurationSeconds: ptr.To(int32(DefaultUpgradeLockDuration.Seconds())),
+			RenewTime:            ptr.To(metav1.NewMicroTime(renewedAt)),
+		},
+	}
+}
 
-	sem := newSemaphore()
+func newTestClient(g *WithT, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	g.Expect(clusterv1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(coordinationv1.AddToScheme(scheme)).To(Succeed())
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
 
-	g.Expect(sem).ToNot(BeNil())
-	g.Expect(sem).To(BeAssignableToTypeOf(&semaphore{}))
+func getTestLease(c client.Client, cluster *clusterv1.Cluster) (*coordinationv1.Lease, error) {
+	lease := &coordinationv1.Lease{}
+	err := c.Get(context.Background(), client.ObjectKey{Namespace: cluster.Namespace, Name: leaseName(cluster.Name)}, lease)
+	return lease, err
 }
 
-func TestSetLockInfo(t *testing.T) {
-	const machineNamespace = "test-namespace"
-	const machineName = "test-machine"
+func TestNewUpgradeLock(t *testing.T) {
 	g := NewWithT(t)
-	sem := &semaphore{}
-	li := lockInformation{
-		MachineName:      machineName,
-		MachineNamespace: machineNamespace,
-	}
+	testClient := fake.NewClientBuilder().Build()
 
-	err := sem.setLockInfo(li)
+	lock := NewUpgradeLock(testClient, nil)
 
-	g.Expect(err).ToNot(HaveOccurred())
-	data, ok := sem.configMap.Data["lock-information"]
-	g.Expect(ok).To(BeTrue())
-	g.Expect(data).To(Equal(fmt.Sprintf("{\"machineName\":\"%s\",\"machineNamespace\":\"%s\"}",
-		machineName, machineNamespace,
-	)))
+	g.Expect(lock).ToNot(BeNil())
+	g.Expect(lock).To(BeAssignableToTypeOf(&upgradeLock{}))
 }
-func TestGetLockInfo(t *testing.T) {
-	g := NewWithT(t)
+
+func TestIsLocked(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
-		machineName := "test-machine"
-		machineNamespace := "test-namespace"
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster-cp-inplace-upgrade-lock",
-				Namespace: "clusterNamespace",
-			},
-			Data: map[string]string{
-				"lock-information": fmt.Sprintf("{\"machineName\":\"%s\",\"machineNamespace\":\"%s\"}",
-					machineName, machineNamespace),
-			},
-		}
-		sem := &semaphore{configMap: lockConfigMap}
+		g := NewWithT(t)
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, machine, newTestLease(cluster, machine, cluster.UID, time.Now()))
+		lock := NewUpgradeLock(testClient, nil)
 
-		lockInfo, err := sem.getLockInfo()
+		machine2, err := lock.IsLocked(context.Background(), cluster)
 
 		g.Expect(err).ToNot(HaveOccurred())
-		g.Expect(lockInfo.MachineName).To(Equal(machineName))
-		g.Expect(lockInfo.MachineNamespace).To(Equal(machineNamespace))
+		g.Expect(machine2).ToNot(BeNil())
+		g.Expect(machine2.Name).To(Equal(machine.Name))
 	})
 
-	t.Run("configMapIsNil", func(t *testing.T) {
-		sem := &semaphore{configMap: nil}
+	t.Run("NoLease", func(t *testing.T) {
+		g := NewWithT(t)
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, machine)
+		lock := NewUpgradeLock(testClient, nil)
 
-		lockInfo, err := sem.getLockInfo()
+		machine2, err := lock.IsLocked(context.Background(), cluster)
 
-		g.Expect(err).To(HaveOccurred())
-		g.Expect(lockInfo).To(BeNil())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(machine2).To(BeNil())
 	})
 
-	t.Run("configMapDataIsNil", func(t *testing.T) {
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster-cp-inplace-upgrade-lock",
-				Namespace: "clusterNamespace",
-			},
-		}
-		sem := &semaphore{configMap: lockConfigMap}
+	t.Run("NoMachine", func(t *testing.T) {
+		g := NewWithT(t)
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, newTestLease(cluster, machine, cluster.UID, time.Now()))
+		recorder := record.NewFakeRecorder(10)
+		lock := NewUpgradeLock(testClient, recorder)
 
-		lockInfo, err := sem.getLockInfo()
+		machine2, err := lock.IsLocked(context.Background(), cluster)
 
-		g.Expect(err).To(HaveOccurred())
-		g.Expect(lockInfo).To(BeNil())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(machine2).To(BeNil())
+		_, err = getTestLease(testClient, cluster)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(recorder.Events).To(Receive(ContainSubstring("no longer exists")))
 	})
-}
-
-func TestSetMetadata(t *testing.T) {
-	g := NewWithT(t)
-
-	clusterName := "test-cluster"
-	clusterNamespace := "test-namespace"
-	clusterUID := types.UID("50f4a6af-39be-4589-abf0-0a71110fda00")
-	cluster := &clusterv1.Cluster{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      clusterName,
-			Namespace: clusterNamespace,
-			UID:       clusterUID,
-		},
-	}
-	sem := &semaphore{}
 
-	sem.setMetadata(cluster)
-
-	meta := sem.configMap.ObjectMeta
-	g.Expect(meta.Namespace).To(Equal(clusterNamespace))
-	g.Expect(meta.Name).To(Equal(fmt.Sprintf("%s-%s", clusterName, lockConfigMapNameSuffix)))
-	g.Expect(meta.Labels[clusterv1.ClusterNameLabel]).To(Equal(clusterName))
-	g.Expect(meta.OwnerReferences[0].Name).To(Equal(clusterName))
-	g.Expect(meta.OwnerReferences[0].UID).To(Equal(clusterUID))
-}
-
-func TestIsLocked(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+	t.Run("Expired", func(t *testing.T) {
 		g := NewWithT(t)
+		cluster, machine := newTestObjects()
+		renewedAt := time.Now().Add(-2 * DefaultUpgradeLockDuration)
+		testClient := newTestClient(g, cluster, machine, newTestLease(cluster, machine, cluster.UID, renewedAt))
+		recorder := record.NewFakeRecorder(10)
+		lock := NewUpgradeLock(testClient, recorder)
 
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		li := lockInformation{
-			MachineName:      machine.Name,
-			MachineNamespace: machine.Namespace,
-		}
-		liStr, err := json.Marshal(li)
-		g.Expect(err).ToNot(HaveOccurred())
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-			Data: map[string]string{
-				"lock-information": string(liStr),
-			},
-		}
-		scheme := runtime.NewScheme()
-		err = clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-				lockConfigMap,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-		err = lock.semaphore.setLockInfo(li)
-		ctx := context.Background()
-		lock.semaphore.setMetadata(cluster)
-		g.Expect(err).ToNot(HaveOccurred())
-
-		machine2, err := lock.IsLocked(ctx, cluster)
+		machine2, err := lock.IsLocked(context.Background(), cluster)
 
 		g.Expect(err).ToNot(HaveOccurred())
-		g.Expect(machine2).ToNot(BeNil())
+		g.Expect(machine2).To(BeNil())
+		_, err = getTestLease(testClient, cluster)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(recorder.Events).To(Receive(And(
+			ContainSubstring(locking.StaleLockRecoveredEvent),
+			ContainSubstring("other-controller"),
+			ContainSubstring("not renewed since"),
+		)))
 	})
 
-	t.Run("NoConfigMap", func(t *testing.T) {
+	t.Run("PreviousCluster", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		li := lockInformation{
-			MachineName:      machine.Name,
-			MachineNamespace: machine.Namespace,
-		}
-		scheme := runtime.NewScheme()
-		err := clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-		err = lock.semaphore.setLockInfo(li)
-		ctx := context.Background()
-		lock.semaphore.setMetadata(cluster)
-		g.Expect(err).ToNot(HaveOccurred())
+		cluster, machine := newTestObjects()
+		lease := newTestLease(cluster, machine, types.UID("0b0e4f7c-2e1f-4a57-9a43-3a4b8f1c9d11"), time.Now())
+		testClient := newTestClient(g, cluster, machine, lease)
+		lock := NewUpgradeLock(testClient, nil)
 
-		machine2, err := lock.IsLocked(ctx, cluster)
+		machine2, err := lock.IsLocked(context.Background(), cluster)
 
 		g.Expect(err).ToNot(HaveOccurred())
 		g.Expect(machine2).To(BeNil())
+		_, err = getTestLease(testClient, cluster)
+		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
 	})
+}
 
-	t.Run("noMachine", func(t *testing.T) {
+func TestLock(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		li := lockInformation{
-			MachineName:      machine.Name,
-			MachineNamespace: machine.Namespace,
-		}
-		liStr, err := json.Marshal(li)
-		g.Expect(err).ToNot(HaveOccurred())
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-			Data: map[string]string{
-				"lock-information": string(liStr),
-			},
-		}
-		scheme := runtime.NewScheme()
-		err = clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				lockConfigMap,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-		err = lock.semaphore.setLockInfo(li)
-		ctx := context.Background()
-		lock.semaphore.setMetadata(cluster)
-		g.Expect(err).ToNot(HaveOccurred())
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, machine)
+		lock := NewUpgradeLock(testClient, nil)
 
-		machine2, err := lock.IsLocked(ctx, cluster)
+		err := lock.Lock(context.Background(), cluster, machine)
 
 		g.Expect(err).ToNot(HaveOccurred())
-		g.Expect(machine2).To(BeNil())
+		lease, err := getTestLease(testClient, cluster)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(locking.HolderMachine(lease)).To(Equal(client.ObjectKeyFromObject(machine)))
+		g.Expect(lease.Spec.HolderIdentity).ToNot(BeNil())
+		g.Expect(*lease.Spec.LeaseDurationSeconds).To(Equal(int32(DefaultUpgradeLockDuration.Seconds())))
+		g.Expect(lease.Spec.RenewTime).ToNot(BeNil())
+		g.Expect(lease.OwnerReferences).To(HaveLen(1))
+		g.Expect(lease.OwnerReferences[0].UID).To(Equal(cluster.UID))
 	})
 
-	t.Run("NoLockData", func(t *testing.T) {
+	t.Run("LockedByAnotherMachine", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		li := lockInformation{
-			MachineName:      machine.Name,
-			MachineNamespace: machine.Namespace,
-		}
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-		}
-		scheme := runtime.NewScheme()
-		err := clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-				lockConfigMap,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-		err = lock.semaphore.setLockInfo(li)
-		ctx := context.Background()
-		lock.semaphore.setMetadata(cluster)
-		g.Expect(err).ToNot(HaveOccurred())
+		cluster, machine := newTestObjects()
+		other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "other-machine", Namespace: cluster.Namespace}}
+		testClient := newTestClient(g, cluster, machine, other, newTestLease(cluster, other, cluster.UID, time.Now()))
+		lock := NewUpgradeLock(testClient, nil)
 
-		machine2, err := lock.IsLocked(ctx, cluster)
+		err := lock.Lock(context.Background(), cluster, machine)
 
 		g.Expect(err).To(HaveOccurred())
-		g.Expect(machine2).To(BeNil())
+		lease, err := getTestLease(testClient, cluster)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(locking.HolderMachine(lease)).To(Equal(client.ObjectKeyFromObject(other)))
 	})
 }
 
-func TestLock(t *testing.T) {
-	t.Run("Success", func(t *testing.T) {
+func TestRenew(t *testing.T) {
+	t.Run("Holder", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		scheme := runtime.NewScheme()
-		err := clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
+		cluster, machine := newTestObjects()
+		renewedAt := time.Now().Add(-time.Minute)
+		testClient := newTestClient(g, cluster, machine, newTestLease(cluster, machine, cluster.UID, renewedAt))
+		lock := NewUpgradeLock(testClient, nil)
 
-		err = lock.Lock(context.Background(), cluster, machine)
+		g.Expect(lock.Renew(context.Background(), cluster, machine)).To(Succeed())
 
+		lease, err := getTestLease(testClient, cluster)
 		g.Expect(err).ToNot(HaveOccurred())
-		lockInfo, err := lock.semaphore.getLockInfo()
-		g.Expect(err).ToNot(HaveOccurred())
-		g.Expect(lockInfo.MachineName).To(Equal(machine.Name))
-		g.Expect(lockInfo.MachineNamespace).To(Equal(machine.Namespace))
+		g.Expect(lease.Spec.RenewTime.Time).To(BeTemporally(">", renewedAt))
 	})
-	t.Run("LockAlreadyExists", func(t *testing.T) {
+
+	t.Run("NotHolder", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		existingLockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-		}
-		scheme := runtime.NewScheme()
-		err := clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-				existingLockConfigMap,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-
-		err = lock.Lock(context.Background(), cluster, machine)
+		cluster, machine := newTestObjects()
+		other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "other-machine", Namespace: cluster.Namespace}}
+		renewedAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+		testClient := newTestClient(g, cluster, machine, other, newTestLease(cluster, other, cluster.UID, renewedAt))
+		lock := NewUpgradeLock(testClient, nil)
 
-		g.Expect(err).To(HaveOccurred())
-		lockInfo, err := lock.semaphore.getLockInfo()
+		g.Expect(lock.Renew(context.Background(), cluster, machine)).To(Succeed())
+
+		lease, err := getTestLease(testClient, cluster)
 		g.Expect(err).ToNot(HaveOccurred())
-		g.Expect(lockInfo.MachineName).To(Equal(machine.Name))
-		g.Expect(lockInfo.MachineNamespace).To(Equal(machine.Namespace))
+		g.Expect(lease.Spec.RenewTime.Time).To(BeTemporally("==", renewedAt))
 	})
 }
 
 func TestUnlock(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		li := lockInformation{
-			MachineName:      machine.Name,
-			MachineNamespace: machine.Namespace,
-		}
-		liStr, err := json.Marshal(li)
-		g.Expect(err).ToNot(HaveOccurred())
-		lockConfigMap := &corev1.ConfigMap{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-			Data: map[string]string{
-				"lock-information": string(liStr),
-			},
-		}
-		scheme := runtime.NewScheme()
-		err = clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-				lockConfigMap,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
-
-		err = lock.Unlock(context.Background(), cluster)
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, machine, newTestLease(cluster, machine, cluster.UID, time.Now()))
+		lock := NewUpgradeLock(testClient, nil)
 
-		g.Expect(err).ToNot(HaveOccurred())
-		err = testClient.Get(context.Background(),
-			client.ObjectKey{
-				Name:      fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name),
-				Namespace: cluster.Namespace,
-			},
-			&corev1.ConfigMap{})
-		g.Expect(err).NotTo(BeNil())
+		g.Expect(lock.Unlock(context.Background(), cluster)).To(Succeed())
+
+		_, err := getTestLease(testClient, cluster)
 		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
 	})
-	t.Run("LockAlreadyReleased", func(t *testing.T) {
-		g := NewWithT(t)
-		cluster := &clusterv1.Cluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-cluster",
-				Namespace: "test-namespace",
-			},
-		}
-		machine := &clusterv1.Machine{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      "test-machine",
-				Namespace: "test-namespace",
-			},
-		}
-		scheme := runtime.NewScheme()
-		err := clusterv1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		err = corev1.AddToScheme(scheme)
-		g.Expect(err).ToNot(HaveOccurred())
-		testClient := fake.NewClientBuilder().
-			WithScheme(scheme).
-			WithObjects(
-				cluster,
-				machine,
-			).
-			Build()
-		lock := NewUpgradeLock(testClient)
 
-		err = lock.Unlock(context.Background(), cluster)
+	t.Run("NoLease", func(t *testing.T) {
+		g := NewWithT(t)
+		cluster, machine := newTestObjects()
+		testClient := newTestClient(g, cluster, machine)
+		lock := NewUpgradeLock(testClient, nil)
 
-		g.Expect(err).ToNot(HaveOccurred())
-		err = testClient.Get(context.Background(), client.ObjectKey{Name: fmt.Sprintf("%s-cp-inplace-upgrade-lock", cluster.Name), Namespace: cluster.Namespace}, &corev1.ConfigMap{})
-		g.Expect(err).NotTo(BeNil())
-		g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		g.Expect(lock.Unlock(context.Background(), cluster)).To(Succeed())
 	})
 }
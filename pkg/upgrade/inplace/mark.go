@@ -11,8 +11,17 @@ import (
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
 )
 
-// MarkMachineToUpgrade marks the machine to upgrade.
-func MarkMachineToUpgrade(ctx context.Context, m *clusterv1.Machine, to string, c client.Client) error {
+// policyAnnotations are the annotations that configure how the machines are upgraded. They are set on the object
+// orchestrating the upgrade, and propagated to its machines.
+var policyAnnotations = []string{
+	bootstrapv1.InPlaceUpgradeDrainAnnotation,
+	bootstrapv1.InPlaceUpgradeRollbackAnnotation,
+	bootstrapv1.InPlaceUpgradeRiskAnnotation,
+	bootstrapv1.InPlaceUpgradeVersionMapAnnotation,
+}
+
+// MarkMachineToUpgrade marks the machine to upgrade, with the upgrade policy set on the orchestrating object, if any.
+func MarkMachineToUpgrade(ctx context.Context, m *clusterv1.Machine, to string, orchestrator client.Object, c client.Client) error {
 	patchHelper, err := patch.NewHelper(m, c)
 	if err != nil {
 		return fmt.Errorf("failed to create new patch helper: %w", err)
@@ -27,8 +36,20 @@ func MarkMachineToUpgrade(ctx context.Context, m *clusterv1.Machine, to string,
 	delete(m.Annotations, bootstrapv1.InPlaceUpgradeStatusAnnotation)
 	delete(m.Annotations, bootstrapv1.InPlaceUpgradeChangeIDAnnotation)
 	delete(m.Annotations, bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation)
+	delete(m.Annotations, bootstrapv1.InPlaceUpgradeRolledBackAtAnnotation)
+	delete(m.Annotations, bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation)
+	delete(m.Annotations, bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation)
 
 	m.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] = to
+	if orchestrator != nil {
+		for _, annotation := range policyAnnotations {
+			if value, ok := orchestrator.GetAnnotations()[annotation]; ok {
+				m.Annotations[annotation] = value
+			} else {
+				delete(m.Annotations, annotation)
+			}
+		}
+	}
 
 	if err := patchHelper.Patch(ctx, m); err != nil {
 		return fmt.Errorf("failed to patch: %w", err)
@@ -9,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
 	"sigs.k8s.io/cluster-api/util/patch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
@@ -23,6 +24,9 @@ func TestMarkMachineToUpgrade(t *testing.T) {
 		name               string
 		annotations        map[string]string
 		ToAnnotation       string
+		orchestrator       client.Object
+		drain              bool
+		rollback           bool
 		addMachineToClient bool
 	}{
 		{
@@ -42,6 +46,41 @@ func TestMarkMachineToUpgrade(t *testing.T) {
 			ToAnnotation:       "v1.29",
 			addMachineToClient: true,
 		},
+		{
+			name:         "orchestratorPolicy",
+			annotations:  nil,
+			ToAnnotation: "v1.29",
+			orchestrator: &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						bootstrapv1.InPlaceUpgradeDrainAnnotation:    "true",
+						bootstrapv1.InPlaceUpgradeRollbackAnnotation: "true",
+					},
+				},
+			},
+			drain:              true,
+			rollback:           true,
+			addMachineToClient: true,
+		},
+		{
+			name: "orchestratorNoPolicy",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeDrainAnnotation:    "true",
+				bootstrapv1.InPlaceUpgradeRollbackAnnotation: "true",
+			},
+			ToAnnotation:       "v1.29",
+			orchestrator:       &clusterv1.MachineDeployment{},
+			addMachineToClient: true,
+		},
+		{
+			name: "noOrchestrator",
+			annotations: map[string]string{
+				bootstrapv1.InPlaceUpgradeDrainAnnotation: "true",
+			},
+			ToAnnotation:       "v1.29",
+			drain:              true,
+			addMachineToClient: true,
+		},
 		{
 			name: "FailedToPatch",
 			annotations: map[string]string{
@@ -72,7 +111,7 @@ func TestMarkMachineToUpgrade(t *testing.T) {
 			}
 			testClient := testClientBuilder.Build()
 
-			res := inplace.MarkMachineToUpgrade(context.Background(), machine, tc.ToAnnotation, testClient)
+			res := inplace.MarkMachineToUpgrade(context.Background(), machine, tc.ToAnnotation, tc.orchestrator, testClient)
 			if tc.addMachineToClient {
 				g.Expect(res).ToNot(HaveOccurred())
 			} else {
@@ -83,8 +122,13 @@ func TestMarkMachineToUpgrade(t *testing.T) {
 				bootstrapv1.InPlaceUpgradeStatusAnnotation,
 				bootstrapv1.InPlaceUpgradeChangeIDAnnotation,
 				bootstrapv1.InPlaceUpgradeLastFailedAttemptAtAnnotation,
+				bootstrapv1.InPlaceUpgradeRolledBackAtAnnotation,
+				bootstrapv1.InPlaceUpgradeHealthCheckedAnnotation,
+				bootstrapv1.InPlaceUpgradeHealthCheckStartedAtAnnotation,
 			))
 			g.Expect(machine.ObjectMeta.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation]).Should(Equal(tc.ToAnnotation))
+			g.Expect(inplace.IsDrainEnabled(machine)).To(Equal(tc.drain))
+			g.Expect(inplace.IsRollbackEnabled(machine)).To(Equal(tc.rollback))
 		})
 	}
 
@@ -94,7 +138,7 @@ func TestMarkMachineToUpgrade(t *testing.T) {
 		g.Expect(err).ToNot(HaveOccurred())
 		testClient := fake.NewClientBuilder().WithScheme(scheme).Build()
 
-		res := inplace.MarkMachineToUpgrade(context.Background(), nil, "", testClient)
+		res := inplace.MarkMachineToUpgrade(context.Background(), nil, "", nil, testClient)
 
 		g.Expect(res).To(HaveOccurred())
 	})
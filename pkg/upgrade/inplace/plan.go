@@ -0,0 +1,46 @@
+package inplace
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/collections"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// SelectedByUpgradePlan returns a filter matching the machines selected by a CK8sUpgradePlan of the cluster.
+// The machines selected by a plan are upgraded by the plan only, so that the control plane and MachineDeployments
+// do not revert them to the release they were last upgraded to in-place.
+func SelectedByUpgradePlan(ctx context.Context, c client.Reader, cluster *clusterv1.Cluster) (collections.Func, error) {
+	var plans bootstrapv1.CK8sUpgradePlanList
+	if err := c.List(ctx, &plans, client.InNamespace(cluster.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list CK8sUpgradePlans: %w", err)
+	}
+
+	var selectors []labels.Selector
+	for _, plan := range plans.Items {
+		if plan.Spec.ClusterName != cluster.Name || !plan.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(&plan.Spec.MachineSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse machine selector of CK8sUpgradePlan %q: %w", plan.Name, err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	return func(m *clusterv1.Machine) bool {
+		for _, selector := range selectors {
+			if selector.Matches(labels.Set(m.Labels)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
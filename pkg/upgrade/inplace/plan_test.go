@@ -0,0 +1,46 @@
+package inplace
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+func TestSelectedByUpgradePlan(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme := runtime.NewScheme()
+	g.Expect(bootstrapv1.AddToScheme(scheme)).To(Succeed())
+
+	cluster, _ := newTestObjects()
+	newPlan := func(name string, clusterName string, labels map[string]string) *bootstrapv1.CK8sUpgradePlan {
+		return &bootstrapv1.CK8sUpgradePlan{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster.Namespace},
+			Spec: bootstrapv1.CK8sUpgradePlanSpec{
+				ClusterName:     clusterName,
+				MachineSelector: metav1.LabelSelector{MatchLabels: labels},
+			},
+		}
+	}
+	newMachine := func(labels map[string]string) *clusterv1.Machine {
+		return &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: cluster.Namespace, Labels: labels}}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newPlan("workers", cluster.Name, map[string]string{"pool": "workers"}),
+		newPlan("other-cluster", "other-cluster", map[string]string{"pool": "other"}),
+	).Build()
+
+	selected, err := SelectedByUpgradePlan(context.Background(), c, cluster)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(selected(newMachine(map[string]string{"pool": "workers"}))).To(BeTrue())
+	g.Expect(selected(newMachine(map[string]string{"pool": "other"}))).To(BeFalse())
+	g.Expect(selected(newMachine(nil))).To(BeFalse())
+}
@@ -0,0 +1,140 @@
+package inplace
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// skippedReleaseCheck is reported by the preflight checks, since k8sd cannot check that the k8s snap of a node can be
+// refreshed to a release without refreshing it.
+const skippedReleaseCheck = "the availability of the release on the nodes is not checked"
+
+// Preflight describes an in-place upgrade that is about to start.
+type Preflight struct {
+	// Cluster is the cluster of the machines.
+	Cluster *clusterv1.Cluster
+	// Object is the CK8sControlPlane or MachineDeployment that upgrades the machines.
+	Object client.Object
+	// Machines are the machines to upgrade.
+	Machines []*clusterv1.Machine
+	// Release is the release the machines are upgraded to.
+	Release string
+
+	// ControlPlaneVersion is the current Kubernetes version of the control plane.
+	ControlPlaneVersion string
+	// WorkerVersions are the current Kubernetes versions of the workers, by name, when the control plane is upgraded.
+	WorkerVersions map[string]string
+	// Workers is set if the machines are workers, whose version skew is checked against the control plane instead.
+	Workers bool
+}
+
+// NeedsPreflightChecks checks if an in-place upgrade of the object was requested, but did not start yet.
+// Upgrades that are done, or whose machines are all upgraded already, are not checked again.
+func NeedsPreflightChecks(obj client.Object, machines []*clusterv1.Machine) bool {
+	annotations := obj.GetAnnotations()
+	upgradeTo, ok := annotations[bootstrapv1.InPlaceUpgradeToAnnotation]
+	if !ok {
+		return false
+	}
+
+	switch annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] {
+	case bootstrapv1.InPlaceUpgradeInProgressStatus, bootstrapv1.InPlaceUpgradeFailedStatus:
+		return false
+	case bootstrapv1.InPlaceUpgradeDoneStatus:
+		// NOTE: The done status is left by the previous upgrade if another release is requested.
+		if IsUpgraded(obj, upgradeTo) {
+			return false
+		}
+	}
+
+	for _, m := range machines {
+		if !IsUpgraded(m, upgradeTo) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRelease checks that the release refreshes to a channel, a revision or a local path, or upgrades to a
+// Kubernetes version, e.g. "channel=1.31-classic/stable" or "version=v1.31.2".
+func ValidateRelease(release string) error {
+	option, value, ok := strings.Cut(release, "=")
+	if !ok || value == "" {
+		return fmt.Errorf("invalid release %q, expected <option>=<value>", release)
+	}
+
+	switch option {
+	case "channel", "revision", "localPath":
+		return nil
+	case "version":
+		if GetUpgradeVersion(release) == "" {
+			return fmt.Errorf("invalid version %q, expected a Kubernetes version such as v1.31.2", value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid release option %q, expected one of channel, revision, localPath or version", option)
+	}
+}
+
+// PreflightResult is the result of the preflight checks of an in-place upgrade.
+type PreflightResult struct {
+	// Reasons are the reasons why the upgrade cannot start, if any.
+	Reasons []string
+	// Skipped are the checks that were not run.
+	Skipped []string
+}
+
+// RunPreflightChecks checks that the in-place upgrade can start: the release is valid, the upgrade respects the
+// Kubernetes version skew policy, and the cluster is healthy.
+func RunPreflightChecks(ctx context.Context, c client.Client, p Preflight) PreflightResult {
+	result := PreflightResult{Skipped: []string{skippedReleaseCheck}}
+
+	if err := ValidateRelease(p.Release); err != nil {
+		result.Reasons = []string{fmt.Sprintf("target: %v", err)}
+		return result
+	}
+
+	if _, err := ResolveRelease(ctx, c, p.Object, p.Release); err != nil {
+		result.Reasons = []string{fmt.Sprintf("target: %v", err)}
+		return result
+	}
+
+	var err error
+	if p.Workers {
+		err = CheckWorkerVersionSkew(p.Release, p.ControlPlaneVersion)
+	} else {
+		err = CheckVersionSkew(p.Release, p.ControlPlaneVersion, p.WorkerVersions)
+	}
+	if err != nil {
+		result.Reasons = append(result.Reasons, fmt.Sprintf("version skew: %v", err))
+	}
+
+	result.Reasons = append(result.Reasons, checkClusterHealth(p.Cluster, p.Machines)...)
+	return result
+}
+
+// checkClusterHealth checks that the control plane of the cluster is ready, and that the machines have healthy nodes.
+func checkClusterHealth(cluster *clusterv1.Cluster, machines []*clusterv1.Machine) []string {
+	var reasons []string
+	if cluster.Spec.ControlPlaneRef != nil && !conditions.IsTrue(cluster, clusterv1.ControlPlaneReadyCondition) {
+		reasons = append(reasons, "cluster: control plane is not ready")
+	}
+
+	for _, m := range machines {
+		switch {
+		case m.Status.NodeRef == nil:
+			reasons = append(reasons, fmt.Sprintf("machine %q: machine has no node reference", m.Name))
+		case conditions.IsFalse(m, clusterv1.MachineNodeHealthyCondition):
+			reasons = append(reasons, fmt.Sprintf("machine %q: node is not healthy", m.Name))
+		}
+	}
+
+	return reasons
+}
@@ -0,0 +1,165 @@
+package inplace_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+func TestNeedsPreflightChecks(t *testing.T) {
+	g := NewWithT(t)
+
+	machines := []*clusterv1.Machine{
+		{ObjectMeta: metav1.ObjectMeta{Name: "upgraded", Annotations: map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.31-classic/stable"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pending", Annotations: map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.30-classic/stable"}}},
+	}
+
+	md := &clusterv1.MachineDeployment{}
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeFalse())
+
+	md.Annotations = map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.30-classic/stable"}
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeFalse())
+
+	md.Annotations[bootstrapv1.InPlaceUpgradeToAnnotation] = "channel=1.31-classic/stable"
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeTrue())
+
+	// The machines are all upgraded already.
+	g.Expect(inplace.NeedsPreflightChecks(md, machines[:1])).To(BeFalse())
+	g.Expect(inplace.NeedsPreflightChecks(md, nil)).To(BeFalse())
+
+	// The done status is left by the previous upgrade.
+	md.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] = bootstrapv1.InPlaceUpgradeDoneStatus
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeTrue())
+
+	// The upgrade is done.
+	md.Annotations[bootstrapv1.InPlaceUpgradeReleaseAnnotation] = "channel=1.31-classic/stable"
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeFalse())
+
+	md.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] = bootstrapv1.InPlaceUpgradeInProgressStatus
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeFalse())
+
+	md.Annotations[bootstrapv1.InPlaceUpgradeStatusAnnotation] = bootstrapv1.InPlaceUpgradeFailedStatus
+	g.Expect(inplace.NeedsPreflightChecks(md, machines)).To(BeFalse())
+}
+
+func TestValidateRelease(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(inplace.ValidateRelease("channel=1.31-classic/stable")).To(Succeed())
+	g.Expect(inplace.ValidateRelease("revision=1234")).To(Succeed())
+	g.Expect(inplace.ValidateRelease("localPath=/path/to/k8s.snap")).To(Succeed())
+	g.Expect(inplace.ValidateRelease("version=v1.31.2")).To(Succeed())
+
+	g.Expect(inplace.ValidateRelease("1.31-classic/stable")).ToNot(Succeed())
+	g.Expect(inplace.ValidateRelease("channel=")).ToNot(Succeed())
+	g.Expect(inplace.ValidateRelease("version=v1.31")).ToNot(Succeed())
+	g.Expect(inplace.ValidateRelease("track=1.31")).ToNot(Succeed())
+}
+
+func TestRunPreflightChecks(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add to scheme: %v", err)
+	}
+
+	newMachine := func(name string, healthy bool, annotations map[string]string) *clusterv1.Machine {
+		m := &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Annotations: annotations},
+			Status:     clusterv1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: name}},
+		}
+		if healthy {
+			conditions.MarkTrue(m, clusterv1.MachineNodeHealthyCondition)
+		} else {
+			conditions.MarkFalse(m, clusterv1.MachineNodeHealthyCondition, clusterv1.NodeConditionsFailedReason, clusterv1.ConditionSeverityWarning, "")
+		}
+		return m
+	}
+
+	for _, tc := range []struct {
+		name          string
+		preflight     inplace.Preflight
+		controlPlane  bool
+		expectReasons []string
+	}{
+		{
+			name: "controlPlane",
+			preflight: inplace.Preflight{
+				Machines:            []*clusterv1.Machine{newMachine("m-0", true, nil), newMachine("m-1", true, nil)},
+				Release:             "version=v1.31.2",
+				ControlPlaneVersion: "v1.30.4",
+				WorkerVersions:      map[string]string{"md-0": "v1.30.4"},
+			},
+			controlPlane: true,
+		},
+		{
+			name: "workers",
+			preflight: inplace.Preflight{
+				Machines:            []*clusterv1.Machine{newMachine("m-0", true, nil)},
+				Release:             "revision=1234",
+				ControlPlaneVersion: "v1.31.2",
+				Workers:             true,
+			},
+		},
+		{
+			name: "invalidTarget",
+			preflight: inplace.Preflight{
+				Machines: []*clusterv1.Machine{newMachine("m-0", true, nil)},
+				Release:  "version=latest",
+			},
+			expectReasons: []string{`target: invalid version "latest", expected a Kubernetes version such as v1.31.2`},
+		},
+		{
+			name: "versionSkew",
+			preflight: inplace.Preflight{
+				Machines:            []*clusterv1.Machine{newMachine("m-0", true, nil)},
+				Release:             "channel=1.32-classic/stable",
+				ControlPlaneVersion: "v1.31.2",
+				Workers:             true,
+			},
+			expectReasons: []string{"version skew: cannot upgrade the workers to v1.32, which is newer than the control plane on v1.31.2"},
+		},
+		{
+			name: "unhealthy",
+			preflight: inplace.Preflight{
+				Machines: []*clusterv1.Machine{newMachine("m-0", false, nil), newMachine("m-1", true, nil)},
+				Release:  "channel=1.31-classic/stable",
+			},
+			controlPlane: true,
+			expectReasons: []string{
+				"cluster: control plane is not ready",
+				`machine "m-0": node is not healthy`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cluster"}}
+			if tc.controlPlane {
+				cluster.Spec.ControlPlaneRef = &corev1.ObjectReference{Name: "cluster-cp"}
+				if tc.expectReasons == nil {
+					conditions.MarkTrue(cluster, clusterv1.ControlPlaneReadyCondition)
+				}
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+			tc.preflight.Cluster = cluster
+			tc.preflight.Object = &clusterv1.MachineDeployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "md-0"}}
+
+			result := inplace.RunPreflightChecks(context.Background(), c, tc.preflight)
+			g.Expect(result.Reasons).To(Equal(tc.expectReasons))
+			// The availability of the release on the nodes cannot be checked.
+			g.Expect(result.Skipped).To(HaveLen(1))
+		})
+	}
+}
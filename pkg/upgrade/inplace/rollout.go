@@ -0,0 +1,69 @@
+package inplace
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// GetMaxUnavailable returns how many of the machines of the MachineDeployment are upgraded at the same time.
+// Percentages are scaled to the number of machines and rounded down, and at least one machine is upgraded.
+func GetMaxUnavailable(md *clusterv1.MachineDeployment, machines int) (int, error) {
+	maxUnavailable := intstr.FromInt32(1)
+	if value, ok := md.GetAnnotations()[bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation]; ok {
+		maxUnavailable = intstr.Parse(value)
+	} else if strategy := md.Spec.Strategy; strategy != nil && strategy.RollingUpdate != nil && strategy.RollingUpdate.MaxUnavailable != nil {
+		maxUnavailable = *strategy.RollingUpdate.MaxUnavailable
+	}
+
+	n, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, machines, false)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max unavailable %q: %w", maxUnavailable.String(), err)
+	}
+	return max(n, 1), nil
+}
+
+// IsSpreadFailureDomainsEnabled checks if the machines upgraded at the same time are spread across failure domains.
+func IsSpreadFailureDomainsEnabled(md *clusterv1.MachineDeployment) bool {
+	return md.GetAnnotations()[bootstrapv1.InPlaceUpgradeSpreadFailureDomainsAnnotation] == "true"
+}
+
+// SelectMachinesToUpgrade returns up to n of the pending machines to upgrade next, keeping their order.
+// If spread is set, each machine is picked from the failure domain with the fewest upgrading machines instead.
+func SelectMachinesToUpgrade(pending []*clusterv1.Machine, upgrading []*clusterv1.Machine, n int, spread bool) []*clusterv1.Machine {
+	if n <= 0 {
+		return nil
+	}
+	if !spread {
+		return pending[:min(n, len(pending))]
+	}
+
+	failureDomain := func(m *clusterv1.Machine) string {
+		return ptr.Deref(m.Spec.FailureDomain, "")
+	}
+	upgradingPerFailureDomain := make(map[string]int)
+	for _, m := range upgrading {
+		upgradingPerFailureDomain[failureDomain(m)]++
+	}
+
+	var selected []*clusterv1.Machine
+	remaining := slices.Clone(pending)
+	for len(selected) < n && len(remaining) > 0 {
+		next := 0
+		for i, m := range remaining {
+			if upgradingPerFailureDomain[failureDomain(m)] < upgradingPerFailureDomain[failureDomain(remaining[next])] {
+				next = i
+			}
+		}
+
+		selected = append(selected, remaining[next])
+		upgradingPerFailureDomain[failureDomain(remaining[next])]++
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return selected
+}
@@ -0,0 +1,133 @@
+package inplace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+func TestGetMaxUnavailable(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		annotations    map[string]string
+		strategy       *clusterv1.MachineDeploymentStrategy
+		machines       int
+		maxUnavailable int
+		expectErr      bool
+	}{
+		{
+			name:           "default",
+			machines:       10,
+			maxUnavailable: 1,
+		},
+		{
+			name:           "annotation",
+			annotations:    map[string]string{bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation: "3"},
+			machines:       10,
+			maxUnavailable: 3,
+		},
+		{
+			name:           "annotationPercentage",
+			annotations:    map[string]string{bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation: "25%"},
+			machines:       10,
+			maxUnavailable: 2,
+		},
+		{
+			name:           "annotationZero",
+			annotations:    map[string]string{bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation: "0"},
+			machines:       10,
+			maxUnavailable: 1,
+		},
+		{
+			name:        "annotationInvalid",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation: "many%"},
+			machines:    10,
+			expectErr:   true,
+		},
+		{
+			name: "strategy",
+			strategy: &clusterv1.MachineDeploymentStrategy{
+				RollingUpdate: &clusterv1.MachineRollingUpdateDeployment{MaxUnavailable: ptr.To(intstr.FromString("50%"))},
+			},
+			machines:       10,
+			maxUnavailable: 5,
+		},
+		{
+			name:        "annotationOverridesStrategy",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeMaxUnavailableAnnotation: "2"},
+			strategy: &clusterv1.MachineDeploymentStrategy{
+				RollingUpdate: &clusterv1.MachineRollingUpdateDeployment{MaxUnavailable: ptr.To(intstr.FromInt32(4))},
+			},
+			machines:       10,
+			maxUnavailable: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			md := &clusterv1.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec:       clusterv1.MachineDeploymentSpec{Strategy: tc.strategy},
+			}
+
+			maxUnavailable, err := inplace.GetMaxUnavailable(md, tc.machines)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(maxUnavailable).To(Equal(tc.maxUnavailable))
+		})
+	}
+}
+
+func TestSelectMachinesToUpgrade(t *testing.T) {
+	newMachine := func(name string, failureDomain string) *clusterv1.Machine {
+		return &clusterv1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       clusterv1.MachineSpec{FailureDomain: ptr.To(failureDomain)},
+		}
+	}
+	names := func(machines []*clusterv1.Machine) []string {
+		var result []string
+		for _, m := range machines {
+			result = append(result, m.Name)
+		}
+		return result
+	}
+
+	pending := []*clusterv1.Machine{
+		newMachine("m1", "a"),
+		newMachine("m2", "a"),
+		newMachine("m3", "b"),
+		newMachine("m4", "c"),
+	}
+	upgrading := []*clusterv1.Machine{
+		newMachine("m0", "c"),
+	}
+
+	t.Run("InOrder", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(names(inplace.SelectMachinesToUpgrade(pending, upgrading, 2, false))).To(Equal([]string{"m1", "m2"}))
+		g.Expect(names(inplace.SelectMachinesToUpgrade(pending, upgrading, 10, false))).To(Equal([]string{"m1", "m2", "m3", "m4"}))
+	})
+
+	t.Run("SpreadFailureDomains", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(names(inplace.SelectMachinesToUpgrade(pending, upgrading, 2, true))).To(Equal([]string{"m1", "m3"}))
+		g.Expect(names(inplace.SelectMachinesToUpgrade(pending, upgrading, 4, true))).To(Equal([]string{"m1", "m3", "m2", "m4"}))
+	})
+
+	t.Run("NoCapacity", func(t *testing.T) {
+		g := NewWithT(t)
+		g.Expect(inplace.SelectMachinesToUpgrade(pending, upgrading, 0, false)).To(BeEmpty())
+		g.Expect(inplace.SelectMachinesToUpgrade(pending, upgrading, -1, true)).To(BeEmpty())
+	})
+}
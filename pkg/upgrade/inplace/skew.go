@@ -0,0 +1,93 @@
+package inplace
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// MaxWorkerVersionSkew is how many minor versions the kubelet of the workers may be older than the API server.
+const MaxWorkerVersionSkew = 3
+
+// GetCurrentVersion returns the Kubernetes version the object was last upgraded to in-place, if it can be derived
+// from the release, or its version otherwise.
+func GetCurrentVersion(obj client.Object, specVersion string) string {
+	if v := GetReleaseVersion(obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeReleaseAnnotation]); v != "" {
+		return v
+	}
+	return specVersion
+}
+
+// IsSameMinorVersion returns whether two Kubernetes versions have the same major and minor versions.
+// Versions that cannot be parsed are compared as-is.
+func IsSameMinorVersion(a string, b string) bool {
+	va, errA := version.ParseGeneric(a)
+	vb, errB := version.ParseGeneric(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Major() == vb.Major() && va.Minor() == vb.Minor()
+}
+
+// CheckVersionSkew checks that upgrading the control plane from its current version to the version of the release,
+// and then the workers from their current versions, respects the Kubernetes version skew policy: the control plane
+// is upgraded one minor version at a time, and the workers never fall more than MaxWorkerVersionSkew minor versions
+// behind it. Versions that are unknown or cannot be parsed are not checked.
+func CheckVersionSkew(release string, controlPlaneVersion string, workerVersions map[string]string) error {
+	target, err := version.ParseGeneric(GetReleaseVersion(release))
+	if err != nil {
+		return nil
+	}
+
+	if current, err := version.ParseGeneric(controlPlaneVersion); err == nil {
+		switch {
+		case current.Major() != target.Major():
+			return fmt.Errorf("cannot upgrade the control plane from %s to another major version %s", controlPlaneVersion, GetReleaseVersion(release))
+		case target.Minor() < current.Minor():
+			return fmt.Errorf("cannot downgrade the control plane from %s to %s", controlPlaneVersion, GetReleaseVersion(release))
+		case target.Minor() > current.Minor()+1:
+			return fmt.Errorf("cannot upgrade the control plane from %s to %s, minor versions cannot be skipped", controlPlaneVersion, GetReleaseVersion(release))
+		}
+	}
+
+	for name, workerVersion := range workerVersions {
+		current, err := version.ParseGeneric(workerVersion)
+		if err != nil {
+			continue
+		}
+		if current.Major() != target.Major() || target.Minor() > current.Minor()+MaxWorkerVersionSkew {
+			return fmt.Errorf("workers of %s on %s would be more than %d minor versions older than the control plane on %s", name, workerVersion, MaxWorkerVersionSkew, GetReleaseVersion(release))
+		}
+	}
+
+	return nil
+}
+
+// CheckWorkerVersionSkew checks that upgrading workers to the version of the release respects the Kubernetes version
+// skew policy against the control plane: the workers are never newer than the control plane, nor more than
+// MaxWorkerVersionSkew minor versions older. Versions that are unknown or cannot be parsed are not checked.
+func CheckWorkerVersionSkew(release string, controlPlaneVersion string) error {
+	target, err := version.ParseGeneric(GetReleaseVersion(release))
+	if err != nil {
+		return nil
+	}
+
+	current, err := version.ParseGeneric(controlPlaneVersion)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case current.Major() != target.Major():
+		return fmt.Errorf("cannot upgrade the workers to %s, the control plane is on another major version %s", GetReleaseVersion(release), controlPlaneVersion)
+	case target.Minor() > current.Minor():
+		return fmt.Errorf("cannot upgrade the workers to %s, which is newer than the control plane on %s", GetReleaseVersion(release), controlPlaneVersion)
+	case current.Minor() > target.Minor()+MaxWorkerVersionSkew:
+		return fmt.Errorf("workers on %s would be more than %d minor versions older than the control plane on %s", GetReleaseVersion(release), MaxWorkerVersionSkew, controlPlaneVersion)
+	}
+
+	return nil
+}
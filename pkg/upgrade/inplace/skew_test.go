@@ -0,0 +1,110 @@
+package inplace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+func TestGetCurrentVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	md := &clusterv1.MachineDeployment{}
+	g.Expect(inplace.GetCurrentVersion(md, "v1.30.1")).To(Equal("v1.30.1"))
+
+	md.Annotations = map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "revision=123"}
+	g.Expect(inplace.GetCurrentVersion(md, "v1.30.1")).To(Equal("v1.30.1"))
+
+	md.Annotations = map[string]string{bootstrapv1.InPlaceUpgradeReleaseAnnotation: "channel=1.31-classic/stable"}
+	g.Expect(inplace.GetCurrentVersion(md, "v1.30.1")).To(Equal("v1.31"))
+}
+
+func TestIsSameMinorVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(inplace.IsSameMinorVersion("v1.30.1", "v1.30.1")).To(BeTrue())
+	g.Expect(inplace.IsSameMinorVersion("v1.30.1", "v1.30.4")).To(BeTrue())
+	g.Expect(inplace.IsSameMinorVersion("v1.30.1", "v1.31.0")).To(BeFalse())
+	g.Expect(inplace.IsSameMinorVersion("v1.30.1", "v2.30.1")).To(BeFalse())
+	g.Expect(inplace.IsSameMinorVersion("unknown", "unknown")).To(BeTrue())
+	g.Expect(inplace.IsSameMinorVersion("unknown", "v1.30.1")).To(BeFalse())
+}
+
+func TestCheckVersionSkew(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		release             string
+		controlPlaneVersion string
+		workerVersions      map[string]string
+		expectErr           bool
+	}{
+		{
+			name:                "minorUpgrade",
+			release:             "channel=1.31-classic/stable",
+			controlPlaneVersion: "v1.30.4",
+			workerVersions:      map[string]string{"md-0": "v1.28.0"},
+		},
+		{
+			name:                "patchUpgrade",
+			release:             "channel=1.31-classic/stable",
+			controlPlaneVersion: "v1.31.1",
+		},
+		{
+			name:                "unknownRelease",
+			release:             "revision=123",
+			controlPlaneVersion: "v1.28.0",
+		},
+		{
+			name:                "unknownVersions",
+			release:             "channel=1.31-classic/stable",
+			controlPlaneVersion: "",
+			workerVersions:      map[string]string{"md-0": ""},
+		},
+		{
+			name:                "downgrade",
+			release:             "channel=1.30-classic/stable",
+			controlPlaneVersion: "v1.31.0",
+			expectErr:           true,
+		},
+		{
+			name:                "skipMinor",
+			release:             "channel=1.32-classic/stable",
+			controlPlaneVersion: "v1.30.0",
+			expectErr:           true,
+		},
+		{
+			name:                "workersTooOld",
+			release:             "channel=1.31-classic/stable",
+			controlPlaneVersion: "v1.30.0",
+			workerVersions:      map[string]string{"md-0": "v1.30.0", "md-1": "v1.27.3"},
+			expectErr:           true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := inplace.CheckVersionSkew(tc.release, tc.controlPlaneVersion, tc.workerVersions)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestCheckWorkerVersionSkew(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(inplace.CheckWorkerVersionSkew("channel=1.31-classic/stable", "v1.31.2")).To(Succeed())
+	g.Expect(inplace.CheckWorkerVersionSkew("version=v1.29.4", "v1.31.2")).To(Succeed())
+	g.Expect(inplace.CheckWorkerVersionSkew("revision=123", "v1.31.2")).To(Succeed())
+	g.Expect(inplace.CheckWorkerVersionSkew("channel=1.31-classic/stable", "")).To(Succeed())
+
+	g.Expect(inplace.CheckWorkerVersionSkew("channel=1.32-classic/stable", "v1.31.2")).ToNot(Succeed())
+	g.Expect(inplace.CheckWorkerVersionSkew("channel=1.27-classic/stable", "v1.31.2")).ToNot(Succeed())
+}
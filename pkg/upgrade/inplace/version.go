@@ -0,0 +1,78 @@
+package inplace
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+)
+
+// defaultRisk is the risk level of the snap channel "version=" releases are resolved to.
+const defaultRisk = "stable"
+
+var (
+	upgradeVersionRegexp = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+	snapRisks            = []string{"stable", "candidate", "beta", "edge"}
+)
+
+// GetUpgradeVersion returns the Kubernetes version (e.g. "v1.31.2") of a release that upgrades to a version
+// (e.g. "version=v1.31.2"), or an empty string for other releases.
+func GetUpgradeVersion(release string) string {
+	option, value, ok := strings.Cut(release, "=")
+	if !ok || option != "version" {
+		return ""
+	}
+	match := upgradeVersionRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	return fmt.Sprintf("v%s.%s.%s", match[1], match[2], match[3])
+}
+
+// ResolveRelease resolves a release that upgrades the machine to a Kubernetes version (e.g. "version=v1.31.2")
+// to the snap revision the version is mapped to by the ConfigMap of the InPlaceUpgradeVersionMapAnnotation of the
+// machine if set, or to the snap channel of its minor version (e.g. "channel=1.31-classic/stable") otherwise.
+// Other releases are returned as is. The object may also be the CK8sControlPlane or MachineDeployment the
+// annotations are propagated from.
+func ResolveRelease(ctx context.Context, c client.Client, obj client.Object, release string) (string, error) {
+	option, value, _ := strings.Cut(release, "=")
+	if option != "version" {
+		return release, nil
+	}
+
+	match := upgradeVersionRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("invalid version %q, expected a Kubernetes version such as v1.31.2", value)
+	}
+	version := GetUpgradeVersion(release)
+
+	if name := obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeVersionMapAnnotation]; name != "" {
+		configMap := &corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: name}, configMap); err != nil {
+			return "", fmt.Errorf("failed to get version map ConfigMap %q: %w", name, err)
+		}
+
+		for _, key := range []string{version, strings.TrimPrefix(version, "v")} {
+			if revision := strings.TrimSpace(configMap.Data[key]); revision != "" {
+				return "revision=" + revision, nil
+			}
+		}
+		return "", fmt.Errorf("version %s is not mapped to a snap revision by ConfigMap %q", version, name)
+	}
+
+	risk := defaultRisk
+	if value, ok := obj.GetAnnotations()[bootstrapv1.InPlaceUpgradeRiskAnnotation]; ok {
+		risk = value
+	}
+	if !slices.Contains(snapRisks, risk) {
+		return "", fmt.Errorf("invalid risk level %q, expected one of %s", risk, strings.Join(snapRisks, ", "))
+	}
+
+	return fmt.Sprintf("channel=%s.%s-classic/%s", match[1], match[2], risk), nil
+}
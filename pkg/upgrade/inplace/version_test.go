@@ -0,0 +1,113 @@
+package inplace_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	bootstrapv1 "github.com/canonical/cluster-api-k8s/bootstrap/api/v1beta2"
+	"github.com/canonical/cluster-api-k8s/pkg/upgrade/inplace"
+)
+
+func TestGetUpgradeVersion(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(inplace.GetUpgradeVersion("version=v1.31.2")).To(Equal("v1.31.2"))
+	g.Expect(inplace.GetUpgradeVersion("version=1.31.2")).To(Equal("v1.31.2"))
+	g.Expect(inplace.GetUpgradeVersion("version=v1.31")).To(BeEmpty())
+	g.Expect(inplace.GetUpgradeVersion("channel=1.31-classic/stable")).To(BeEmpty())
+	g.Expect(inplace.GetUpgradeVersion("revision=123")).To(BeEmpty())
+}
+
+func TestResolveRelease(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add to scheme: %v", err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "k8s-revisions"},
+		Data: map[string]string{
+			"v1.31.2": "1234",
+			"1.32.0":  "1300",
+		},
+	}).Build()
+
+	for _, tc := range []struct {
+		name        string
+		release     string
+		annotations map[string]string
+		expected    string
+		expectErr   bool
+	}{
+		{
+			name:     "channel",
+			release:  "channel=1.30-classic/stable",
+			expected: "channel=1.30-classic/stable",
+		},
+		{
+			name:     "version",
+			release:  "version=v1.31.2",
+			expected: "channel=1.31-classic/stable",
+		},
+		{
+			name:        "versionWithRisk",
+			release:     "version=1.31.2",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeRiskAnnotation: "candidate"},
+			expected:    "channel=1.31-classic/candidate",
+		},
+		{
+			name:        "invalidRisk",
+			release:     "version=v1.31.2",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeRiskAnnotation: "unstable"},
+			expectErr:   true,
+		},
+		{
+			name:      "invalidVersion",
+			release:   "version=v1.31",
+			expectErr: true,
+		},
+		{
+			name:        "versionMap",
+			release:     "version=v1.31.2",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeVersionMapAnnotation: "k8s-revisions"},
+			expected:    "revision=1234",
+		},
+		{
+			name:        "versionMapWithoutPrefix",
+			release:     "version=v1.32.0",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeVersionMapAnnotation: "k8s-revisions"},
+			expected:    "revision=1300",
+		},
+		{
+			name:        "versionNotMapped",
+			release:     "version=v1.33.0",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeVersionMapAnnotation: "k8s-revisions"},
+			expectErr:   true,
+		},
+		{
+			name:        "versionMapNotFound",
+			release:     "version=v1.31.2",
+			annotations: map[string]string{bootstrapv1.InPlaceUpgradeVersionMapAnnotation: "missing"},
+			expectErr:   true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "machine", Annotations: tc.annotations}}
+			release, err := inplace.ResolveRelease(context.Background(), c, m, tc.release)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(release).To(Equal(tc.expected))
+		})
+	}
+}